@@ -7,4 +7,5 @@ require (
 	github.com/google/uuid v1.1.2
 	github.com/rs/zerolog v1.19.0
 	github.com/spf13/viper v1.7.1
+	gopkg.in/yaml.v2 v2.2.4
 )
@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"strings"
+	"testing"
 
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/bradmccoydev/self-service-sdk/sdk/aws/auth"
@@ -31,6 +32,14 @@ const (
 	TestValidAwsRegion string = "TESTING_AWS_DEFAULT_REGION"
 	// TestValidAwsUserID - the env var for ***PASSING IN*** a valid AWS user id
 	TestValidAwsUserID string = "TESTING_AWS_USER_ID"
+	// LocalAwsKey - the dummy AWS key used against local stand-ins
+	LocalAwsKey string = "local"
+	// LocalAwsSecret - the dummy AWS secret used against local stand-ins
+	LocalAwsSecret string = "local"
+	// LocalAwsRegion - the region used against local stand-ins when none is given
+	LocalAwsRegion string = "us-east-1"
+	// LocalAwsUserID - the dummy AWS user id used against local stand-ins
+	LocalAwsUserID string = "000000000000"
 )
 
 // AwsCreds - Structure for handling AWS credentials
@@ -70,8 +79,9 @@ func CreateAwsSession(valid bool) *session.Session {
 		os.Setenv(EnvAwsRegion, creds.Region)
 	}
 
-	// Create the session
-	sess, err := auth.NewSession()
+	// Create the session, honouring any SELFSERVICE_ENDPOINT_* overrides
+	// so the tests can be run against local stand-ins
+	sess, err := auth.NewCustomSession(auth.WithEndpointsFromEnv())
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -81,9 +91,15 @@ func CreateAwsSession(valid bool) *session.Session {
 }
 
 // LoadAwsCreds - attempts to load the AWS credentials (key, secret, region)
-// from the TESTING_* environment variables
+// from the TESTING_* environment variables. When a SELFSERVICE_ENDPOINT_*
+// override points at a local stand-in, missing values fall back to dummy ones.
 func LoadAwsCreds() (AwsCreds, error) {
 
+	// Local stand-ins don't need real credentials
+	if len(auth.EndpointsFromEnv().Endpoints) > 0 {
+		return loadLocalAwsCreds(), nil
+	}
+
 	// We need to grab valid AWS credentials from environment variables.
 	// If any of these don't exist then we fail.
 	var values AwsCreds
@@ -117,6 +133,29 @@ func LoadAwsCreds() (AwsCreds, error) {
 	return values, err
 }
 
+// loadLocalAwsCreds - loads the TESTING_* environment variables, using
+// dummy values for any that aren't set
+func loadLocalAwsCreds() AwsCreds {
+
+	values := AwsCreds{
+		Key:    os.Getenv(TestValidAwsKey),
+		Secret: os.Getenv(TestValidAwsSecret),
+		Region: os.Getenv(TestValidAwsRegion),
+		Userid: os.Getenv(TestValidAwsUserID),
+	}
+	if values.Key == "" || values.Secret == "" {
+		values.Key = LocalAwsKey
+		values.Secret = LocalAwsSecret
+	}
+	if values.Region == "" {
+		values.Region = LocalAwsRegion
+	}
+	if values.Userid == "" {
+		values.Userid = LocalAwsUserID
+	}
+	return values
+}
+
 // PerformAwsTests - checks whether the TESTING_AWS_ENABLED
 // environment variable is set to TRUE
 func PerformAwsTests() bool {
@@ -131,3 +170,11 @@ func PerformAwsTests() bool {
 	// Return the values
 	return doAwsTests
 }
+
+// SkipAwsTests - skips the calling test if the TESTING_AWS_ENABLED
+// environment variable is not set to TRUE
+func SkipAwsTests(tb testing.TB) {
+	if !PerformAwsTests() {
+		tb.Skipf("AWS testing variable: %s not set or set to false", TestAwsEnabled)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"sync"
 	"testing"
 )
 
@@ -43,3 +44,19 @@ func NoError(tb testing.TB, err error) {
 		tb.FailNow()
 	}
 }
+
+// Sequence returns a fake service handler that walks through the provided
+// responses, repeating the last one.
+func Sequence(responses ...func() (int, interface{})) func(input map[string]interface{}) (int, interface{}) {
+	var mu sync.Mutex
+	i := 0
+	return func(input map[string]interface{}) (int, interface{}) {
+		mu.Lock()
+		r := responses[i]
+		if i < len(responses)-1 {
+			i++
+		}
+		mu.Unlock()
+		return r()
+	}
+}
@@ -0,0 +1,124 @@
+// This file contains all the bits & pieces related to
+// creating sessions by assuming IAM roles via STS
+
+package auth
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+const (
+	// DefaultRoleSessionName - the session name used when none is provided
+	DefaultRoleSessionName string = "self-service-sdk"
+
+	// DefaultExpiryWindow - how long before expiry the credentials are refreshed
+	DefaultExpiryWindow time.Duration = 5 * time.Minute
+
+	// MinRoleDuration - the minimum duration STS allows for an assumed role
+	MinRoleDuration time.Duration = 15 * time.Minute
+
+	// MaxRoleDuration - the maximum duration STS allows for an assumed role
+	MaxRoleDuration time.Duration = 12 * time.Hour
+)
+
+// AssumeRoleConf - structure used to represent the role to assume
+type AssumeRoleConf struct {
+	RoleArn      string
+	ExternalID   string
+	SessionName  string
+	Duration     time.Duration
+	ExpiryWindow time.Duration
+}
+
+// NewAssumeRoleSession - This function creates an AWS session whose credentials
+// are obtained by assuming the provided role(s). When more than one role is
+// provided they are chained, ie each role is assumed using the credentials of
+// the role before it. The credentials are refreshed automatically before they expire.
+//
+//   Parameters:
+//     base: a valid AWS session used to assume the first role
+//     roles: an array of the role(s) to assume
+//
+//   Example:
+//     sess, err := NewAssumeRoleSession(mySession, roles)
+func NewAssumeRoleSession(base *session.Session, roles []AssumeRoleConf) (*session.Session, error) {
+
+	// Sanity check
+	if base == nil {
+		return nil, newErrorBaseSessionNotProvided()
+	}
+	if len(roles) == 0 {
+		return nil, newErrorRolesNotProvided()
+	}
+	for _, r := range roles {
+		err := validateAssumeRoleConf(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Assume each role in turn using the session from the previous step
+	sess := base
+	for _, r := range roles {
+		creds := stscreds.NewCredentials(sess, r.RoleArn, assumeRoleOptions(r))
+		sess = sess.Copy(&aws.Config{Credentials: creds})
+	}
+
+	// Return the session
+	return sess, nil
+}
+
+// validateAssumeRoleConf checks the role configuration is usable
+func validateAssumeRoleConf(role AssumeRoleConf) error {
+
+	// Check the ARN
+	if role.RoleArn == "" {
+		return newErrorRoleArnNotProvided()
+	}
+	_, err := arn.Parse(role.RoleArn)
+	if err != nil {
+		return newErrorRoleArnInvalid(role.RoleArn, err)
+	}
+
+	// Check the duration (zero means use the default)
+	if role.Duration != 0 && (role.Duration < MinRoleDuration || role.Duration > MaxRoleDuration) {
+		return newErrorRoleDurationInvalid(role.Duration)
+	}
+
+	return nil
+}
+
+// assumeRoleOptions maps the role configuration onto the STS credential provider
+func assumeRoleOptions(role AssumeRoleConf) func(*stscreds.AssumeRoleProvider) {
+
+	return func(p *stscreds.AssumeRoleProvider) {
+
+		// Session name
+		p.RoleSessionName = DefaultRoleSessionName
+		if role.SessionName != "" {
+			p.RoleSessionName = role.SessionName
+		}
+
+		// External ID
+		if role.ExternalID != "" {
+			p.ExternalID = aws.String(role.ExternalID)
+		}
+
+		// Duration
+		p.Duration = stscreds.DefaultDuration
+		if role.Duration != 0 {
+			p.Duration = role.Duration
+		}
+
+		// Refresh window
+		p.ExpiryWindow = DefaultExpiryWindow
+		if role.ExpiryWindow != 0 {
+			p.ExpiryWindow = role.ExpiryWindow
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package auth_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/bradmccoydev/self-service-sdk/internal"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/auth"
+)
+
+// Test NewAssumeRoleSession
+func TestNewAssumeRoleSession(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeSts()
+	defer fake.Server.Close()
+
+	// Setup role test data
+	var noRoles []auth.AssumeRoleConf
+	noArn := []auth.AssumeRoleConf{{SessionName: "fred"}}
+	invalidArn := []auth.AssumeRoleConf{{RoleArn: TestRoleArnInvalid}}
+	shortDuration := []auth.AssumeRoleConf{{RoleArn: TestRoleArnValid, Duration: time.Minute}}
+	singleRole := []auth.AssumeRoleConf{{RoleArn: TestRoleArnValid, ExternalID: "ext-123", SessionName: "fred"}}
+	chainedRoles := []auth.AssumeRoleConf{{RoleArn: TestRoleArnValid}, {RoleArn: TestRoleArnChained}}
+
+	// Setup test data
+	tests := []struct {
+		desc        string
+		validSess   bool
+		roles       []auth.AssumeRoleConf
+		expectErr   bool
+		expectCalls int
+	}{
+		{"No inputs", false, noRoles, true, 0},
+		{"Just session", true, noRoles, true, 0},
+		{"Session & role without ARN", true, noArn, true, 0},
+		{"Session & invalid role ARN", true, invalidArn, true, 0},
+		{"Session & invalid duration", true, shortDuration, true, 0},
+		{"Session & single role", true, singleRole, false, 1},
+		{"Session & chained roles", true, chainedRoles, false, 2},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Run the test
+			var base *session.Session
+			if test.validSess {
+				base = fake.Session()
+			}
+			before := len(fake.Calls())
+			sess, err := auth.NewAssumeRoleSession(base, test.roles)
+			if test.expectErr {
+				internal.HasError(t, err)
+				return
+			}
+			internal.NoError(t, err)
+
+			// Fetching the credentials should assume each role in turn
+			creds, err := sess.Config.Credentials.Get()
+			internal.NoError(t, err)
+			calls := fake.Calls()[before:]
+			internal.Equals(t, test.expectCalls, len(calls))
+			internal.Assert(t, creds.AccessKeyID != TestBaseAccessKey, "expected assumed role credentials")
+			for i, call := range calls {
+				internal.Equals(t, "AssumeRole", call["Action"])
+				internal.Equals(t, test.roles[i].RoleArn, call["RoleArn"])
+				if i > 0 {
+					internal.Assert(t, strings.Contains(call["Authorization"], "Credential=AKIDROLE"), "expected chained role to be signed by the previous role")
+				}
+			}
+
+			// Check the optional values were passed through
+			first := calls[0]
+			internal.Equals(t, test.roles[0].ExternalID, first["ExternalId"])
+			if test.roles[0].SessionName != "" {
+				internal.Equals(t, test.roles[0].SessionName, first["RoleSessionName"])
+			} else {
+				internal.Equals(t, auth.DefaultRoleSessionName, first["RoleSessionName"])
+			}
+		})
+	}
+}
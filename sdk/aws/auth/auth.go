@@ -1,9 +1,22 @@
 // Package auth provides a simplified api to perform common
 // AWS authentication operations.
 //
+//   The following self service SDK packages are used:
+//     * sdk/aws/secretsmanager
+//
 //   The following AWS GoLang SDK packages are used:
 //     * aws
+//     * aws/arn
+//     * aws/awserr
+//     * aws/credentials
+//     * aws/credentials/stscreds
+//     * aws/defaults
+//     * aws/endpoints
 //     * aws/session
+//     * aws/signer/v4
+//     * service/dynamodb
+//     * service/secretsmanager
+//     * service/sts
 package auth
 
 import (
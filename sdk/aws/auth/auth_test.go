@@ -57,17 +57,14 @@ func manageTestEnvVar(t *testing.T, testdata testdef) func(t *testing.T) {
 // TestMain routine for controlling setup/destruction for all tests in this package
 func TestMain(m *testing.M) {
 
-	// Do we need to do these tests?
-	var doTests bool = internal.PerformAwsTests()
-	if doTests == false {
-		os.Exit(0)
-	}
-
-	// Set the global variable to make the values available for all tests
-	var err error = nil
-	AwsCreds, err = internal.LoadAwsCreds()
-	if err != nil {
-		log.Fatal(err)
+	// Do we need to do the AWS tests? If so, set the global variable
+	// to make the values available for all tests
+	if internal.PerformAwsTests() {
+		var err error = nil
+		AwsCreds, err = internal.LoadAwsCreds()
+		if err != nil {
+			log.Fatal(err)
+		}
 	}
 
 	// Run the various tests then exit
@@ -78,6 +75,9 @@ func TestMain(m *testing.M) {
 // Test NewSession
 func TestNewSession(t *testing.T) {
 
+	// Only run against a real AWS account
+	internal.SkipAwsTests(t)
+
 	// Setup test data
 	var tests = []testdef{
 		{"No values", false, "", false, "", false, "", false, "", false, "", false, ""},
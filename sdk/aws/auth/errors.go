@@ -0,0 +1,145 @@
+// This file contains all the bits & pieces related to
+// error messages for the auth package.
+
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrCredentialsNotFound - no credentials could be found for the session
+	ErrCredentialsNotFound = errors.New("No AWS credentials were found")
+
+	// ErrCredentialsExpired - the session credentials have expired
+	ErrCredentialsExpired = errors.New("The AWS credentials have expired")
+
+	// ErrCredentialsInvalid - the session credentials were rejected by AWS
+	ErrCredentialsInvalid = errors.New("The AWS credentials are invalid")
+)
+
+/***
+Session errors
+***/
+
+func newErrorSessionNotProvided() error {
+	return errors.New("A session must be provided")
+}
+
+func newErrorRegionNotProvided() error {
+	return errors.New("A region must be provided")
+}
+
+func newErrorRegionNotResolved() error {
+	return errors.New("A region could not be resolved from the configuration, environment or profile")
+}
+
+func newWarningRegionUnknown(region string, source string) string {
+	return fmt.Sprintf("The region %s (from %s) is not a known AWS region", region, source)
+}
+
+func newErrorClientServiceNotProvided() error {
+	return errors.New("A service name must be provided for the client")
+}
+
+func newErrorClientCreatorNotProvided() error {
+	return errors.New("A function to create the client must be provided")
+}
+
+/***
+Credential errors
+***/
+
+func newErrorCredentialsNotFound(err error) error {
+	return fmt.Errorf("%w: %v", ErrCredentialsNotFound, err)
+}
+
+func newErrorCredentialsExpired(err error) error {
+	return fmt.Errorf("%w: %v", ErrCredentialsExpired, err)
+}
+
+func newErrorCredentialsInvalid(err error) error {
+	return fmt.Errorf("%w: %v", ErrCredentialsInvalid, err)
+}
+
+func newErrorCredentialsNotProvided() error {
+	return errors.New("Credentials must be provided")
+}
+
+func newErrorCredentialKeyNotSet(key string, providerName string) error {
+	return fmt.Errorf("%w: the key %s was not set for %s", ErrCredentialsNotFound, key, providerName)
+}
+
+func newErrorConfigNotProvided() error {
+	return errors.New("A configuration must be provided")
+}
+
+func newErrorSecretNameNotProvided() error {
+	return errors.New("A secret name must be provided")
+}
+
+/***
+Endpoint errors
+***/
+
+func newErrorEndpointServiceNotProvided() error {
+	return errors.New("A service must be provided for each endpoint override")
+}
+
+func newErrorEndpointURLNotProvided(service string) error {
+	return fmt.Errorf("The endpoint override for service %s did not include a URL", service)
+}
+
+/***
+Profile errors
+***/
+
+func newErrorProfileNotProvided() error {
+	return errors.New("A profile name must be provided")
+}
+
+func newErrorProfileNotFound(profile string) error {
+	return fmt.Errorf("The profile %s was not found in the shared config files", profile)
+}
+
+func newErrorSharedConfigFilesNotProvided() error {
+	return errors.New("At least one shared config file must be provided")
+}
+
+func newErrorMFATokenProviderNotProvided() error {
+	return errors.New("An MFA token provider must be provided")
+}
+
+func newErrorMFATokenNotSet(envVar string) error {
+	return fmt.Errorf("The environment variable %s containing the MFA token is not set", envVar)
+}
+
+/***
+Assume role errors
+***/
+
+func newErrorBaseSessionNotProvided() error {
+	return errors.New("A base session must be provided")
+}
+
+func newErrorRolesNotProvided() error {
+	return errors.New("At least one role must be provided")
+}
+
+func newErrorRoleArnNotProvided() error {
+	return errors.New("A role ARN must be provided")
+}
+
+func newErrorRoleArnInvalid(roleArn string, err error) error {
+	return fmt.Errorf("The role ARN %s is invalid: %v", roleArn, err)
+}
+
+func newErrorWebIdentityTokenNotProvided() error {
+	return errors.New("Either a web identity token file or token provider must be provided")
+}
+
+func newErrorRoleDurationInvalid(duration time.Duration) error {
+	return fmt.Errorf("The role duration %s must be between %s and %s", duration, MinRoleDuration, MaxRoleDuration)
+}
@@ -0,0 +1,95 @@
+// This file contains all the bits & pieces related to
+// verifying credentials & identifying the caller
+
+package auth
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/clients"
+)
+
+// assumedRolePrefix - the resource prefix STS uses for assumed role ARNs
+const assumedRolePrefix string = "assumed-role/"
+
+// CallerIdentity - structure used to represent the identity behind a session
+type CallerIdentity struct {
+	Account     string
+	Arn         string
+	UserID      string
+	AssumedRole bool
+	RoleName    string
+	SessionName string
+}
+
+// VerifyCredentials - This function checks that the session has valid credentials
+// by calling STS GetCallerIdentity. Any credential failure is classified as one of
+// ErrCredentialsNotFound, ErrCredentialsExpired or ErrCredentialsInvalid, which
+// can be checked with errors.Is.
+//
+//   Parameters:
+//     sess: the AWS session to verify
+//
+//   Example:
+//     id, err := VerifyCredentials(mySession)
+func VerifyCredentials(sess *session.Session) (CallerIdentity, error) {
+
+	// Sanity check
+	var identity CallerIdentity
+	if sess == nil {
+		return identity, newErrorSessionNotProvided()
+	}
+
+	// Create the STS client
+	svc := clients.STS(sess)
+
+	// Make the call to STS
+	result, err := svc.GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		return identity, classifyCredentialError(err)
+	}
+
+	// Populate the identity
+	identity.Account = aws.StringValue(result.Account)
+	identity.Arn = aws.StringValue(result.Arn)
+	identity.UserID = aws.StringValue(result.UserId)
+
+	// Work out if this is an assumed role (arn:aws:sts::123:assumed-role/role/session)
+	parsed, err := arn.Parse(identity.Arn)
+	if err == nil && parsed.Service == sts.EndpointsID && strings.HasPrefix(parsed.Resource, assumedRolePrefix) {
+		identity.AssumedRole = true
+		parts := strings.SplitN(strings.TrimPrefix(parsed.Resource, assumedRolePrefix), "/", 2)
+		identity.RoleName = parts[0]
+		if len(parts) > 1 {
+			identity.SessionName = parts[1]
+		}
+	}
+
+	// Return it
+	return identity, nil
+}
+
+// classifyCredentialError maps credential related AWS errors onto the
+// package error values, anything else is returned untouched
+func classifyCredentialError(err error) error {
+
+	aerr, ok := err.(awserr.Error)
+	if !ok {
+		return err
+	}
+	switch aerr.Code() {
+	case "NoCredentialProviders", "EnvAccessKeyNotFound", "EnvSecretNotFound", "EmptyStaticCreds", "SharedCredsLoad":
+		return newErrorCredentialsNotFound(err)
+	case "ExpiredToken", "ExpiredTokenException", "RequestExpired":
+		return newErrorCredentialsExpired(err)
+	case "InvalidClientTokenId", "SignatureDoesNotMatch", "IncompleteSignature", "UnrecognizedClientException", "InvalidAccessKeyId", "AccessDenied":
+		return newErrorCredentialsInvalid(err)
+	default:
+		return err
+	}
+}
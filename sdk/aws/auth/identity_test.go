@@ -0,0 +1,69 @@
+package auth_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/bradmccoydev/self-service-sdk/internal"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/auth"
+)
+
+// Test VerifyCredentials
+func TestVerifyCredentials(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeSts()
+	defer fake.Server.Close()
+
+	// Setup session test data
+	noCreds := session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(fake.Server.URL),
+		Credentials: credentials.NewStaticCredentials("", "", ""),
+	}))
+	roleSess, _ := auth.NewAssumeRoleSession(fake.Session(), []auth.AssumeRoleConf{{RoleArn: TestRoleArnValid}})
+
+	// Setup test data
+	tests := []struct {
+		desc        string
+		sess        *session.Session
+		expectErr   error
+		expectRole  bool
+		expectedArn string
+	}{
+		{"No session", nil, nil, false, ""},
+		{"Missing credentials", noCreds, auth.ErrCredentialsNotFound, false, ""},
+		{"Expired credentials", fake.SessionWithKey(TestExpiredAccessKey), auth.ErrCredentialsExpired, false, ""},
+		{"Invalid credentials", fake.SessionWithKey(TestInvalidAccessKey), auth.ErrCredentialsInvalid, false, ""},
+		{"Valid user credentials", fake.Session(), nil, false, "arn:aws:iam::" + TestAccountID + ":user/testing"},
+		{"Valid assumed role credentials", roleSess, nil, true, "arn:aws:sts::" + TestAccountID + ":assumed-role/testing/fred"},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Run the test
+			id, err := auth.VerifyCredentials(test.sess)
+			if test.expectedArn == "" {
+				internal.HasError(t, err)
+				if test.expectErr != nil {
+					internal.Assert(t, errors.Is(err, test.expectErr), "expected %v but got %v", test.expectErr, err)
+				}
+				return
+			}
+			internal.NoError(t, err)
+			internal.Equals(t, TestAccountID, id.Account)
+			internal.Equals(t, test.expectedArn, id.Arn)
+			internal.Equals(t, test.expectRole, id.AssumedRole)
+			if test.expectRole {
+				internal.Equals(t, "testing", id.RoleName)
+				internal.Equals(t, "fred", id.SessionName)
+			}
+		})
+	}
+}
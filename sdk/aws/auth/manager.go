@@ -0,0 +1,251 @@
+// This file contains all the bits & pieces related to
+// caching sessions & service clients
+
+package auth
+
+import (
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/clients"
+)
+
+// SessionKey - structure used to identify a cached session. Empty values
+// mean the default region, the default credential chain & no role respectively.
+type SessionKey struct {
+	Region  string
+	Profile string
+	RoleArn string
+}
+
+// SessionManager - caches sessions & service clients so they can be reused
+// across requests. It is safe for concurrent use. Its sessions are registered
+// with the clients package, so the sdk packages reuse the cached clients.
+// Use Forget or Close to drop sessions that are no longer needed.
+type SessionManager struct {
+	mu       sync.Mutex
+	options  []SessionOption
+	sessions map[SessionKey]*sessionEntry
+}
+
+// sessionEntry - a cached session, locked while it is being created so a
+// slow credential lookup only holds up callers using the same key
+type sessionEntry struct {
+	mu        sync.Mutex
+	sess      *session.Session
+	forgotten bool
+}
+
+// NewSessionManager - This function creates a new session manager. The session
+// option(s) are applied to every session the manager creates.
+//
+//   Parameters:
+//     options: the session option(s) to apply
+//
+//   Example:
+//     mgr := NewSessionManager(WithEndpointsFromEnv())
+func NewSessionManager(options ...SessionOption) *SessionManager {
+
+	return &SessionManager{
+		options:  options,
+		sessions: make(map[SessionKey]*sessionEntry),
+	}
+}
+
+// Session - This function returns the cached session for the key, creating it if required
+//
+//   Parameters:
+//     key: the region/profile/role of the session
+//
+//   Example:
+//     sess, err := mgr.Session(SessionKey{Region: "us-west-2"})
+func (m *SessionManager) Session(key SessionKey) (*session.Session, error) {
+	return m.session(key)
+}
+
+// Client - This function returns the cached client for the key & service,
+// creating it with the provided function if required. Use this for services
+// without a dedicated helper.
+//
+//   Parameters:
+//     key: the region/profile/role of the session
+//     service: a name identifying the client, eg the service endpoint ID
+//     create: a function that creates the client from a session
+//
+//   Example:
+//     c, err := mgr.Client(key, s3.EndpointsID, func(s *session.Session) interface{} { return s3.New(s) })
+//     svc := c.(*s3.S3)
+func (m *SessionManager) Client(key SessionKey, service string, create func(*session.Session) interface{}) (interface{}, error) {
+
+	// Sanity check
+	if service == "" {
+		return nil, newErrorClientServiceNotProvided()
+	}
+	if create == nil {
+		return nil, newErrorClientCreatorNotProvided()
+	}
+
+	// Find the session & its client
+	sess, err := m.session(key)
+	if err != nil {
+		return nil, err
+	}
+	return clients.Get(sess, service, create), nil
+}
+
+// DynamoDB - This function returns the cached DynamoDB client for the key
+//
+//   Parameters:
+//     key: the region/profile/role of the session
+//
+//   Example:
+//     svc, err := mgr.DynamoDB(SessionKey{Region: "us-west-2"})
+func (m *SessionManager) DynamoDB(key SessionKey) (*dynamodb.DynamoDB, error) {
+
+	client, err := m.Client(key, dynamodb.EndpointsID, func(s *session.Session) interface{} { return dynamodb.New(s) })
+	if err != nil {
+		return nil, err
+	}
+	return client.(*dynamodb.DynamoDB), nil
+}
+
+// SecretsManager - This function returns the cached Secrets Manager client for the key
+//
+//   Parameters:
+//     key: the region/profile/role of the session
+//
+//   Example:
+//     svc, err := mgr.SecretsManager(SessionKey{Region: "us-west-2"})
+func (m *SessionManager) SecretsManager(key SessionKey) (*secretsmanager.SecretsManager, error) {
+
+	client, err := m.Client(key, secretsmanager.EndpointsID, func(s *session.Session) interface{} { return secretsmanager.New(s) })
+	if err != nil {
+		return nil, err
+	}
+	return client.(*secretsmanager.SecretsManager), nil
+}
+
+// STS - This function returns the cached STS client for the key
+//
+//   Parameters:
+//     key: the region/profile/role of the session
+//
+//   Example:
+//     svc, err := mgr.STS(SessionKey{Region: "us-west-2"})
+func (m *SessionManager) STS(key SessionKey) (*sts.STS, error) {
+
+	client, err := m.Client(key, sts.EndpointsID, func(s *session.Session) interface{} { return sts.New(s) })
+	if err != nil {
+		return nil, err
+	}
+	return client.(*sts.STS), nil
+}
+
+// Forget - This function drops the cached session for the key & its clients,
+// so the next call creates a new session, eg after its credentials expire
+//
+//   Parameters:
+//     key: the region/profile/role of the session
+//
+//   Example:
+//     mgr.Forget(SessionKey{RoleArn: arn})
+func (m *SessionManager) Forget(key SessionKey) {
+
+	m.mu.Lock()
+	entry, ok := m.sessions[key]
+	delete(m.sessions, key)
+	m.mu.Unlock()
+	if ok {
+		entry.forget()
+	}
+}
+
+// Close - This function drops every cached session & its clients
+//
+//   Example:
+//     defer mgr.Close()
+func (m *SessionManager) Close() {
+
+	m.mu.Lock()
+	entries := m.sessions
+	m.sessions = make(map[SessionKey]*sessionEntry)
+	m.mu.Unlock()
+	for _, entry := range entries {
+		entry.forget()
+	}
+}
+
+// forget drops the session from the client cache & stops it being cached again
+func (e *sessionEntry) forget() {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.replace(nil)
+	e.forgotten = true
+}
+
+// replace swaps the cached session, dropping the clients of the old one
+func (e *sessionEntry) replace(sess *session.Session) {
+
+	if e.sess != nil && e.sess != sess {
+		clients.Forget(e.sess)
+	}
+	e.sess = sess
+}
+
+// session returns the cached session, creating it if required
+func (m *SessionManager) session(key SessionKey) (*session.Session, error) {
+
+	// Find the entry for this key
+	m.mu.Lock()
+	entry, ok := m.sessions[key]
+	if !ok {
+		entry = &sessionEntry{}
+		m.sessions[key] = entry
+	}
+	m.mu.Unlock()
+
+	// Already have one?
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if entry.sess != nil {
+		return entry.sess, nil
+	}
+
+	// Build the options for this key
+	options := append([]SessionOption{}, m.options...)
+	if key.Region != "" {
+		options = append(options, WithRegion(key.Region))
+	}
+
+	// Create the base session
+	var sess *session.Session
+	var err error
+	if key.Profile != "" {
+		sess, err = NewSessionFromProfile(key.Profile, options...)
+	} else {
+		sess, err = NewCustomSession(options...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Assume the role if required
+	if key.RoleArn != "" {
+		sess, err = NewAssumeRoleSession(sess, []AssumeRoleConf{{RoleArn: key.RoleArn}})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Cache & return it, unless the entry was forgotten while creating it
+	if entry.forgotten {
+		return sess, nil
+	}
+	clients.Register(sess)
+	entry.replace(sess)
+	return sess, nil
+}
@@ -0,0 +1,155 @@
+package auth_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/bradmccoydev/self-service-sdk/internal"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/auth"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/clients"
+)
+
+// Test SessionManager drops sessions & their clients
+func TestSessionManagerForget(t *testing.T) {
+
+	// Setup
+	teardownTestCase := manageEndpointEnvVars(t, nil)
+	defer teardownTestCase(t)
+	mgr := auth.NewSessionManager()
+	key := auth.SessionKey{Region: "us-west-2"}
+	sess, err := mgr.Session(key)
+	internal.NoError(t, err)
+	internal.Assert(t, clients.DynamoDB(sess) == clients.DynamoDB(sess), "expected a cached client")
+
+	// Forgetting the key drops the clients & creates a new session next time
+	mgr.Forget(key)
+	internal.Assert(t, clients.DynamoDB(sess) != clients.DynamoDB(sess), "expected the clients to be dropped")
+	renewed, err := mgr.Session(key)
+	internal.NoError(t, err)
+	internal.Assert(t, renewed != sess, "expected a new session")
+	mgr.Forget(auth.SessionKey{Region: "garbage"})
+
+	// Closing drops everything
+	other, err := mgr.Session(auth.SessionKey{Region: "eu-west-1"})
+	internal.NoError(t, err)
+	mgr.Close()
+	for _, s := range []*session.Session{renewed, other} {
+		internal.Assert(t, clients.DynamoDB(s) != clients.DynamoDB(s), "expected the clients to be dropped")
+	}
+}
+
+// Test SessionManager
+func TestSessionManager(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeSts()
+	defer fake.Server.Close()
+	teardownTestCase := manageEndpointEnvVars(t, nil)
+	defer teardownTestCase(t)
+	files := writeSharedConfigFiles(t)
+	mgr := auth.NewSessionManager(
+		auth.WithSharedConfigFiles(files...),
+		auth.WithEndpoints(auth.EndpointConf{Endpoints: map[string]string{"sts": fake.Server.URL}}),
+	)
+
+	// Setup test data
+	tests := []struct {
+		desc           string
+		key            auth.SessionKey
+		expectErr      bool
+		expectedRegion string
+		expectedKey    string
+	}{
+		{"Default session", auth.SessionKey{}, false, "us-east-1", "AKIDLOCAL"},
+		{"Different region", auth.SessionKey{Region: "us-west-2"}, false, "us-west-2", "AKIDLOCAL"},
+		{"Named profile", auth.SessionKey{Profile: "static", Region: "eu-west-1"}, false, "eu-west-1", "AKIDSTATIC"},
+		{"Missing profile", auth.SessionKey{Profile: "garbage"}, true, "", ""},
+		{"Invalid role", auth.SessionKey{RoleArn: TestRoleArnInvalid}, true, "", ""},
+		{"Assumed role", auth.SessionKey{RoleArn: TestRoleArnValid}, false, "us-east-1", "AKIDROLE"},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Run the test
+			sess, err := mgr.Session(test.key)
+			if test.expectErr {
+				internal.HasError(t, err)
+				_, err = mgr.DynamoDB(test.key)
+				internal.HasError(t, err)
+				return
+			}
+			internal.NoError(t, err)
+			internal.Equals(t, test.expectedRegion, aws.StringValue(sess.Config.Region))
+			creds, err := sess.Config.Credentials.Get()
+			internal.NoError(t, err)
+			internal.Assert(t, strings.HasPrefix(creds.AccessKeyID, test.expectedKey), "expected access key %s but got %s", test.expectedKey, creds.AccessKeyID)
+
+			// The same session & clients should be handed out each time
+			again, err := mgr.Session(test.key)
+			internal.NoError(t, err)
+			internal.Assert(t, sess == again, "expected the cached session")
+			ddb, err := mgr.DynamoDB(test.key)
+			internal.NoError(t, err)
+			ddbAgain, _ := mgr.DynamoDB(test.key)
+			internal.Assert(t, ddb == ddbAgain, "expected the cached dynamodb client")
+			internal.Assert(t, ddb == clients.DynamoDB(sess), "expected the sdk packages to share the cached dynamodb client")
+			internal.Equals(t, test.expectedRegion, aws.StringValue(ddb.Config.Region))
+			sm, err := mgr.SecretsManager(test.key)
+			internal.NoError(t, err)
+			smAgain, _ := mgr.SecretsManager(test.key)
+			internal.Assert(t, sm == smAgain, "expected the cached secrets manager client")
+			stsClient, err := mgr.STS(test.key)
+			internal.NoError(t, err)
+			stsAgain, _ := mgr.STS(test.key)
+			internal.Assert(t, stsClient == stsAgain, "expected the cached sts client")
+		})
+	}
+}
+
+// Test SessionManager.Client
+func TestSessionManagerClient(t *testing.T) {
+
+	// Setup backend
+	teardownTestCase := manageEndpointEnvVars(t, nil)
+	defer teardownTestCase(t)
+	mgr := auth.NewSessionManager()
+	create := func(s *session.Session) interface{} { return s3.New(s) }
+
+	// Sanity checks
+	_, err := mgr.Client(auth.SessionKey{}, "", create)
+	internal.HasError(t, err)
+	_, err = mgr.Client(auth.SessionKey{}, s3.EndpointsID, nil)
+	internal.HasError(t, err)
+
+	// Hammer the manager concurrently & make sure only one client per key is created
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	clients := make(map[string]map[interface{}]bool)
+	regions := []string{"us-east-1", "us-west-2", "ap-southeast-2"}
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func(region string) {
+			defer wg.Done()
+			c, err := mgr.Client(auth.SessionKey{Region: region}, s3.EndpointsID, create)
+			internal.NoError(t, err)
+			mu.Lock()
+			if clients[region] == nil {
+				clients[region] = make(map[interface{}]bool)
+			}
+			clients[region][c] = true
+			mu.Unlock()
+		}(regions[i%len(regions)])
+	}
+	wg.Wait()
+	internal.Equals(t, len(regions), len(clients))
+	for _, region := range regions {
+		internal.Equals(t, 1, len(clients[region]))
+	}
+}
@@ -0,0 +1,191 @@
+// This file contains all the bits & pieces related to
+// the options that can be applied when creating a session
+
+package auth
+
+import (
+	"crypto/tls"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+const (
+	// EnvEndpointPrefix - the prefix for env vars that override a service endpoint,
+	// eg SELFSERVICE_ENDPOINT_DYNAMODB=http://localhost:8000
+	EnvEndpointPrefix string = "SELFSERVICE_ENDPOINT_"
+
+	// EnvInsecureSkipVerify - the env var that disables SSL certificate verification
+	EnvInsecureSkipVerify string = "SELFSERVICE_INSECURE_SKIP_VERIFY"
+
+	// EnvForcePathStyle - the env var that forces path-style S3 addressing
+	EnvForcePathStyle string = "SELFSERVICE_FORCE_PATH_STYLE"
+)
+
+// SessionOption - a function that modifies the options used to create a session
+type SessionOption func(opts *session.Options) error
+
+// EndpointConf - structure used to represent endpoint overrides for a session.
+// Endpoints are keyed on the service endpoint ID (eg dynamodb, secretsmanager, sts)
+type EndpointConf struct {
+	Endpoints          map[string]string
+	InsecureSkipVerify bool
+	ForcePathStyle     bool
+}
+
+// NewCustomSession - This function creates an AWS session using the defaults
+// modified by the provided session option(s)
+//
+//   Parameters:
+//     options: the session option(s) to apply
+//
+//   Example:
+//     sess, err := NewCustomSession(WithEndpointsFromEnv())
+func NewCustomSession(options ...SessionOption) (*session.Session, error) {
+
+	// Build the session options
+	opts, err := applySessionOptions(session.Options{}, options)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the session
+	sess, err := session.NewSessionWithOptions(opts)
+	return sess, err
+}
+
+// WithRegion - This function creates a session option that sets the region
+//
+//   Parameters:
+//     region: the AWS region to use
+//
+//   Example:
+//     sess, err := NewCustomSession(WithRegion("us-west-2"))
+func WithRegion(region string) SessionOption {
+
+	return func(opts *session.Options) error {
+		if region == "" {
+			return newErrorRegionNotProvided()
+		}
+		opts.Config.Region = aws.String(region)
+		return nil
+	}
+}
+
+// WithEndpoints - This function creates a session option that overrides
+// the endpoints used for the specified services
+//
+//   Parameters:
+//     conf: the endpoint overrides to apply
+//
+//   Example:
+//     sess, err := NewCustomSession(WithEndpoints(conf))
+func WithEndpoints(conf EndpointConf) SessionOption {
+
+	return func(opts *session.Options) error {
+
+		// Sanity check
+		for service, url := range conf.Endpoints {
+			if service == "" {
+				return newErrorEndpointServiceNotProvided()
+			}
+			if url == "" {
+				return newErrorEndpointURLNotProvided(service)
+			}
+		}
+
+		// Add the endpoint resolver, falling back to any existing resolver
+		if len(conf.Endpoints) > 0 {
+			overrides := make(map[string]string)
+			for service, url := range conf.Endpoints {
+				overrides[strings.ToLower(service)] = url
+			}
+			var fallback endpoints.Resolver = endpoints.DefaultResolver()
+			if opts.Config.EndpointResolver != nil {
+				fallback = opts.Config.EndpointResolver
+			}
+			opts.Config.EndpointResolver = endpoints.ResolverFunc(func(service, region string, optFns ...func(*endpoints.Options)) (endpoints.ResolvedEndpoint, error) {
+				if url, ok := overrides[service]; ok {
+					return endpoints.ResolvedEndpoint{URL: url, SigningRegion: region}, nil
+				}
+				return fallback.EndpointFor(service, region, optFns...)
+			})
+		}
+
+		// Disable SSL certificate verification if requested
+		if conf.InsecureSkipVerify {
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+			opts.Config.HTTPClient = &http.Client{Transport: transport}
+		}
+
+		// Force path-style addressing if requested
+		if conf.ForcePathStyle {
+			opts.Config.S3ForcePathStyle = aws.Bool(true)
+		}
+
+		return nil
+	}
+}
+
+// WithEndpointsFromEnv - This function creates a session option that overrides
+// service endpoints using the SELFSERVICE_ENDPOINT_* environment variables.
+// The service endpoint ID is taken from the variable name, eg
+// SELFSERVICE_ENDPOINT_DYNAMODB overrides the dynamodb endpoint.
+//
+//   Example:
+//     sess, err := NewCustomSession(WithEndpointsFromEnv())
+func WithEndpointsFromEnv() SessionOption {
+
+	return func(opts *session.Options) error {
+		return WithEndpoints(EndpointsFromEnv())(opts)
+	}
+}
+
+// EndpointsFromEnv - This function builds the endpoint overrides from the
+// SELFSERVICE_ENDPOINT_* environment variables
+//
+//   Example:
+//     conf := EndpointsFromEnv()
+func EndpointsFromEnv() EndpointConf {
+
+	// Look for the endpoint variables
+	conf := EndpointConf{Endpoints: make(map[string]string)}
+	for _, env := range os.Environ() {
+		parts := strings.SplitN(env, "=", 2)
+		if len(parts) != 2 || !strings.HasPrefix(parts[0], EnvEndpointPrefix) || parts[1] == "" {
+			continue
+		}
+		service := strings.TrimPrefix(parts[0], EnvEndpointPrefix)
+		service = strings.ReplaceAll(strings.ToLower(service), "_", "-")
+		if service != "" {
+			conf.Endpoints[service] = parts[1]
+		}
+	}
+
+	// Handle the flags
+	conf.InsecureSkipVerify = strings.ToUpper(os.Getenv(EnvInsecureSkipVerify)) == "TRUE"
+	conf.ForcePathStyle = strings.ToUpper(os.Getenv(EnvForcePathStyle)) == "TRUE"
+
+	// Return it
+	return conf
+}
+
+// applySessionOptions applies each session option in turn
+func applySessionOptions(opts session.Options, options []SessionOption) (session.Options, error) {
+
+	for _, o := range options {
+		if o == nil {
+			continue
+		}
+		err := o(&opts)
+		if err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
@@ -0,0 +1,147 @@
+package auth_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/bradmccoydev/self-service-sdk/internal"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/auth"
+)
+
+// newFakeDynamo creates a server that answers ListTables like DynamoDB Local
+func newFakeDynamo(tls bool) *httptest.Server {
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		fmt.Fprint(w, `{"TableNames":["testing"]}`)
+	})
+	if tls {
+		return httptest.NewTLSServer(handler)
+	}
+	return httptest.NewServer(handler)
+}
+
+// manageEndpointEnvVars handles creation/destruction of the endpoint env vars
+func manageEndpointEnvVars(t *testing.T, vars map[string]string) func(t *testing.T) {
+
+	// Always use dummy credentials & region
+	os.Setenv(internal.EnvAwsKey, "AKIDLOCAL")
+	os.Setenv(internal.EnvAwsSecret, "SECRET")
+	os.Setenv(internal.EnvAwsRegion, "us-east-1")
+	for k, v := range vars {
+		os.Setenv(k, v)
+	}
+	return func(t *testing.T) {
+
+		// Delete environment variables
+		os.Unsetenv(internal.EnvAwsKey)
+		os.Unsetenv(internal.EnvAwsSecret)
+		os.Unsetenv(internal.EnvAwsRegion)
+		for k := range vars {
+			os.Unsetenv(k)
+		}
+	}
+}
+
+// Test NewCustomSession with WithEndpoints
+func TestWithEndpoints(t *testing.T) {
+
+	// Setup backend
+	plain := newFakeDynamo(false)
+	defer plain.Close()
+	secure := newFakeDynamo(true)
+	defer secure.Close()
+
+	// Setup test data
+	tests := []struct {
+		desc      string
+		conf      auth.EndpointConf
+		expectErr bool
+		callErr   bool
+	}{
+		{"Override without service", auth.EndpointConf{Endpoints: map[string]string{"": plain.URL}}, true, false},
+		{"Override without URL", auth.EndpointConf{Endpoints: map[string]string{"dynamodb": ""}}, true, false},
+		{"Plain override", auth.EndpointConf{Endpoints: map[string]string{"dynamodb": plain.URL}}, false, false},
+		{"Mixed case service", auth.EndpointConf{Endpoints: map[string]string{"DynamoDB": plain.URL}}, false, false},
+		{"TLS override with verification", auth.EndpointConf{Endpoints: map[string]string{"dynamodb": secure.URL}}, false, true},
+		{"TLS override without verification", auth.EndpointConf{Endpoints: map[string]string{"dynamodb": secure.URL}, InsecureSkipVerify: true}, false, false},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Run routine to setup appropriate env vars
+			teardownTestCase := manageEndpointEnvVars(t, nil)
+			defer teardownTestCase(t)
+
+			// Run the test
+			sess, err := auth.NewCustomSession(auth.WithEndpoints(test.conf))
+			if test.expectErr {
+				internal.HasError(t, err)
+				return
+			}
+			internal.NoError(t, err)
+			svc := dynamodb.New(sess, aws.NewConfig().WithMaxRetries(0))
+			result, err := svc.ListTables(&dynamodb.ListTablesInput{})
+			if test.callErr {
+				internal.HasError(t, err)
+				return
+			}
+			internal.NoError(t, err)
+			internal.Equals(t, []string{"testing"}, aws.StringValueSlice(result.TableNames))
+		})
+	}
+}
+
+// Test EndpointsFromEnv
+func TestEndpointsFromEnv(t *testing.T) {
+
+	// Setup test data
+	tests := []struct {
+		desc         string
+		vars         map[string]string
+		expectedConf auth.EndpointConf
+	}{
+		{"No values", nil, auth.EndpointConf{Endpoints: map[string]string{}}},
+		{"Single endpoint", map[string]string{"SELFSERVICE_ENDPOINT_DYNAMODB": "http://localhost:8000"},
+			auth.EndpointConf{Endpoints: map[string]string{"dynamodb": "http://localhost:8000"}}},
+		{"Hyphenated service & flags", map[string]string{
+			"SELFSERVICE_ENDPOINT_EXECUTE_API":    "https://localhost:4566",
+			"SELFSERVICE_ENDPOINT_S3":             "https://localhost:4566",
+			auth.EnvInsecureSkipVerify:            "true",
+			auth.EnvForcePathStyle:                "TRUE",
+			"SELFSERVICE_ENDPOINT_SECRETSMANAGER": "",
+		}, auth.EndpointConf{
+			Endpoints:          map[string]string{"execute-api": "https://localhost:4566", "s3": "https://localhost:4566"},
+			InsecureSkipVerify: true,
+			ForcePathStyle:     true,
+		}},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Run routine to setup appropriate env vars
+			teardownTestCase := manageEndpointEnvVars(t, test.vars)
+			defer teardownTestCase(t)
+
+			// Run the test
+			actual := auth.EndpointsFromEnv()
+			internal.Equals(t, test.expectedConf, actual)
+
+			// The session should pick up the path style flag
+			sess, err := auth.NewCustomSession(auth.WithEndpointsFromEnv())
+			internal.NoError(t, err)
+			internal.Equals(t, test.expectedConf.ForcePathStyle, aws.BoolValue(sess.Config.S3ForcePathStyle))
+		})
+	}
+}
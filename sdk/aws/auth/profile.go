@@ -0,0 +1,201 @@
+// This file contains all the bits & pieces related to
+// creating sessions from named profiles in the shared
+// config & credentials files
+
+package auth
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/defaults"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+const (
+	// EnvMFAToken - the default env var used by EnvMFATokenProvider
+	EnvMFAToken string = "AWS_MFA_TOKEN"
+
+	// envSharedCredentialsFile - the env var for the shared credentials file
+	envSharedCredentialsFile string = "AWS_SHARED_CREDENTIALS_FILE"
+
+	// envSharedConfigFile - the env var for the shared config file
+	envSharedConfigFile string = "AWS_CONFIG_FILE"
+)
+
+// MFATokenProvider - a function that returns an MFA token code when a
+// profile requiring MFA (mfa_serial) is used
+type MFATokenProvider func() (string, error)
+
+// NewSessionFromProfile - This function creates an AWS session using a named
+// profile from the shared config (~/.aws/config) & credentials (~/.aws/credentials)
+// files. Role chains configured with role_arn & source_profile are honoured.
+//
+//   Parameters:
+//     profile: the name of the profile to use
+//     options: any additional session option(s) to apply
+//
+//   Example:
+//     sess, err := NewSessionFromProfile("dev", WithMFATokenProvider(StdinMFATokenProvider()))
+func NewSessionFromProfile(profile string, options ...SessionOption) (*session.Session, error) {
+
+	// Sanity check
+	if profile == "" {
+		return nil, newErrorProfileNotProvided()
+	}
+
+	// Build the session options
+	opts := session.Options{
+		Profile:           profile,
+		SharedConfigState: session.SharedConfigEnable,
+	}
+	opts, err := applySessionOptions(opts, options)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make sure the profile actually exists, otherwise the SDK silently
+	// falls back to the default credential chain
+	if !profileExists(sharedConfigFiles(opts), profile) {
+		return nil, newErrorProfileNotFound(profile)
+	}
+
+	// Create the session
+	sess, err := session.NewSessionWithOptions(opts)
+	return sess, err
+}
+
+// WithSharedConfigFiles - This function creates a session option that sets the
+// shared config & credentials files to load instead of the defaults
+//
+//   Parameters:
+//     files: the file(s) to load
+//
+//   Example:
+//     sess, err := NewSessionFromProfile("dev", WithSharedConfigFiles("/tmp/credentials", "/tmp/config"))
+func WithSharedConfigFiles(files ...string) SessionOption {
+
+	return func(opts *session.Options) error {
+		if len(files) == 0 {
+			return newErrorSharedConfigFilesNotProvided()
+		}
+		opts.SharedConfigFiles = files
+		return nil
+	}
+}
+
+// WithMFATokenProvider - This function creates a session option that sets the
+// provider used to obtain an MFA token when assuming a role that requires one
+//
+//   Parameters:
+//     provider: the MFA token provider
+//
+//   Example:
+//     sess, err := NewSessionFromProfile("dev", WithMFATokenProvider(EnvMFATokenProvider("")))
+func WithMFATokenProvider(provider MFATokenProvider) SessionOption {
+
+	return func(opts *session.Options) error {
+		if provider == nil {
+			return newErrorMFATokenProviderNotProvided()
+		}
+		opts.AssumeRoleTokenProvider = provider
+		return nil
+	}
+}
+
+// StdinMFATokenProvider - This function creates an MFA token provider that
+// prompts for the token on stdin
+//
+//   Example:
+//     provider := StdinMFATokenProvider()
+func StdinMFATokenProvider() MFATokenProvider {
+	return stscreds.StdinTokenProvider
+}
+
+// EnvMFATokenProvider - This function creates an MFA token provider that reads
+// the token from an environment variable (AWS_MFA_TOKEN if none is provided)
+//
+//   Parameters:
+//     envVar: the environment variable holding the token
+//
+//   Example:
+//     provider := EnvMFATokenProvider("MY_MFA_TOKEN")
+func EnvMFATokenProvider(envVar string) MFATokenProvider {
+
+	if envVar == "" {
+		envVar = EnvMFAToken
+	}
+	return func() (string, error) {
+		token := os.Getenv(envVar)
+		if token == "" {
+			return "", newErrorMFATokenNotSet(envVar)
+		}
+		return token, nil
+	}
+}
+
+// sharedConfigFiles works out which shared config files the session will load
+func sharedConfigFiles(opts session.Options) []string {
+
+	if len(opts.SharedConfigFiles) > 0 {
+		return opts.SharedConfigFiles
+	}
+	credsFile := os.Getenv(envSharedCredentialsFile)
+	if credsFile == "" {
+		credsFile = defaults.SharedCredentialsFilename()
+	}
+	configFile := os.Getenv(envSharedConfigFile)
+	if configFile == "" {
+		configFile = defaults.SharedConfigFilename()
+	}
+	return []string{credsFile, configFile}
+}
+
+// profileExists checks whether any of the files define the profile,
+// either as [name] or [profile name]
+func profileExists(files []string, profile string) bool {
+
+	_, found := readProfile(files, profile)
+	return found
+}
+
+// readProfile reads the key/value pairs for the profile from the files,
+// values in later files take precedence
+func readProfile(files []string, profile string) (map[string]string, bool) {
+
+	values := make(map[string]string)
+	found := false
+	for _, f := range files {
+		file, err := os.Open(f)
+		if err != nil {
+			continue
+		}
+		inProfile := false
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+
+			// Section header?
+			if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+				name := strings.TrimSpace(strings.Trim(line, "[]"))
+				name = strings.TrimSpace(strings.TrimPrefix(name, "profile "))
+				inProfile = name == profile
+				found = found || inProfile
+				continue
+			}
+
+			// Key/value within the profile?
+			if !inProfile || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+				continue
+			}
+			parts := strings.SplitN(line, "=", 2)
+			if len(parts) == 2 {
+				values[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+			}
+		}
+		file.Close()
+	}
+	return values, found
+}
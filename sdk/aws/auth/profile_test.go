@@ -0,0 +1,156 @@
+package auth_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bradmccoydev/self-service-sdk/internal"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/auth"
+)
+
+// The shared credentials file used for testing
+const testSharedCredentials string = `
+[static]
+aws_access_key_id = AKIDSTATIC
+aws_secret_access_key = SECRET
+`
+
+// The shared config file used for testing
+const testSharedConfig string = `
+[profile static]
+region = us-east-1
+
+[profile role]
+region = us-east-1
+role_arn = ` + TestRoleArnValid + `
+source_profile = static
+
+[profile mfa]
+region = us-east-1
+role_arn = ` + TestRoleArnValid + `
+source_profile = static
+mfa_serial = arn:aws:iam::123456789012:mfa/fred
+`
+
+// writeSharedConfigFiles writes the test shared config files & returns their paths
+func writeSharedConfigFiles(t *testing.T) []string {
+
+	dir := t.TempDir()
+	credsFile := filepath.Join(dir, "credentials")
+	configFile := filepath.Join(dir, "config")
+	internal.NoError(t, ioutil.WriteFile(credsFile, []byte(testSharedCredentials), 0600))
+	internal.NoError(t, ioutil.WriteFile(configFile, []byte(testSharedConfig), 0600))
+	return []string{credsFile, configFile}
+}
+
+// Test NewSessionFromProfile
+func TestNewSessionFromProfile(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeSts()
+	defer fake.Server.Close()
+	files := writeSharedConfigFiles(t)
+	stsEndpoint := auth.WithEndpoints(auth.EndpointConf{Endpoints: map[string]string{"sts": fake.Server.URL}})
+	callback := auth.WithMFATokenProvider(func() (string, error) { return "123456", nil })
+	fromEnv := auth.WithMFATokenProvider(auth.EnvMFATokenProvider(""))
+
+	// Setup test data
+	tests := []struct {
+		desc        string
+		profile     string
+		mfaEnv      string
+		options     []auth.SessionOption
+		expectErr   bool
+		expectedKey string
+		expectedMFA string
+	}{
+		{"No profile", "", "", nil, true, "", ""},
+		{"Missing profile", "garbage", "", []auth.SessionOption{auth.WithSharedConfigFiles(files...)}, true, "", ""},
+		{"Empty config files", "static", "", []auth.SessionOption{auth.WithSharedConfigFiles()}, true, "", ""},
+		{"Static profile", "static", "", []auth.SessionOption{auth.WithSharedConfigFiles(files...)}, false, "AKIDSTATIC", ""},
+		{"Role profile", "role", "", []auth.SessionOption{auth.WithSharedConfigFiles(files...), stsEndpoint}, false, "AKIDROLE", ""},
+		{"MFA profile without provider", "mfa", "", []auth.SessionOption{auth.WithSharedConfigFiles(files...), stsEndpoint}, true, "", ""},
+		{"MFA profile with nil provider", "mfa", "", []auth.SessionOption{auth.WithSharedConfigFiles(files...), auth.WithMFATokenProvider(nil)}, true, "", ""},
+		{"MFA profile with callback provider", "mfa", "", []auth.SessionOption{auth.WithSharedConfigFiles(files...), stsEndpoint, callback}, false, "AKIDROLE", "123456"},
+		{"MFA profile with env provider", "mfa", "654321", []auth.SessionOption{auth.WithSharedConfigFiles(files...), stsEndpoint, fromEnv}, false, "AKIDROLE", "654321"},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Setup the MFA token env var
+			if test.mfaEnv != "" {
+				os.Setenv(auth.EnvMFAToken, test.mfaEnv)
+				defer os.Unsetenv(auth.EnvMFAToken)
+			}
+
+			// Run the test
+			before := len(fake.Calls())
+			sess, err := auth.NewSessionFromProfile(test.profile, test.options...)
+			if test.expectErr {
+				internal.HasError(t, err)
+				return
+			}
+			internal.NoError(t, err)
+			creds, err := sess.Config.Credentials.Get()
+			internal.NoError(t, err)
+			internal.Assert(t, strings.HasPrefix(creds.AccessKeyID, test.expectedKey), "expected access key %s but got %s", test.expectedKey, creds.AccessKeyID)
+
+			// Check the MFA token was passed through
+			if test.expectedMFA != "" {
+				calls := fake.Calls()[before:]
+				internal.Equals(t, 1, len(calls))
+				internal.Equals(t, test.expectedMFA, calls[0]["TokenCode"])
+				internal.Equals(t, "arn:aws:iam::123456789012:mfa/fred", calls[0]["SerialNumber"])
+			}
+		})
+	}
+}
+
+// Test EnvMFATokenProvider
+func TestEnvMFATokenProvider(t *testing.T) {
+
+	// Setup test data
+	tests := []struct {
+		desc        string
+		envVar      string
+		value       string
+		expectErr   bool
+		expectedVal string
+	}{
+		{"Default variable not set", "", "", true, ""},
+		{"Default variable", "", "123456", false, "123456"},
+		{"Custom variable", "TEST_MFA_TOKEN", "654321", false, "654321"},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Setup the env var
+			name := test.envVar
+			if name == "" {
+				name = auth.EnvMFAToken
+			}
+			if test.value != "" {
+				os.Setenv(name, test.value)
+				defer os.Unsetenv(name)
+			}
+
+			// Run the test
+			actual, err := auth.EnvMFATokenProvider(test.envVar)()
+			if test.expectErr {
+				internal.HasError(t, err)
+			} else {
+				internal.NoError(t, err)
+				internal.Equals(t, test.expectedVal, actual)
+			}
+		})
+	}
+}
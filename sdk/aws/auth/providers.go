@@ -0,0 +1,222 @@
+// This file contains all the bits & pieces related to
+// credential providers that source static credentials
+// from configuration or Secrets Manager
+
+package auth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/secretsmanager"
+	"github.com/spf13/viper"
+)
+
+const (
+	// ConfigProviderName - the name of the configuration credential provider
+	ConfigProviderName string = "SelfServiceConfigProvider"
+
+	// SecretProviderName - the name of the Secrets Manager credential provider
+	SecretProviderName string = "SelfServiceSecretProvider"
+
+	// DefaultSecretRefreshInterval - how often the secret is re-read to pick up rotations
+	DefaultSecretRefreshInterval time.Duration = 15 * time.Minute
+)
+
+// CredentialKeys - structure used to represent the keys holding each credential value
+type CredentialKeys struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// DefaultConfigCredentialKeys - the default configuration keys for credentials
+var DefaultConfigCredentialKeys = CredentialKeys{
+	AccessKeyID:     "aws.access_key_id",
+	SecretAccessKey: "aws.secret_access_key",
+	SessionToken:    "aws.session_token",
+}
+
+// DefaultSecretCredentialKeys - the default secret keys for credentials
+var DefaultSecretCredentialKeys = CredentialKeys{
+	AccessKeyID:     "access_key_id",
+	SecretAccessKey: "secret_access_key",
+	SessionToken:    "session_token",
+}
+
+// ConfigProvider - a credential provider that reads the credentials from a
+// configuration created by the configutil package
+type ConfigProvider struct {
+	Config *viper.Viper
+	Keys   CredentialKeys
+}
+
+// SecretProvider - a credential provider that reads the credentials from a key/value
+// secret in Secrets Manager. The secret is re-read every RefreshInterval so rotated
+// credentials are picked up.
+type SecretProvider struct {
+	Session         *session.Session
+	SecretName      string
+	Keys            CredentialKeys
+	RefreshInterval time.Duration
+
+	mu        sync.Mutex
+	retrieved time.Time
+}
+
+// NewConfigCredentials - This function creates credentials that are read from
+// a configuration (see the configutil package)
+//
+//   Parameters:
+//     config: the configuration holding the credentials
+//     keys: the configuration keys, empty values use DefaultConfigCredentialKeys
+//
+//   Example:
+//     creds, err := NewConfigCredentials(cfg, CredentialKeys{})
+func NewConfigCredentials(config *viper.Viper, keys CredentialKeys) (*credentials.Credentials, error) {
+
+	// Sanity check
+	if config == nil {
+		return nil, newErrorConfigNotProvided()
+	}
+
+	// Create the credentials
+	provider := &ConfigProvider{
+		Config: config,
+		Keys:   defaultCredentialKeys(keys, DefaultConfigCredentialKeys),
+	}
+	return credentials.NewCredentials(provider), nil
+}
+
+// NewSecretCredentials - This function creates credentials that are read from
+// a key/value secret in Secrets Manager
+//
+//   Parameters:
+//     sess: a valid AWS session able to read the secret
+//     secretName: the name of the secret holding the credentials
+//     keys: the secret keys, empty values use DefaultSecretCredentialKeys
+//     refresh: how often to re-read the secret, zero uses DefaultSecretRefreshInterval
+//
+//   Example:
+//     creds, err := NewSecretCredentials(mySession, "service-creds", CredentialKeys{}, 0)
+func NewSecretCredentials(sess *session.Session, secretName string, keys CredentialKeys, refresh time.Duration) (*credentials.Credentials, error) {
+
+	// Sanity check
+	if sess == nil {
+		return nil, newErrorSessionNotProvided()
+	}
+	if secretName == "" {
+		return nil, newErrorSecretNameNotProvided()
+	}
+	if refresh == 0 {
+		refresh = DefaultSecretRefreshInterval
+	}
+
+	// Create the credentials
+	provider := &SecretProvider{
+		Session:         sess,
+		SecretName:      secretName,
+		Keys:            defaultCredentialKeys(keys, DefaultSecretCredentialKeys),
+		RefreshInterval: refresh,
+	}
+	return credentials.NewCredentials(provider), nil
+}
+
+// WithCredentials - This function creates a session option that sets the session credentials
+//
+//   Parameters:
+//     creds: the credentials to use
+//
+//   Example:
+//     sess, err := NewCustomSession(WithCredentials(creds))
+func WithCredentials(creds *credentials.Credentials) SessionOption {
+
+	return func(opts *session.Options) error {
+		if creds == nil {
+			return newErrorCredentialsNotProvided()
+		}
+		opts.Config.Credentials = creds
+		return nil
+	}
+}
+
+// Retrieve satisfies the credentials.Provider interface
+func (p *ConfigProvider) Retrieve() (credentials.Value, error) {
+
+	values := map[string]string{
+		p.Keys.AccessKeyID:     p.Config.GetString(p.Keys.AccessKeyID),
+		p.Keys.SecretAccessKey: p.Config.GetString(p.Keys.SecretAccessKey),
+		p.Keys.SessionToken:    p.Config.GetString(p.Keys.SessionToken),
+	}
+	return credentialValue(values, p.Keys, ConfigProviderName)
+}
+
+// IsExpired satisfies the credentials.Provider interface, configuration
+// values never expire
+func (p *ConfigProvider) IsExpired() bool {
+	return false
+}
+
+// Retrieve satisfies the credentials.Provider interface
+func (p *SecretProvider) Retrieve() (credentials.Value, error) {
+
+	// Read the secret
+	values, err := secretsmanager.GetSecretKeyValue(p.Session, p.SecretName)
+	if err != nil {
+		return credentials.Value{ProviderName: SecretProviderName}, err
+	}
+	value, err := credentialValue(values, p.Keys, SecretProviderName)
+	if err != nil {
+		return value, err
+	}
+
+	// Record when we read it
+	p.mu.Lock()
+	p.retrieved = time.Now()
+	p.mu.Unlock()
+	return value, nil
+}
+
+// IsExpired satisfies the credentials.Provider interface, the credentials
+// expire once the refresh interval has passed
+func (p *SecretProvider) IsExpired() bool {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.retrieved.IsZero() || time.Since(p.retrieved) >= p.RefreshInterval
+}
+
+// credentialValue builds the credential value from the key/value pairs
+func credentialValue(values map[string]string, keys CredentialKeys, providerName string) (credentials.Value, error) {
+
+	value := credentials.Value{
+		AccessKeyID:     values[keys.AccessKeyID],
+		SecretAccessKey: values[keys.SecretAccessKey],
+		SessionToken:    values[keys.SessionToken],
+		ProviderName:    providerName,
+	}
+	if value.AccessKeyID == "" {
+		return value, newErrorCredentialKeyNotSet(keys.AccessKeyID, providerName)
+	}
+	if value.SecretAccessKey == "" {
+		return value, newErrorCredentialKeyNotSet(keys.SecretAccessKey, providerName)
+	}
+	return value, nil
+}
+
+// defaultCredentialKeys fills in any missing keys from the defaults
+func defaultCredentialKeys(keys CredentialKeys, defaults CredentialKeys) CredentialKeys {
+
+	if keys.AccessKeyID == "" {
+		keys.AccessKeyID = defaults.AccessKeyID
+	}
+	if keys.SecretAccessKey == "" {
+		keys.SecretAccessKey = defaults.SecretAccessKey
+	}
+	if keys.SessionToken == "" {
+		keys.SessionToken = defaults.SessionToken
+	}
+	return keys
+}
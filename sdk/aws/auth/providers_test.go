@@ -0,0 +1,215 @@
+package auth_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/bradmccoydev/self-service-sdk/internal"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/auth"
+	"github.com/bradmccoydev/self-service-sdk/sdk/configutil"
+	"github.com/spf13/viper"
+)
+
+// FakeSecrets is a minimal stand-in for the Secrets Manager API
+type FakeSecrets struct {
+	Server *httptest.Server
+	mu     sync.Mutex
+	values map[string]string
+}
+
+// NewFakeSecrets starts a fake Secrets Manager server
+func NewFakeSecrets() *FakeSecrets {
+
+	fake := &FakeSecrets{values: make(map[string]string)}
+	fake.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input struct{ SecretId string }
+		json.NewDecoder(r.Body).Decode(&input)
+		fake.mu.Lock()
+		value, ok := fake.values[input.SecretId]
+		fake.mu.Unlock()
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"__type": "ResourceNotFoundException", "message": "not found"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"Name": input.SecretId, "SecretString": value})
+	}))
+	return fake
+}
+
+// Set stores a key/value secret
+func (f *FakeSecrets) Set(name string, kv map[string]string) {
+
+	data, _ := json.Marshal(kv)
+	f.mu.Lock()
+	f.values[name] = string(data)
+	f.mu.Unlock()
+}
+
+// Test NewConfigCredentials
+func TestNewConfigCredentials(t *testing.T) {
+
+	// Setup configuration test data
+	validConf, _ := configutil.NewConfig([]configutil.DefaultValue{
+		{ConfigKey: "aws.access_key_id", ConfigValue: "AKIDCONFIG"},
+		{ConfigKey: "aws.secret_access_key", ConfigValue: "SECRET"},
+	})
+	customConf, _ := configutil.NewConfig([]configutil.DefaultValue{
+		{ConfigKey: "creds.key", ConfigValue: "AKIDCUSTOM"},
+		{ConfigKey: "creds.secret", ConfigValue: "SECRET"},
+		{ConfigKey: "creds.token", ConfigValue: "TOKEN"},
+	})
+	noSecretConf, _ := configutil.NewConfig([]configutil.DefaultValue{
+		{ConfigKey: "aws.access_key_id", ConfigValue: "AKIDCONFIG"},
+	})
+	customKeys := auth.CredentialKeys{AccessKeyID: "creds.key", SecretAccessKey: "creds.secret", SessionToken: "creds.token"}
+
+	// Setup test data
+	tests := []struct {
+		desc          string
+		config        *viper.Viper
+		keys          auth.CredentialKeys
+		expectErr     bool
+		expectGetErr  bool
+		expectedKey   string
+		expectedToken string
+	}{
+		{"No configuration", nil, auth.CredentialKeys{}, true, false, "", ""},
+		{"Default keys", validConf, auth.CredentialKeys{}, false, false, "AKIDCONFIG", ""},
+		{"Custom keys", customConf, customKeys, false, false, "AKIDCUSTOM", "TOKEN"},
+		{"Missing secret", noSecretConf, auth.CredentialKeys{}, false, true, "", ""},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Run the test
+			creds, err := auth.NewConfigCredentials(test.config, test.keys)
+			if test.expectErr {
+				internal.HasError(t, err)
+				return
+			}
+			internal.NoError(t, err)
+			value, err := creds.Get()
+			if test.expectGetErr {
+				internal.HasError(t, err)
+				internal.Assert(t, errors.Is(err, auth.ErrCredentialsNotFound), "expected a credentials not found error")
+				return
+			}
+			internal.NoError(t, err)
+			internal.Equals(t, test.expectedKey, value.AccessKeyID)
+			internal.Equals(t, test.expectedToken, value.SessionToken)
+			internal.Equals(t, auth.ConfigProviderName, value.ProviderName)
+		})
+	}
+}
+
+// Test NewSecretCredentials
+func TestNewSecretCredentials(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeSecrets()
+	defer fake.Server.Close()
+	teardownTestCase := manageEndpointEnvVars(t, nil)
+	defer teardownTestCase(t)
+	sess, err := auth.NewCustomSession(auth.WithEndpoints(auth.EndpointConf{Endpoints: map[string]string{"secretsmanager": fake.Server.URL}}))
+	internal.NoError(t, err)
+	fake.Set("valid", map[string]string{"access_key_id": "AKIDSECRET", "secret_access_key": "SECRET"})
+	fake.Set("custom", map[string]string{"key": "AKIDCUSTOM", "secret": "SECRET", "token": "TOKEN"})
+	fake.Set("incomplete", map[string]string{"access_key_id": "AKIDSECRET"})
+	customKeys := auth.CredentialKeys{AccessKeyID: "key", SecretAccessKey: "secret", SessionToken: "token"}
+
+	// Setup test data
+	tests := []struct {
+		desc          string
+		validSess     bool
+		secretName    string
+		keys          auth.CredentialKeys
+		expectErr     bool
+		expectGetErr  bool
+		expectedKey   string
+		expectedToken string
+	}{
+		{"No inputs", false, "", auth.CredentialKeys{}, true, false, "", ""},
+		{"Just session", true, "", auth.CredentialKeys{}, true, false, "", ""},
+		{"Missing secret", true, "garbage", auth.CredentialKeys{}, false, true, "", ""},
+		{"Incomplete secret", true, "incomplete", auth.CredentialKeys{}, false, true, "", ""},
+		{"Default keys", true, "valid", auth.CredentialKeys{}, false, false, "AKIDSECRET", ""},
+		{"Custom keys", true, "custom", customKeys, false, false, "AKIDCUSTOM", "TOKEN"},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Run the test
+			var s *session.Session
+			if test.validSess {
+				s = sess
+			}
+			creds, err := auth.NewSecretCredentials(s, test.secretName, test.keys, 0)
+			if test.expectErr {
+				internal.HasError(t, err)
+				return
+			}
+			internal.NoError(t, err)
+			value, err := creds.Get()
+			if test.expectGetErr {
+				internal.HasError(t, err)
+				return
+			}
+			internal.NoError(t, err)
+			internal.Equals(t, test.expectedKey, value.AccessKeyID)
+			internal.Equals(t, test.expectedToken, value.SessionToken)
+			internal.Equals(t, auth.SecretProviderName, value.ProviderName)
+		})
+	}
+}
+
+// Test NewSecretCredentials picks up a rotated secret
+func TestNewSecretCredentialsRotation(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeSecrets()
+	defer fake.Server.Close()
+	teardownTestCase := manageEndpointEnvVars(t, nil)
+	defer teardownTestCase(t)
+	sess, err := auth.NewCustomSession(auth.WithEndpoints(auth.EndpointConf{Endpoints: map[string]string{"secretsmanager": fake.Server.URL}}))
+	internal.NoError(t, err)
+	fake.Set("rotating", map[string]string{"access_key_id": "AKIDFIRST", "secret_access_key": "SECRET"})
+
+	// Read the first value
+	creds, err := auth.NewSecretCredentials(sess, "rotating", auth.CredentialKeys{}, 200*time.Millisecond)
+	internal.NoError(t, err)
+	value, err := creds.Get()
+	internal.NoError(t, err)
+	internal.Equals(t, "AKIDFIRST", value.AccessKeyID)
+
+	// Rotate the secret, the old value should be used until the interval passes
+	fake.Set("rotating", map[string]string{"access_key_id": "AKIDSECOND", "secret_access_key": "SECRET"})
+	value, _ = creds.Get()
+	internal.Equals(t, "AKIDFIRST", value.AccessKeyID)
+	time.Sleep(250 * time.Millisecond)
+	value, err = creds.Get()
+	internal.NoError(t, err)
+	internal.Equals(t, "AKIDSECOND", value.AccessKeyID)
+
+	// The credentials can be plugged straight into a session
+	custom, err := auth.NewCustomSession(auth.WithCredentials(creds))
+	internal.NoError(t, err)
+	value, err = custom.Config.Credentials.Get()
+	internal.NoError(t, err)
+	internal.Equals(t, "AKIDSECOND", value.AccessKeyID)
+	_, err = auth.NewCustomSession(auth.WithCredentials(nil))
+	internal.HasError(t, err)
+}
@@ -0,0 +1,155 @@
+// This file contains all the bits & pieces related to
+// resolving & validating the AWS region
+
+package auth
+
+import (
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+const (
+	// RegionSourceExplicit - the region was passed in explicitly
+	RegionSourceExplicit string = "EXPLICIT"
+
+	// RegionSourceEnvRegion - the region came from the AWS_REGION env var
+	RegionSourceEnvRegion string = "AWS_REGION"
+
+	// RegionSourceEnvDefaultRegion - the region came from the AWS_DEFAULT_REGION env var
+	RegionSourceEnvDefaultRegion string = "AWS_DEFAULT_REGION"
+
+	// RegionSourceProfile - the region came from the shared config profile
+	RegionSourceProfile string = "PROFILE"
+
+	// envRegion - the env var for the AWS region
+	envRegion string = "AWS_REGION"
+
+	// envDefaultRegion - the env var for the AWS default region
+	envDefaultRegion string = "AWS_DEFAULT_REGION"
+
+	// envProfile - the env var for the AWS profile
+	envProfile string = "AWS_PROFILE"
+
+	// defaultProfile - the name of the default shared config profile
+	defaultProfile string = "default"
+)
+
+// RegionConf - structure used to represent the inputs to region resolution.
+// Profile defaults to AWS_PROFILE (or "default") & SharedConfigFiles default
+// to the standard shared config & credentials files.
+type RegionConf struct {
+	Region            string
+	Profile           string
+	SharedConfigFiles []string
+}
+
+// RegionResolution - structure used to represent the resolved region & where it
+// came from. Partition is empty & Warning is set when the region isn't in the
+// partitions known to this version of the AWS SDK.
+type RegionResolution struct {
+	Region    string
+	Source    string
+	Partition string
+	Warning   string
+}
+
+// ResolveRegion - This function resolves the AWS region & checks it against the
+// known partitions. The region is taken from the first of the following that is
+// set (the Lambda runtime sets AWS_REGION):
+//
+//   1. the explicit region in the configuration
+//   2. the AWS_REGION environment variable
+//   3. the AWS_DEFAULT_REGION environment variable
+//   4. the region of the shared config profile
+//
+//   Regions launched after this version of the AWS SDK aren't known, so an
+//   unknown region isn't rejected. Warning is set instead, for the caller
+//   to report if it wants to.
+//
+//   Parameters:
+//     conf: the region resolution configuration
+//
+//   Example:
+//     res, err := ResolveRegion(RegionConf{Profile: "dev"})
+func ResolveRegion(conf RegionConf) (RegionResolution, error) {
+
+	// Work through the sources in order of precedence
+	var res RegionResolution
+	switch {
+	case conf.Region != "":
+		res.Region, res.Source = conf.Region, RegionSourceExplicit
+	case os.Getenv(envRegion) != "":
+		res.Region, res.Source = os.Getenv(envRegion), RegionSourceEnvRegion
+	case os.Getenv(envDefaultRegion) != "":
+		res.Region, res.Source = os.Getenv(envDefaultRegion), RegionSourceEnvDefaultRegion
+	default:
+		res.Region, res.Source = profileRegion(conf), RegionSourceProfile
+	}
+
+	// Did we find one?
+	if res.Region == "" {
+		return RegionResolution{}, newErrorRegionNotResolved()
+	}
+
+	// Check it against the known partitions
+	for _, p := range endpoints.DefaultPartitions() {
+		if _, ok := p.Regions()[res.Region]; ok {
+			res.Partition = p.ID()
+			return res, nil
+		}
+	}
+	res.Warning = newWarningRegionUnknown(res.Region, res.Source)
+	return res, nil
+}
+
+// WithResolvedRegion - This function creates a session option that sets the
+// region using ResolveRegion
+//
+//   Parameters:
+//     conf: the region resolution configuration
+//
+//   Example:
+//     sess, err := NewCustomSession(WithResolvedRegion(RegionConf{}))
+func WithResolvedRegion(conf RegionConf) SessionOption {
+
+	return func(opts *session.Options) error {
+		if len(conf.SharedConfigFiles) == 0 {
+			conf.SharedConfigFiles = sharedConfigFiles(*opts)
+		}
+		if conf.Profile == "" {
+			conf.Profile = opts.Profile
+		}
+		res, err := ResolveRegion(conf)
+		if err != nil {
+			return err
+		}
+		opts.Config.Region = aws.String(res.Region)
+		return nil
+	}
+}
+
+// profileRegion reads the region from the shared config profile
+func profileRegion(conf RegionConf) string {
+
+	// Work out the profile
+	profile := conf.Profile
+	if profile == "" {
+		profile = os.Getenv(envProfile)
+	}
+	if profile == "" {
+		profile = defaultProfile
+	}
+
+	// Work out the files
+	files := conf.SharedConfigFiles
+	if len(files) == 0 {
+		files = sharedConfigFiles(session.Options{})
+	}
+
+	// Read the profile
+	values, _ := readProfile(files, profile)
+	return values["region"]
+}
@@ -0,0 +1,110 @@
+package auth_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/bradmccoydev/self-service-sdk/internal"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/auth"
+)
+
+// manageRegionEnvVars handles creation/destruction of the region env vars
+func manageRegionEnvVars(t *testing.T, vars map[string]string) func(t *testing.T) {
+
+	// Clear anything already set
+	names := []string{internal.EnvAwsRegion, internal.EnvAwsDefRegion, "AWS_PROFILE", "AWS_LAMBDA_FUNCTION_NAME"}
+	saved := make(map[string]string)
+	for _, n := range names {
+		saved[n] = os.Getenv(n)
+		os.Unsetenv(n)
+	}
+	for k, v := range vars {
+		os.Setenv(k, v)
+	}
+	return func(t *testing.T) {
+
+		// Restore environment variables
+		for _, n := range names {
+			os.Unsetenv(n)
+			if saved[n] != "" {
+				os.Setenv(n, saved[n])
+			}
+		}
+	}
+}
+
+// Test ResolveRegion
+func TestResolveRegion(t *testing.T) {
+
+	// Setup backend
+	files := writeSharedConfigFiles(t)
+	lambda := map[string]string{"AWS_LAMBDA_FUNCTION_NAME": "fred", internal.EnvAwsRegion: "ap-southeast-2"}
+	unknown := map[string]string{internal.EnvAwsRegion: "moon-north-1"}
+	both := map[string]string{internal.EnvAwsRegion: "eu-west-1", internal.EnvAwsDefRegion: "eu-west-2"}
+
+	// Setup test data
+	tests := []struct {
+		desc           string
+		conf           auth.RegionConf
+		vars           map[string]string
+		expectErr      bool
+		expectedRegion string
+		expectedSource string
+		expectedPart   string
+		expectWarning  bool
+	}{
+		{"Nothing set", auth.RegionConf{SharedConfigFiles: files}, nil, true, "", "", "", false},
+		{"Unknown explicit region", auth.RegionConf{Region: "moon-north-1"}, nil, false, "moon-north-1", auth.RegionSourceExplicit, "", true},
+		{"Unknown environment region", auth.RegionConf{}, unknown, false, "moon-north-1", auth.RegionSourceEnvRegion, "", true},
+		{"Explicit region wins", auth.RegionConf{Region: "us-west-2"}, lambda, false, "us-west-2", auth.RegionSourceExplicit, "aws", false},
+		{"Lambda environment", auth.RegionConf{}, lambda, false, "ap-southeast-2", auth.RegionSourceEnvRegion, "aws", false},
+		{"AWS_REGION beats AWS_DEFAULT_REGION", auth.RegionConf{}, both, false, "eu-west-1", auth.RegionSourceEnvRegion, "aws", false},
+		{"AWS_DEFAULT_REGION", auth.RegionConf{}, map[string]string{internal.EnvAwsDefRegion: "cn-north-1"}, false, "cn-north-1", auth.RegionSourceEnvDefaultRegion, "aws-cn", false},
+		{"Named profile", auth.RegionConf{Profile: "static", SharedConfigFiles: files}, nil, false, "us-east-1", auth.RegionSourceProfile, "aws", false},
+		{"AWS_PROFILE", auth.RegionConf{SharedConfigFiles: files}, map[string]string{"AWS_PROFILE": "role"}, false, "us-east-1", auth.RegionSourceProfile, "aws", false},
+		{"Profile without region", auth.RegionConf{Profile: "garbage", SharedConfigFiles: files}, nil, true, "", "", "", false},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Run routine to setup appropriate env vars
+			teardownTestCase := manageRegionEnvVars(t, test.vars)
+			defer teardownTestCase(t)
+
+			// Run the test
+			res, err := auth.ResolveRegion(test.conf)
+			if test.expectErr {
+				internal.HasError(t, err)
+			} else {
+				internal.NoError(t, err)
+				internal.Equals(t, test.expectedRegion, res.Region)
+				internal.Equals(t, test.expectedSource, res.Source)
+				internal.Equals(t, test.expectedPart, res.Partition)
+				internal.Equals(t, test.expectWarning, res.Warning != "")
+			}
+		})
+	}
+}
+
+// Test WithResolvedRegion
+func TestWithResolvedRegion(t *testing.T) {
+
+	// Setup backend
+	files := writeSharedConfigFiles(t)
+	teardownTestCase := manageRegionEnvVars(t, nil)
+	defer teardownTestCase(t)
+
+	// The profile region should be picked up from the session's shared config files
+	sess, err := auth.NewSessionFromProfile("static", auth.WithSharedConfigFiles(files...), auth.WithResolvedRegion(auth.RegionConf{}))
+	internal.NoError(t, err)
+	internal.Equals(t, "us-east-1", aws.StringValue(sess.Config.Region))
+
+	// An unknown region is still used, it may be newer than the AWS SDK
+	sess, err = auth.NewCustomSession(auth.WithResolvedRegion(auth.RegionConf{Region: "moon-north-1"}))
+	internal.NoError(t, err)
+	internal.Equals(t, "moon-north-1", aws.StringValue(sess.Config.Region))
+}
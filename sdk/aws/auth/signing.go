@@ -0,0 +1,134 @@
+// This file contains all the bits & pieces related to
+// signing arbitrary HTTP requests (eg to IAM protected
+// API Gateway endpoints) with AWS Signature Version 4
+
+package auth
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+const (
+	// DefaultSigningService - the service requests are signed for by default (API Gateway)
+	DefaultSigningService string = "execute-api"
+)
+
+// SigningConf - structure used to represent the details used to sign requests.
+// An empty Service means execute-api & an empty Region means the session region.
+type SigningConf struct {
+	Service string
+	Region  string
+}
+
+// SigningTransport - an http.RoundTripper that signs each request with SigV4
+// before passing it on to the Base round tripper (http.DefaultTransport if nil)
+type SigningTransport struct {
+	Credentials *credentials.Credentials
+	Service     string
+	Region      string
+	Base        http.RoundTripper
+}
+
+// NewSigningTransport - This function creates a round tripper that signs requests
+// using the credentials from the session
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     conf: the service & region to sign for
+//
+//   Example:
+//     rt, err := NewSigningTransport(mySession, SigningConf{Region: "us-west-2"})
+func NewSigningTransport(sess *session.Session, conf SigningConf) (*SigningTransport, error) {
+
+	// Sanity check
+	if sess == nil {
+		return nil, newErrorSessionNotProvided()
+	}
+	if sess.Config.Credentials == nil {
+		return nil, newErrorCredentialsNotProvided()
+	}
+
+	// Fill in the defaults
+	if conf.Service == "" {
+		conf.Service = DefaultSigningService
+	}
+	if conf.Region == "" {
+		conf.Region = aws.StringValue(sess.Config.Region)
+	}
+	if conf.Region == "" {
+		return nil, newErrorRegionNotProvided()
+	}
+
+	// Create the transport
+	transport := &SigningTransport{
+		Credentials: sess.Config.Credentials,
+		Service:     conf.Service,
+		Region:      conf.Region,
+	}
+	return transport, nil
+}
+
+// NewSigningClient - This function creates an HTTP client that signs requests
+// using the credentials from the session
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     conf: the service & region to sign for
+//
+//   Example:
+//     client, err := NewSigningClient(mySession, SigningConf{})
+//     resp, err := client.Get("https://abc123.execute-api.us-west-2.amazonaws.com/prod/services")
+func NewSigningClient(sess *session.Session, conf SigningConf) (*http.Client, error) {
+
+	transport, err := NewSigningTransport(sess, conf)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// RoundTrip satisfies the http.RoundTripper interface
+func (t *SigningTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	// Work on a copy so the caller's request is left untouched
+	signed := req.Clone(req.Context())
+
+	// Read the body so it can be hashed as part of the signature
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Sign the request (the signer also sets the body on the copy)
+	var reader io.ReadSeeker
+	if body != nil {
+		reader = bytes.NewReader(body)
+		signed.ContentLength = int64(len(body))
+	}
+	signer := v4.NewSigner(t.Credentials)
+	_, err := signer.Sign(signed, reader, t.Service, t.Region, time.Now())
+	if err != nil {
+		return nil, classifyCredentialError(err)
+	}
+
+	// Pass it on
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(signed)
+}
@@ -0,0 +1,129 @@
+package auth_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/bradmccoydev/self-service-sdk/internal"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/auth"
+)
+
+// newVerifyingServer creates a server that checks the SigV4 signature of each
+// request by re-signing it with the same credentials & time
+func newVerifyingServer(creds *credentials.Credentials, service string, region string) *httptest.Server {
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		// Rebuild the request as the client signed it (the transport adds
+		// headers of its own after signing so only copy the signed ones)
+		body, _ := ioutil.ReadAll(r.Body)
+		check, _ := http.NewRequest(r.Method, "http://"+r.Host+r.URL.RequestURI(), nil)
+		authz := r.Header.Get("Authorization")
+		if i := strings.Index(authz, "SignedHeaders="); i >= 0 {
+			signed := strings.SplitN(authz[i+len("SignedHeaders="):], ",", 2)[0]
+			for _, h := range strings.Split(signed, ";") {
+				if v, ok := r.Header[http.CanonicalHeaderKey(h)]; ok {
+					check.Header[http.CanonicalHeaderKey(h)] = v
+				}
+			}
+		}
+		signTime, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+		if err != nil {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		// Re-sign it & compare
+		var reader io.ReadSeeker
+		if len(body) > 0 {
+			reader = bytes.NewReader(body)
+			check.ContentLength = int64(len(body))
+		}
+		v4.NewSigner(creds).Sign(check, reader, service, region, signTime)
+		if check.Header.Get("Authorization") != authz {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write(body)
+	}))
+}
+
+// Test NewSigningClient
+func TestNewSigningClient(t *testing.T) {
+
+	// Setup backend
+	creds := credentials.NewStaticCredentials("AKIDSIGN", "SECRET", "TOKEN")
+	server := newVerifyingServer(creds, "execute-api", "us-west-2")
+	defer server.Close()
+	validSess := session.Must(session.NewSession(&aws.Config{Region: aws.String("us-west-2"), Credentials: creds}))
+	noRegion := session.Must(session.NewSession(&aws.Config{Region: aws.String(""), Credentials: creds}))
+	wrongRegion := session.Must(session.NewSession(&aws.Config{Region: aws.String("eu-west-1"), Credentials: creds}))
+	noCreds := session.Must(session.NewSession(&aws.Config{Region: aws.String("us-west-2"), Credentials: credentials.NewStaticCredentials("", "", "")}))
+
+	// Setup test data
+	tests := []struct {
+		desc         string
+		sess         *session.Session
+		conf         auth.SigningConf
+		method       string
+		body         string
+		expectErr    bool
+		expectReqErr error
+		expectedCode int
+	}{
+		{"No session", nil, auth.SigningConf{}, "GET", "", true, nil, 0},
+		{"No region", noRegion, auth.SigningConf{}, "GET", "", true, nil, 0},
+		{"Missing credentials", noCreds, auth.SigningConf{}, "GET", "", false, auth.ErrCredentialsNotFound, 0},
+		{"Signed for the wrong region", wrongRegion, auth.SigningConf{}, "GET", "", false, nil, http.StatusForbidden},
+		{"Signed for the wrong service", validSess, auth.SigningConf{Service: "lambda"}, "GET", "", false, nil, http.StatusForbidden},
+		{"Valid GET", validSess, auth.SigningConf{}, "GET", "", false, nil, http.StatusOK},
+		{"Valid POST", validSess, auth.SigningConf{}, "POST", `{"service":"fred"}`, false, nil, http.StatusOK},
+		{"Region override", wrongRegion, auth.SigningConf{Region: "us-west-2", Service: "execute-api"}, "PUT", "blah", false, nil, http.StatusOK},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Run the test
+			client, err := auth.NewSigningClient(test.sess, test.conf)
+			if test.expectErr {
+				internal.HasError(t, err)
+				return
+			}
+			internal.NoError(t, err)
+			req, _ := http.NewRequest(test.method, server.URL+"/prod/services?name=fred", strings.NewReader(test.body))
+			if test.body == "" {
+				req, _ = http.NewRequest(test.method, server.URL+"/prod/services?name=fred", nil)
+			}
+			resp, err := client.Do(req)
+			if test.expectReqErr != nil {
+				internal.HasError(t, err)
+				internal.Assert(t, errors.Is(err, test.expectReqErr), "expected %v but got %v", test.expectReqErr, err)
+				return
+			}
+			internal.NoError(t, err)
+			defer resp.Body.Close()
+			internal.Equals(t, test.expectedCode, resp.StatusCode)
+
+			// The body should arrive intact & the caller's request should be unsigned
+			if resp.StatusCode == http.StatusOK {
+				body, _ := ioutil.ReadAll(resp.Body)
+				internal.Equals(t, test.body, string(body))
+			}
+			internal.Equals(t, "", req.Header.Get("Authorization"))
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package auth_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+const (
+	// The account id returned by the fake STS server
+	TestAccountID string = "123456789012"
+
+	// A valid role ARN for testing
+	TestRoleArnValid string = "arn:aws:iam::123456789012:role/testing"
+
+	// A second valid role ARN for testing role chaining
+	TestRoleArnChained string = "arn:aws:iam::210987654321:role/chained"
+
+	// An invalid role ARN for testing
+	TestRoleArnInvalid string = "garbage"
+
+	// The access key used by the base test session
+	TestBaseAccessKey string = "AKIDBASE"
+
+	// An access key the fake STS server treats as expired
+	TestExpiredAccessKey string = "AKIDEXPIRED"
+
+	// An access key the fake STS server treats as invalid
+	TestInvalidAccessKey string = "AKIDINVALID"
+)
+
+// FakeSts is a minimal stand-in for the STS API
+type FakeSts struct {
+	Server *httptest.Server
+	mu     sync.Mutex
+	calls  []map[string]string
+}
+
+// NewFakeSts starts a fake STS server
+func NewFakeSts() *FakeSts {
+
+	fake := &FakeSts{}
+	fake.Server = httptest.NewServer(http.HandlerFunc(fake.handle))
+	return fake
+}
+
+// Calls returns the form values of each request received
+func (f *FakeSts) Calls() []map[string]string {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]map[string]string{}, f.calls...)
+}
+
+// Session creates a session with static credentials that talks to the fake server
+func (f *FakeSts) Session() *session.Session {
+	return f.SessionWithKey(TestBaseAccessKey)
+}
+
+// SessionWithKey creates a session with the provided access key that talks to the fake server
+func (f *FakeSts) SessionWithKey(key string) *session.Session {
+
+	return session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(f.Server.URL),
+		Credentials: credentials.NewStaticCredentials(key, "SECRET", ""),
+	}))
+}
+
+// accessKey extracts the access key used to sign the request
+func accessKey(authorization string) string {
+
+	parts := strings.SplitN(authorization, "Credential=", 2)
+	if len(parts) != 2 {
+		return ""
+	}
+	return strings.SplitN(parts[1], "/", 2)[0]
+}
+
+// writeError writes an STS error response
+func writeError(w http.ResponseWriter, status int, code string, msg string) {
+
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `<ErrorResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <Error><Type>Sender</Type><Code>%s</Code><Message>%s</Message></Error>
+  <RequestId>1</RequestId>
+</ErrorResponse>`, code, msg)
+}
+
+// handle services a single STS request
+func (f *FakeSts) handle(w http.ResponseWriter, r *http.Request) {
+
+	// Record the call
+	r.ParseForm()
+	call := make(map[string]string)
+	for k := range r.Form {
+		call[k] = r.Form.Get(k)
+	}
+	call["Authorization"] = r.Header.Get("Authorization")
+	f.mu.Lock()
+	f.calls = append(f.calls, call)
+	count := len(f.calls)
+	f.mu.Unlock()
+
+	// Reject the "bad" access keys
+	key := accessKey(call["Authorization"])
+	switch key {
+	case TestExpiredAccessKey:
+		writeError(w, http.StatusForbidden, "ExpiredToken", "The security token included in the request is expired")
+		return
+	case TestInvalidAccessKey:
+		writeError(w, http.StatusForbidden, "InvalidClientTokenId", "The security token included in the request is invalid")
+		return
+	}
+
+	// Build the response
+	expiry := time.Now().UTC().Add(time.Hour).Format(time.RFC3339)
+	action := call["Action"]
+	switch action {
+	case "AssumeRole":
+		fmt.Fprintf(w, `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleResult>
+    <Credentials>
+      <AccessKeyId>AKIDROLE%d</AccessKeyId>
+      <SecretAccessKey>SECRET</SecretAccessKey>
+      <SessionToken>TOKEN</SessionToken>
+      <Expiration>%s</Expiration>
+    </Credentials>
+    <AssumedRoleUser>
+      <Arn>%s</Arn>
+      <AssumedRoleId>AROA:%s</AssumedRoleId>
+    </AssumedRoleUser>
+  </AssumeRoleResult>
+  <ResponseMetadata><RequestId>%d</RequestId></ResponseMetadata>
+</AssumeRoleResponse>`, count, expiry, call["RoleArn"], call["RoleSessionName"], count)
+	case "AssumeRoleWithWebIdentity":
+		if call["WebIdentityToken"] == "" {
+			writeError(w, http.StatusBadRequest, "InvalidIdentityToken", "No token provided")
+			return
+		}
+		fmt.Fprintf(w, `<AssumeRoleWithWebIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleWithWebIdentityResult>
+    <Credentials>
+      <AccessKeyId>AKIDWEB%d</AccessKeyId>
+      <SecretAccessKey>SECRET</SecretAccessKey>
+      <SessionToken>TOKEN</SessionToken>
+      <Expiration>%s</Expiration>
+    </Credentials>
+    <AssumedRoleUser>
+      <Arn>%s</Arn>
+      <AssumedRoleId>AROA:%s</AssumedRoleId>
+    </AssumedRoleUser>
+  </AssumeRoleWithWebIdentityResult>
+  <ResponseMetadata><RequestId>%d</RequestId></ResponseMetadata>
+</AssumeRoleWithWebIdentityResponse>`, count, expiry, call["RoleArn"], call["RoleSessionName"], count)
+	case "GetCallerIdentity":
+		callerArn := "arn:aws:iam::" + TestAccountID + ":user/testing"
+		if strings.HasPrefix(key, "AKIDROLE") {
+			callerArn = "arn:aws:sts::" + TestAccountID + ":assumed-role/testing/fred"
+		}
+		fmt.Fprintf(w, `<GetCallerIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <GetCallerIdentityResult>
+    <Arn>%s</Arn>
+    <UserId>%s</UserId>
+    <Account>%s</Account>
+  </GetCallerIdentityResult>
+  <ResponseMetadata><RequestId>%d</RequestId></ResponseMetadata>
+</GetCallerIdentityResponse>`, callerArn, key, TestAccountID, count)
+	default:
+		writeError(w, http.StatusBadRequest, "InvalidAction", "Unsupported action "+action)
+	}
+}
@@ -0,0 +1,115 @@
+// This file contains all the bits & pieces related to
+// creating sessions from web identity (OIDC) tokens
+
+package auth
+
+import (
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+const (
+	// EnvWebIdentityRoleArn - the env var for the role to assume with a web identity token
+	EnvWebIdentityRoleArn string = "AWS_ROLE_ARN"
+
+	// EnvWebIdentityTokenFile - the env var for the web identity token file
+	EnvWebIdentityTokenFile string = "AWS_WEB_IDENTITY_TOKEN_FILE"
+
+	// EnvWebIdentitySessionName - the env var for the web identity role session name
+	EnvWebIdentitySessionName string = "AWS_ROLE_SESSION_NAME"
+)
+
+// WebIdentityTokenProvider - a function that returns a web identity (OIDC) token
+type WebIdentityTokenProvider func() (string, error)
+
+// WebIdentityConf - structure used to represent the role to assume with a web
+// identity token. The token is read from TokenProvider if set, otherwise from TokenFile.
+type WebIdentityConf struct {
+	RoleArn       string
+	SessionName   string
+	TokenFile     string
+	TokenProvider WebIdentityTokenProvider
+	Duration      time.Duration
+	ExpiryWindow  time.Duration
+}
+
+// NewWebIdentitySession - This function creates an AWS session whose credentials
+// are obtained by calling STS AssumeRoleWithWebIdentity. The token is re-read and
+// the credentials refreshed automatically before they expire.
+//
+//   Parameters:
+//     conf: the role & token details
+//     options: any additional session option(s) to apply
+//
+//   Example:
+//     sess, err := NewWebIdentitySession(WebIdentityConfFromEnv())
+func NewWebIdentitySession(conf WebIdentityConf, options ...SessionOption) (*session.Session, error) {
+
+	// Sanity check
+	err := validateAssumeRoleConf(AssumeRoleConf{RoleArn: conf.RoleArn, Duration: conf.Duration})
+	if err != nil {
+		return nil, err
+	}
+	if conf.TokenFile == "" && conf.TokenProvider == nil {
+		return nil, newErrorWebIdentityTokenNotProvided()
+	}
+
+	// Create the base session used to talk to STS
+	base, err := NewCustomSession(options...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Work out where the token comes from
+	var fetcher stscreds.TokenFetcher = stscreds.FetchTokenPath(conf.TokenFile)
+	if conf.TokenProvider != nil {
+		fetcher = conf.TokenProvider
+	}
+
+	// Build the credential provider
+	sessionName := DefaultRoleSessionName
+	if conf.SessionName != "" {
+		sessionName = conf.SessionName
+	}
+	provider := stscreds.NewWebIdentityRoleProviderWithToken(sts.New(base), conf.RoleArn, sessionName, fetcher)
+	provider.Duration = conf.Duration
+	provider.ExpiryWindow = DefaultExpiryWindow
+	if conf.ExpiryWindow != 0 {
+		provider.ExpiryWindow = conf.ExpiryWindow
+	}
+
+	// Return a session using the credentials
+	sess := base.Copy(&aws.Config{Credentials: credentials.NewCredentials(provider)})
+	return sess, nil
+}
+
+// WebIdentityConfFromEnv - This function builds the web identity configuration
+// from the AWS_ROLE_ARN, AWS_WEB_IDENTITY_TOKEN_FILE & AWS_ROLE_SESSION_NAME
+// environment variables (as set for EKS pods & many CI runners)
+//
+//   Example:
+//     conf := WebIdentityConfFromEnv()
+func WebIdentityConfFromEnv() WebIdentityConf {
+
+	return WebIdentityConf{
+		RoleArn:     os.Getenv(EnvWebIdentityRoleArn),
+		SessionName: os.Getenv(EnvWebIdentitySessionName),
+		TokenFile:   os.Getenv(EnvWebIdentityTokenFile),
+	}
+}
+
+// FetchToken satisfies the stscreds.TokenFetcher interface
+func (p WebIdentityTokenProvider) FetchToken(ctx credentials.Context) ([]byte, error) {
+
+	token, err := p()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(token), nil
+}
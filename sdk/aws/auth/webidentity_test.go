@@ -0,0 +1,122 @@
+package auth_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bradmccoydev/self-service-sdk/internal"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/auth"
+)
+
+// Test NewWebIdentitySession
+func TestNewWebIdentitySession(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeSts()
+	defer fake.Server.Close()
+	stsEndpoint := auth.WithEndpoints(auth.EndpointConf{Endpoints: map[string]string{"sts": fake.Server.URL}})
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	internal.NoError(t, ioutil.WriteFile(tokenFile, []byte("file-token"), 0600))
+	callback := func() (string, error) { return "callback-token", nil }
+
+	// Setup test data
+	tests := []struct {
+		desc          string
+		conf          auth.WebIdentityConf
+		expectErr     bool
+		expectGetErr  bool
+		expectedToken string
+	}{
+		{"No inputs", auth.WebIdentityConf{}, true, false, ""},
+		{"Invalid role ARN", auth.WebIdentityConf{RoleArn: TestRoleArnInvalid, TokenFile: tokenFile}, true, false, ""},
+		{"No token", auth.WebIdentityConf{RoleArn: TestRoleArnValid}, true, false, ""},
+		{"Missing token file", auth.WebIdentityConf{RoleArn: TestRoleArnValid, TokenFile: tokenFile + ".missing"}, false, true, ""},
+		{"Token file", auth.WebIdentityConf{RoleArn: TestRoleArnValid, TokenFile: tokenFile}, false, false, "file-token"},
+		{"Token callback", auth.WebIdentityConf{RoleArn: TestRoleArnValid, TokenFile: tokenFile, TokenProvider: callback, SessionName: "fred"}, false, false, "callback-token"},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Run routine to setup appropriate env vars
+			teardownTestCase := manageEndpointEnvVars(t, nil)
+			defer teardownTestCase(t)
+
+			// Run the test
+			before := len(fake.Calls())
+			sess, err := auth.NewWebIdentitySession(test.conf, stsEndpoint)
+			if test.expectErr {
+				internal.HasError(t, err)
+				return
+			}
+			internal.NoError(t, err)
+			_, err = sess.Config.Credentials.Get()
+			if test.expectGetErr {
+				internal.HasError(t, err)
+				return
+			}
+			internal.NoError(t, err)
+
+			// Check the values passed to STS
+			calls := fake.Calls()[before:]
+			internal.Equals(t, 1, len(calls))
+			internal.Equals(t, "AssumeRoleWithWebIdentity", calls[0]["Action"])
+			internal.Equals(t, test.expectedToken, calls[0]["WebIdentityToken"])
+			internal.Equals(t, "", calls[0]["Authorization"])
+			if test.conf.SessionName != "" {
+				internal.Equals(t, test.conf.SessionName, calls[0]["RoleSessionName"])
+			} else {
+				internal.Equals(t, auth.DefaultRoleSessionName, calls[0]["RoleSessionName"])
+			}
+		})
+	}
+}
+
+// Test NewWebIdentitySession refreshes using the latest token
+func TestNewWebIdentitySessionRefresh(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeSts()
+	defer fake.Server.Close()
+	teardownTestCase := manageEndpointEnvVars(t, nil)
+	defer teardownTestCase(t)
+
+	// The fake credentials last an hour so a two hour window forces a refresh every time
+	var token string
+	conf := auth.WebIdentityConf{
+		RoleArn:       TestRoleArnValid,
+		TokenProvider: func() (string, error) { return token, nil },
+		ExpiryWindow:  2 * time.Hour,
+	}
+	sess, err := auth.NewWebIdentitySession(conf, auth.WithEndpoints(auth.EndpointConf{Endpoints: map[string]string{"sts": fake.Server.URL}}))
+	internal.NoError(t, err)
+
+	// Fetch the credentials with a rotating token
+	for _, token = range []string{"first", "second"} {
+		_, err = sess.Config.Credentials.Get()
+		internal.NoError(t, err)
+	}
+	calls := fake.Calls()
+	internal.Equals(t, 2, len(calls))
+	internal.Equals(t, "first", calls[0]["WebIdentityToken"])
+	internal.Equals(t, "second", calls[1]["WebIdentityToken"])
+}
+
+// Test WebIdentityConfFromEnv
+func TestWebIdentityConfFromEnv(t *testing.T) {
+
+	// Setup env vars
+	os.Setenv(auth.EnvWebIdentityRoleArn, TestRoleArnValid)
+	os.Setenv(auth.EnvWebIdentityTokenFile, "/var/run/token")
+	defer os.Unsetenv(auth.EnvWebIdentityRoleArn)
+	defer os.Unsetenv(auth.EnvWebIdentityTokenFile)
+
+	// Run the test
+	expected := auth.WebIdentityConf{RoleArn: TestRoleArnValid, TokenFile: "/var/run/token"}
+	internal.Equals(t, expected, auth.WebIdentityConfFromEnv())
+}
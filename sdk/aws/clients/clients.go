@@ -0,0 +1,128 @@
+// This file contains all the bits & pieces related to
+// caching service clients for registered sessions
+
+package clients
+
+import (
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+// registry - the client cache of each registered session
+var registry sync.Map
+
+// cache - the clients created for a session, keyed on service
+type cache struct {
+	mu      sync.Mutex
+	clients map[string]interface{}
+}
+
+// Register - This function marks a long-lived session so the clients created
+// for it are cached. Sessions from an auth.SessionManager are registered
+// automatically. Clients for unregistered sessions are created on each call,
+// so short-lived sessions aren't kept around.
+//
+//   Parameters:
+//     sess: the session to register
+//
+//   Example:
+//     clients.Register(mySession)
+func Register(sess *session.Session) {
+
+	if sess == nil {
+		return
+	}
+	registry.LoadOrStore(sess, &cache{clients: make(map[string]interface{})})
+}
+
+// Forget - This function drops a registered session & its cached clients
+//
+//   Parameters:
+//     sess: the session to forget
+//
+//   Example:
+//     clients.Forget(mySession)
+func Forget(sess *session.Session) {
+	registry.Delete(sess)
+}
+
+// Get - This function returns the client for the session & service, creating
+// it with the provided function if it isn't cached
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     service: a name identifying the client, eg the service endpoint ID
+//     create: a function that creates the client from a session
+//
+//   Example:
+//     svc := clients.Get(mySession, s3.EndpointsID, func(s *session.Session) interface{} { return s3.New(s) }).(*s3.S3)
+func Get(sess *session.Session, service string, create func(*session.Session) interface{}) interface{} {
+
+	// Only registered sessions are cached
+	value, ok := registry.Load(sess)
+	if !ok {
+		return create(sess)
+	}
+
+	// Already have one?
+	c := value.(*cache)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if client, ok := c.clients[service]; ok {
+		return client
+	}
+
+	// Create & cache it
+	client := create(sess)
+	c.clients[service] = client
+	return client
+}
+
+// DynamoDB - This function returns the DynamoDB client for the session
+//
+//   Parameters:
+//     sess: a valid AWS session
+//
+//   Example:
+//     svc := clients.DynamoDB(mySession)
+func DynamoDB(sess *session.Session) *dynamodb.DynamoDB {
+	return Get(sess, dynamodb.EndpointsID, func(s *session.Session) interface{} { return dynamodb.New(s) }).(*dynamodb.DynamoDB)
+}
+
+// S3 - This function returns the S3 client for the session
+//
+//   Parameters:
+//     sess: a valid AWS session
+//
+//   Example:
+//     svc := clients.S3(mySession)
+func S3(sess *session.Session) *s3.S3 {
+	return Get(sess, s3.EndpointsID, func(s *session.Session) interface{} { return s3.New(s) }).(*s3.S3)
+}
+
+// SecretsManager - This function returns the Secrets Manager client for the session
+//
+//   Parameters:
+//     sess: a valid AWS session
+//
+//   Example:
+//     svc := clients.SecretsManager(mySession)
+func SecretsManager(sess *session.Session) *secretsmanager.SecretsManager {
+	return Get(sess, secretsmanager.EndpointsID, func(s *session.Session) interface{} { return secretsmanager.New(s) }).(*secretsmanager.SecretsManager)
+}
+
+// STS - This function returns the STS client for the session
+//
+//   Parameters:
+//     sess: a valid AWS session
+//
+//   Example:
+//     svc := clients.STS(mySession)
+func STS(sess *session.Session) *sts.STS {
+	return Get(sess, sts.EndpointsID, func(s *session.Session) interface{} { return sts.New(s) }).(*sts.STS)
+}
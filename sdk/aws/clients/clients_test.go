@@ -0,0 +1,70 @@
+package clients_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/bradmccoydev/self-service-sdk/internal"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/clients"
+)
+
+// newTestSession creates a session that never calls AWS
+func newTestSession() *session.Session {
+
+	return session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("AKIDCLIENTS", "SECRET", ""),
+	}))
+}
+
+// Test the client cache
+func TestClients(t *testing.T) {
+
+	// Unregistered sessions get a new client each time
+	sess := newTestSession()
+	internal.Assert(t, clients.DynamoDB(sess) != clients.DynamoDB(sess), "unexpected cached client")
+
+	// Registered sessions reuse their clients, per service
+	clients.Register(sess)
+	ddb := clients.DynamoDB(sess)
+	internal.Assert(t, ddb == clients.DynamoDB(sess), "expected the cached dynamodb client")
+	internal.Assert(t, clients.S3(sess) == clients.S3(sess), "expected the cached s3 client")
+	internal.Assert(t, clients.SecretsManager(sess) == clients.SecretsManager(sess), "expected the cached secrets manager client")
+	internal.Assert(t, clients.STS(sess) == clients.STS(sess), "expected the cached sts client")
+	internal.Assert(t, clients.DynamoDB(newTestSession()) != ddb, "unexpected client from another session")
+
+	// Registering again keeps the cache, forgetting drops it
+	clients.Register(sess)
+	internal.Assert(t, ddb == clients.DynamoDB(sess), "expected the cached dynamodb client")
+	clients.Forget(sess)
+	internal.Assert(t, ddb != clients.DynamoDB(sess), "unexpected cached client")
+}
+
+// Test the client cache is safe for concurrent use
+func TestClientsConcurrent(t *testing.T) {
+
+	// Hammer the cache & make sure only one client is created
+	sess := newTestSession()
+	clients.Register(sess)
+	defer clients.Forget(sess)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	created := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			clients.Get(sess, "counter", func(s *session.Session) interface{} {
+				mu.Lock()
+				defer mu.Unlock()
+				created++
+				return created
+			})
+		}()
+	}
+	wg.Wait()
+	internal.Equals(t, 1, created)
+}
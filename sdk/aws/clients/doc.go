@@ -0,0 +1,11 @@
+// Package clients caches AWS service clients per session, so the sdk
+// packages can reuse them across calls instead of creating a new client
+// every time.
+//
+//   The following AWS GoLang SDK packages are used:
+//     * aws/session
+//     * service/dynamodb
+//     * service/s3
+//     * service/secretsmanager
+//     * service/sts
+package clients
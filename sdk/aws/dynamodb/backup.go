@@ -0,0 +1,395 @@
+// This file contains all the bits & pieces related to
+// protecting table data: on-demand backups, point in
+// time recovery & restoring tables
+
+package dynamodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/clients"
+)
+
+// BackupInfo - structure used to represent an on-demand backup
+type BackupInfo struct {
+	BackupArn  string
+	BackupName string
+	TableName  string
+	Status     string
+	Type       string
+	SizeBytes  int64
+	Created    time.Time
+}
+
+// CreateBackup - This function creates an on-demand backup of the table &
+// waits (using the default WaitConf) until it is AVAILABLE
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     tableName: the name of the table to back up
+//     backupName: the name to give the backup
+//
+//   Example:
+//     arn, err := CreateBackup(mySession, "fred", "fred-before-migration")
+func CreateBackup(sess *session.Session, tableName string, backupName string) (string, error) {
+	return CreateBackupWithContext(context.Background(), sess, tableName, backupName, WaitConf{})
+}
+
+// CreateBackupWithContext - This function creates an on-demand backup of the
+// table & waits until it is AVAILABLE
+//
+//   Parameters:
+//     ctx: the context used to cancel the request & wait
+//     sess: a valid AWS session
+//     tableName: the name of the table to back up
+//     backupName: the name to give the backup
+//     wait: the timeout & backoff to use while waiting
+//
+//   Example:
+//     arn, err := CreateBackupWithContext(ctx, mySession, "fred", "fred-before-migration", WaitConf{})
+func CreateBackupWithContext(ctx context.Context, sess *session.Session, tableName string, backupName string, wait WaitConf) (string, error) {
+
+	// Sanity check
+	if tableName == "" {
+		return "", newErrorTableNameNotProvided()
+	}
+	if backupName == "" {
+		return "", newErrorBackupNameNotProvided()
+	}
+
+	// Make the call to DynamoDB
+	svc := clients.DynamoDB(sess)
+	result, err := svc.CreateBackupWithContext(ctx, &dynamodb.CreateBackupInput{
+		TableName:  aws.String(tableName),
+		BackupName: aws.String(backupName),
+	})
+	if err != nil {
+		return "", err
+	}
+	if result.BackupDetails == nil {
+		return "", newErrorBackupDetailsNotProvided()
+	}
+
+	// Wait for it to be usable
+	arn := aws.StringValue(result.BackupDetails.BackupArn)
+	return arn, WaitUntilBackupAvailable(ctx, sess, arn, wait)
+}
+
+// ListBackups - This function retrieves all the on-demand backups of the table,
+// or of every table if tableName is empty
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     tableName: the name of the table to list the backups of
+//
+//   Example:
+//     backups, err := ListBackups(mySession, "fred")
+func ListBackups(sess *session.Session, tableName string) ([]BackupInfo, error) {
+	return ListBackupsWithContext(context.Background(), sess, tableName)
+}
+
+// ListBackupsWithContext - This function retrieves all the on-demand backups
+// of the table, or of every table if tableName is empty
+//
+//   Parameters:
+//     ctx: the context used to cancel the requests
+//     sess: a valid AWS session
+//     tableName: the name of the table to list the backups of
+//
+//   Example:
+//     backups, err := ListBackupsWithContext(ctx, mySession, "fred")
+func ListBackupsWithContext(ctx context.Context, sess *session.Session, tableName string) ([]BackupInfo, error) {
+
+	// Create a basic input structure for the request
+	params := &dynamodb.ListBackupsInput{BackupType: aws.String(dynamodb.BackupTypeFilterUser)}
+	if tableName != "" {
+		params.TableName = aws.String(tableName)
+	}
+
+	// Read every page
+	svc := clients.DynamoDB(sess)
+	var backups []BackupInfo
+	for {
+		result, err := svc.ListBackupsWithContext(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range result.BackupSummaries {
+			backups = append(backups, BackupInfo{
+				BackupArn:  aws.StringValue(b.BackupArn),
+				BackupName: aws.StringValue(b.BackupName),
+				TableName:  aws.StringValue(b.TableName),
+				Status:     aws.StringValue(b.BackupStatus),
+				Type:       aws.StringValue(b.BackupType),
+				SizeBytes:  aws.Int64Value(b.BackupSizeBytes),
+				Created:    aws.TimeValue(b.BackupCreationDateTime),
+			})
+		}
+		if aws.StringValue(result.LastEvaluatedBackupArn) == "" {
+			return backups, nil
+		}
+		params.ExclusiveStartBackupArn = result.LastEvaluatedBackupArn
+	}
+}
+
+// DeleteBackup - This function deletes an on-demand backup & waits (using
+// the default WaitConf) until it is gone
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     backupArn: the ARN of the backup to delete
+//
+//   Example:
+//     err := DeleteBackup(mySession, arn)
+func DeleteBackup(sess *session.Session, backupArn string) error {
+	return DeleteBackupWithContext(context.Background(), sess, backupArn, WaitConf{})
+}
+
+// DeleteBackupWithContext - This function deletes an on-demand backup & waits
+// until it is gone
+//
+//   Parameters:
+//     ctx: the context used to cancel the request & wait
+//     sess: a valid AWS session
+//     backupArn: the ARN of the backup to delete
+//     wait: the timeout & backoff to use while waiting
+//
+//   Example:
+//     err := DeleteBackupWithContext(ctx, mySession, arn, WaitConf{})
+func DeleteBackupWithContext(ctx context.Context, sess *session.Session, backupArn string, wait WaitConf) error {
+
+	// Sanity check
+	if backupArn == "" {
+		return newErrorBackupArnNotProvided()
+	}
+
+	// Make the call to DynamoDB
+	svc := clients.DynamoDB(sess)
+	_, err := svc.DeleteBackupWithContext(ctx, &dynamodb.DeleteBackupInput{BackupArn: aws.String(backupArn)})
+	if err != nil {
+		return err
+	}
+
+	// Wait for it to go
+	return WaitUntilBackupDeleted(ctx, sess, backupArn, wait)
+}
+
+// EnablePointInTimeRecovery - This function turns on point in time recovery
+// for the table & waits (using the default WaitConf) until it is ENABLED
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     tableName: the name of the table
+//
+//   Example:
+//     err := EnablePointInTimeRecovery(mySession, "fred")
+func EnablePointInTimeRecovery(sess *session.Session, tableName string) error {
+	return SetPointInTimeRecoveryWithContext(context.Background(), sess, tableName, true, WaitConf{})
+}
+
+// DisablePointInTimeRecovery - This function turns off point in time recovery
+// for the table & waits (using the default WaitConf) until it is DISABLED
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     tableName: the name of the table
+//
+//   Example:
+//     err := DisablePointInTimeRecovery(mySession, "fred")
+func DisablePointInTimeRecovery(sess *session.Session, tableName string) error {
+	return SetPointInTimeRecoveryWithContext(context.Background(), sess, tableName, false, WaitConf{})
+}
+
+// SetPointInTimeRecoveryWithContext - This function turns point in time
+// recovery for the table on or off & waits until the change has taken effect
+//
+//   Parameters:
+//     ctx: the context used to cancel the request & wait
+//     sess: a valid AWS session
+//     tableName: the name of the table
+//     enabled: whether point in time recovery should be on
+//     wait: the timeout & backoff to use while waiting
+//
+//   Example:
+//     err := SetPointInTimeRecoveryWithContext(ctx, mySession, "fred", true, WaitConf{})
+func SetPointInTimeRecoveryWithContext(ctx context.Context, sess *session.Session, tableName string, enabled bool, wait WaitConf) error {
+
+	// Sanity check
+	if tableName == "" {
+		return newErrorTableNameNotProvided()
+	}
+
+	// Make the call to DynamoDB
+	svc := clients.DynamoDB(sess)
+	_, err := svc.UpdateContinuousBackupsWithContext(ctx, &dynamodb.UpdateContinuousBackupsInput{
+		TableName: aws.String(tableName),
+		PointInTimeRecoverySpecification: &dynamodb.PointInTimeRecoverySpecification{
+			PointInTimeRecoveryEnabled: aws.Bool(enabled),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Wait for it to take effect
+	return WaitUntilPointInTimeRecovery(ctx, sess, tableName, enabled, wait)
+}
+
+// GetPointInTimeRecovery - This function checks if point in time recovery is
+// enabled for the table & returns the earliest & latest restorable times
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     tableName: the name of the table
+//
+//   Example:
+//     enabled, earliest, latest, err := GetPointInTimeRecovery(mySession, "fred")
+func GetPointInTimeRecovery(sess *session.Session, tableName string) (bool, time.Time, time.Time, error) {
+
+	// Sanity check
+	if tableName == "" {
+		return false, time.Time{}, time.Time{}, newErrorTableNameNotProvided()
+	}
+
+	// Make the call to DynamoDB
+	svc := clients.DynamoDB(sess)
+	result, err := svc.DescribeContinuousBackups(&dynamodb.DescribeContinuousBackupsInput{TableName: aws.String(tableName)})
+	if err != nil {
+		return false, time.Time{}, time.Time{}, err
+	}
+
+	// Extract the details
+	pitr := pointInTimeRecovery(result.ContinuousBackupsDescription)
+	if pitr == nil || aws.StringValue(pitr.PointInTimeRecoveryStatus) != dynamodb.PointInTimeRecoveryStatusEnabled {
+		return false, time.Time{}, time.Time{}, nil
+	}
+	return true, aws.TimeValue(pitr.EarliestRestorableDateTime), aws.TimeValue(pitr.LatestRestorableDateTime), nil
+}
+
+// RestoreTableToPointInTime - This function restores the source table, as it
+// was at restoreTime, into a new table & waits (for up to
+// DefaultRestoreWaitTimeout) until the new table is ACTIVE. A zero
+// restoreTime uses the latest restorable time.
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     sourceTable: the name of the table to restore
+//     targetTable: the name of the new table
+//     restoreTime: the point in time to restore
+//
+//   Example:
+//     err := RestoreTableToPointInTime(mySession, "fred", "fred-restored", time.Now().Add(-time.Hour))
+func RestoreTableToPointInTime(sess *session.Session, sourceTable string, targetTable string, restoreTime time.Time) error {
+	return RestoreTableToPointInTimeWithContext(context.Background(), sess, sourceTable, targetTable, restoreTime, WaitConf{})
+}
+
+// RestoreTableToPointInTimeWithContext - This function restores the source
+// table, as it was at restoreTime, into a new table & waits until the new
+// table is ACTIVE. A zero restoreTime uses the latest restorable time &
+// a zero timeout uses DefaultRestoreWaitTimeout.
+//
+//   Parameters:
+//     ctx: the context used to cancel the request & wait
+//     sess: a valid AWS session
+//     sourceTable: the name of the table to restore
+//     targetTable: the name of the new table
+//     restoreTime: the point in time to restore
+//     wait: the timeout & backoff to use while waiting
+//
+//   Example:
+//     err := RestoreTableToPointInTimeWithContext(ctx, mySession, "fred", "fred-restored", time.Time{}, WaitConf{Timeout: time.Hour})
+func RestoreTableToPointInTimeWithContext(ctx context.Context, sess *session.Session, sourceTable string, targetTable string, restoreTime time.Time, wait WaitConf) error {
+
+	// Sanity check
+	if sourceTable == "" || targetTable == "" {
+		return newErrorTableNameNotProvided()
+	}
+	if sourceTable == targetTable {
+		return newErrorRestoreTargetInvalid(targetTable)
+	}
+
+	// Build the input params
+	params := &dynamodb.RestoreTableToPointInTimeInput{
+		SourceTableName: aws.String(sourceTable),
+		TargetTableName: aws.String(targetTable),
+	}
+	if restoreTime.IsZero() {
+		params.UseLatestRestorableTime = aws.Bool(true)
+	} else {
+		params.RestoreDateTime = aws.Time(restoreTime)
+	}
+
+	// Make the call to DynamoDB
+	svc := clients.DynamoDB(sess)
+	_, err := svc.RestoreTableToPointInTimeWithContext(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	// Wait for the new table to be ready, restores take a while
+	return WaitUntilTableActive(ctx, sess, targetTable, wait.WithDefaults(WaitConf{Timeout: DefaultRestoreWaitTimeout}))
+}
+
+// RestoreTableFromBackup - This function restores an on-demand backup into a
+// new table & waits (for up to DefaultRestoreWaitTimeout) until it is ACTIVE
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     backupArn: the ARN of the backup to restore
+//     targetTable: the name of the new table
+//
+//   Example:
+//     err := RestoreTableFromBackup(mySession, arn, "fred-restored")
+func RestoreTableFromBackup(sess *session.Session, backupArn string, targetTable string) error {
+	return RestoreTableFromBackupWithContext(context.Background(), sess, backupArn, targetTable, WaitConf{})
+}
+
+// RestoreTableFromBackupWithContext - This function restores an on-demand
+// backup into a new table & waits until it is ACTIVE. A zero timeout uses
+// DefaultRestoreWaitTimeout.
+//
+//   Parameters:
+//     ctx: the context used to cancel the request & wait
+//     sess: a valid AWS session
+//     backupArn: the ARN of the backup to restore
+//     targetTable: the name of the new table
+//     wait: the timeout & backoff to use while waiting
+//
+//   Example:
+//     err := RestoreTableFromBackupWithContext(ctx, mySession, arn, "fred-restored", WaitConf{Timeout: time.Hour})
+func RestoreTableFromBackupWithContext(ctx context.Context, sess *session.Session, backupArn string, targetTable string, wait WaitConf) error {
+
+	// Sanity check
+	if backupArn == "" {
+		return newErrorBackupArnNotProvided()
+	}
+	if targetTable == "" {
+		return newErrorTableNameNotProvided()
+	}
+
+	// Make the call to DynamoDB
+	svc := clients.DynamoDB(sess)
+	_, err := svc.RestoreTableFromBackupWithContext(ctx, &dynamodb.RestoreTableFromBackupInput{
+		BackupArn:       aws.String(backupArn),
+		TargetTableName: aws.String(targetTable),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Wait for the new table to be ready, restores take a while
+	return WaitUntilTableActive(ctx, sess, targetTable, wait.WithDefaults(WaitConf{Timeout: DefaultRestoreWaitTimeout}))
+}
+
+// pointInTimeRecovery extracts the point in time recovery details
+func pointInTimeRecovery(desc *dynamodb.ContinuousBackupsDescription) *dynamodb.PointInTimeRecoveryDescription {
+
+	if desc == nil {
+		return nil
+	}
+	return desc.PointInTimeRecoveryDescription
+}
@@ -0,0 +1,216 @@
+package dynamodb_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bradmccoydev/self-service-sdk/internal"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/dynamodb"
+)
+
+// The ARN of the fake backup
+const testBackupArn string = "arn:aws:dynamodb:us-east-1:123456789012:table/testing/backup/01"
+
+// backupStatus returns a DescribeBackup response with the given status
+func backupStatus(status string) func() (int, interface{}) {
+	return func() (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{"BackupDescription": map[string]interface{}{
+			"BackupDetails": map[string]interface{}{"BackupArn": testBackupArn, "BackupName": "nightly", "BackupStatus": status},
+		}}
+	}
+}
+
+// pitrStatus returns a DescribeContinuousBackups response with the given status
+func pitrStatus(status string) func() (int, interface{}) {
+	return func() (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{"ContinuousBackupsDescription": map[string]interface{}{
+			"ContinuousBackupsStatus": "ENABLED",
+			"PointInTimeRecoveryDescription": map[string]interface{}{
+				"PointInTimeRecoveryStatus":  status,
+				"EarliestRestorableDateTime": 1600000000,
+				"LatestRestorableDateTime":   1600003600,
+			},
+		}}
+	}
+}
+
+// Test CreateBackup
+func TestCreateBackup(t *testing.T) {
+
+	// Setup test data
+	tests := []struct {
+		desc          string
+		tableName     string
+		backupName    string
+		responses     []func() (int, interface{})
+		expectErr     bool
+		expectedCalls int
+	}{
+		{"No table name", "", "nightly", nil, true, 0},
+		{"No backup name", TestTableNameValid, "", nil, true, 0},
+		{"Becomes available", TestTableNameValid, "nightly", []func() (int, interface{}){backupStatus("CREATING"), backupStatus("AVAILABLE")}, false, 2},
+		{"Deleted while creating", TestTableNameValid, "nightly", []func() (int, interface{}){backupStatus("CREATING"), backupStatus("DELETED")}, true, 2},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Setup backend
+			fake := NewFakeDynamo()
+			defer fake.Server.Close()
+			fake.Handle("CreateBackup", func(input map[string]interface{}) (int, interface{}) {
+				return http.StatusOK, map[string]interface{}{"BackupDetails": map[string]interface{}{"BackupArn": testBackupArn, "BackupStatus": "CREATING"}}
+			})
+			if test.responses != nil {
+				fake.Handle("DescribeBackup", internal.Sequence(test.responses...))
+			}
+
+			// Run the test
+			arn, err := dynamodb.CreateBackupWithContext(context.Background(), fake.Session(), test.tableName, test.backupName, fastWait)
+			if test.expectErr {
+				internal.HasError(t, err)
+			} else {
+				internal.NoError(t, err)
+				internal.Equals(t, testBackupArn, arn)
+				internal.Equals(t, "nightly", fake.Calls("CreateBackup")[0].Input["BackupName"])
+			}
+			internal.Equals(t, test.expectedCalls, len(fake.Calls("DescribeBackup")))
+		})
+	}
+}
+
+// Test ListBackups reads every page
+func TestListBackups(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("ListBackups", func(input map[string]interface{}) (int, interface{}) {
+		if input["ExclusiveStartBackupArn"] == nil {
+			return http.StatusOK, map[string]interface{}{
+				"BackupSummaries":        []interface{}{map[string]interface{}{"BackupArn": testBackupArn, "BackupName": "first", "TableName": TestTableNameValid, "BackupStatus": "AVAILABLE", "BackupType": "USER", "BackupSizeBytes": 10, "BackupCreationDateTime": 1600000000}},
+				"LastEvaluatedBackupArn": testBackupArn,
+			}
+		}
+		return http.StatusOK, map[string]interface{}{
+			"BackupSummaries": []interface{}{map[string]interface{}{"BackupArn": testBackupArn + "2", "BackupName": "second", "TableName": TestTableNameValid, "BackupStatus": "CREATING", "BackupType": "USER"}},
+		}
+	})
+
+	// Run the test
+	backups, err := dynamodb.ListBackups(fake.Session(), TestTableNameValid)
+	internal.NoError(t, err)
+	internal.Equals(t, 2, len(backups))
+	internal.Equals(t, dynamodb.BackupInfo{
+		BackupArn:  testBackupArn,
+		BackupName: "first",
+		TableName:  TestTableNameValid,
+		Status:     "AVAILABLE",
+		Type:       "USER",
+		SizeBytes:  10,
+		Created:    time.Unix(1600000000, 0).UTC(),
+	}, backups[0])
+	internal.Equals(t, "second", backups[1].BackupName)
+	calls := fake.Calls("ListBackups")
+	internal.Equals(t, TestTableNameValid, calls[0].Input["TableName"])
+	internal.Equals(t, "USER", calls[0].Input["BackupType"])
+	internal.Equals(t, testBackupArn, calls[1].Input["ExclusiveStartBackupArn"])
+}
+
+// Test DeleteBackup
+func TestDeleteBackup(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("DeleteBackup", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{}
+	})
+	fake.Handle("DescribeBackup", internal.Sequence(backupStatus("DELETING"), func() (int, interface{}) {
+		return http.StatusBadRequest, FakeError("BackupNotFoundException", "Backup not found")
+	}))
+
+	// Run the tests
+	err := dynamodb.DeleteBackupWithContext(context.Background(), fake.Session(), "", fastWait)
+	internal.HasError(t, err)
+	err = dynamodb.DeleteBackupWithContext(context.Background(), fake.Session(), testBackupArn, fastWait)
+	internal.NoError(t, err)
+	internal.Equals(t, 2, len(fake.Calls("DescribeBackup")))
+}
+
+// Test point in time recovery
+func TestPointInTimeRecovery(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("UpdateContinuousBackups", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{}
+	})
+	fake.Handle("DescribeContinuousBackups", internal.Sequence(pitrStatus("DISABLED"), pitrStatus("ENABLED")))
+
+	// Turn it on
+	err := dynamodb.SetPointInTimeRecoveryWithContext(context.Background(), fake.Session(), "", true, fastWait)
+	internal.HasError(t, err)
+	err = dynamodb.SetPointInTimeRecoveryWithContext(context.Background(), fake.Session(), TestTableNameValid, true, fastWait)
+	internal.NoError(t, err)
+	internal.Equals(t, map[string]interface{}{"PointInTimeRecoveryEnabled": true}, fake.Calls("UpdateContinuousBackups")[0].Input["PointInTimeRecoverySpecification"])
+	internal.Equals(t, 2, len(fake.Calls("DescribeContinuousBackups")))
+
+	// Check the restorable window
+	enabled, earliest, latest, err := dynamodb.GetPointInTimeRecovery(fake.Session(), TestTableNameValid)
+	internal.NoError(t, err)
+	internal.Assert(t, enabled, "point in time recovery should be enabled")
+	internal.Equals(t, time.Unix(1600000000, 0).UTC(), earliest)
+	internal.Equals(t, time.Unix(1600003600, 0).UTC(), latest)
+
+	// Turning it off times out as the fake never reports DISABLED again
+	err = dynamodb.SetPointInTimeRecoveryWithContext(context.Background(), fake.Session(), TestTableNameValid, false, dynamodb.WaitConf{Timeout: 50 * time.Millisecond, Delay: time.Millisecond})
+	internal.HasError(t, err)
+}
+
+// Test restoring tables
+func TestRestoreTable(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	ok := func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{}
+	}
+	fake.Handle("RestoreTableToPointInTime", ok)
+	fake.Handle("RestoreTableFromBackup", ok)
+	fake.Handle("DescribeTable", internal.Sequence(tableStatus("CREATING"), tableStatus("ACTIVE")))
+	ctx := context.Background()
+
+	// Sanity checks
+	internal.HasError(t, dynamodb.RestoreTableToPointInTimeWithContext(ctx, fake.Session(), TestTableNameValid, "", time.Time{}, fastWait))
+	internal.HasError(t, dynamodb.RestoreTableToPointInTimeWithContext(ctx, fake.Session(), TestTableNameValid, TestTableNameValid, time.Time{}, fastWait))
+	internal.HasError(t, dynamodb.RestoreTableFromBackupWithContext(ctx, fake.Session(), "", "restored", fastWait))
+	internal.HasError(t, dynamodb.RestoreTableFromBackupWithContext(ctx, fake.Session(), testBackupArn, "", fastWait))
+
+	// The latest restorable time
+	err := dynamodb.RestoreTableToPointInTimeWithContext(ctx, fake.Session(), TestTableNameValid, "restored", time.Time{}, fastWait)
+	internal.NoError(t, err)
+	input := fake.Calls("RestoreTableToPointInTime")[0].Input
+	internal.Equals(t, true, input["UseLatestRestorableTime"])
+	internal.Equals(t, "restored", input["TargetTableName"])
+	internal.Equals(t, 2, len(fake.Calls("DescribeTable")))
+
+	// A specific time
+	err = dynamodb.RestoreTableToPointInTimeWithContext(ctx, fake.Session(), TestTableNameValid, "restored", time.Unix(1600001800, 0), fastWait)
+	internal.NoError(t, err)
+	input = fake.Calls("RestoreTableToPointInTime")[1].Input
+	internal.Equals(t, float64(1600001800), input["RestoreDateTime"])
+	_, latest := input["UseLatestRestorableTime"]
+	internal.Assert(t, !latest, "should not use the latest restorable time")
+
+	// From a backup
+	err = dynamodb.RestoreTableFromBackupWithContext(ctx, fake.Session(), testBackupArn, "restored", fastWait)
+	internal.NoError(t, err)
+	internal.Equals(t, testBackupArn, fake.Calls("RestoreTableFromBackup")[0].Input["BackupArn"])
+}
@@ -0,0 +1,383 @@
+// This file contains all the bits & pieces related to
+// reading & writing items in bulk, retrying anything
+// Dynamo DB leaves unprocessed
+
+package dynamodb
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/clients"
+)
+
+const (
+	// MaxBatchWriteItems - the most items Dynamo DB accepts in one batch write
+	MaxBatchWriteItems int = 25
+
+	// MaxBatchGetItems - the most keys Dynamo DB accepts in one batch get
+	MaxBatchGetItems int = 100
+
+	// DefaultBatchMaxRetries - how many times to retry unprocessed items
+	DefaultBatchMaxRetries int = 10
+
+	// DefaultBatchDelay - the delay before the first retry
+	DefaultBatchDelay time.Duration = 50 * time.Millisecond
+
+	// DefaultBatchMaxDelay - the longest delay between retries
+	DefaultBatchMaxDelay time.Duration = 5 * time.Second
+)
+
+// BatchConf - structure used to represent how to run a batch operation.
+// Concurrency is the number of chunks sent at once (0 or 1 sends them one
+// after the other). Unprocessed items are retried up to MaxRetries times,
+// with the delay doubling after each retry up to MaxDelay. Zero values use
+// the defaults above.
+type BatchConf struct {
+	Concurrency int
+	MaxRetries  int
+	Delay       time.Duration
+	MaxDelay    time.Duration
+}
+
+// BatchPutItems - This function adds or replaces the items in the specified
+// table, MaxBatchWriteItems at a time
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     tableName: the name of the table to add the items to
+//     items: a slice of structures containing the item properties
+//     conf: the concurrency & retry settings
+//
+//   Example:
+//     err := BatchPutItems(mySession, "fred", myStructs, BatchConf{Concurrency: 4})
+func BatchPutItems(sess *session.Session, tableName string, items interface{}, conf BatchConf) error {
+	return BatchPutItemsWithContext(context.Background(), sess, tableName, items, conf)
+}
+
+// BatchPutItemsWithContext - This function adds or replaces the items in the
+// specified table, MaxBatchWriteItems at a time
+//
+//   Parameters:
+//     ctx: the context used to cancel the requests
+//     sess: a valid AWS session
+//     tableName: the name of the table to add the items to
+//     items: a slice of structures containing the item properties
+//     conf: the concurrency & retry settings
+//
+//   Example:
+//     err := BatchPutItemsWithContext(ctx, mySession, "fred", myStructs, BatchConf{})
+func BatchPutItemsWithContext(ctx context.Context, sess *session.Session, tableName string, items interface{}, conf BatchConf) error {
+
+	return batchWrite(ctx, sess, tableName, items, conf, func(item map[string]*dynamodb.AttributeValue) *dynamodb.WriteRequest {
+		return &dynamodb.WriteRequest{PutRequest: &dynamodb.PutRequest{Item: item}}
+	})
+}
+
+// BatchDeleteItems - This function deletes the items with the given keys from
+// the specified table, MaxBatchWriteItems at a time
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     tableName: the name of the table to delete the items from
+//     keys: a slice of structures containing the key values of the items
+//     conf: the concurrency & retry settings
+//
+//   Example:
+//     err := BatchDeleteItems(mySession, "fred", myKeys, BatchConf{})
+func BatchDeleteItems(sess *session.Session, tableName string, keys interface{}, conf BatchConf) error {
+	return BatchDeleteItemsWithContext(context.Background(), sess, tableName, keys, conf)
+}
+
+// BatchDeleteItemsWithContext - This function deletes the items with the given
+// keys from the specified table, MaxBatchWriteItems at a time
+//
+//   Parameters:
+//     ctx: the context used to cancel the requests
+//     sess: a valid AWS session
+//     tableName: the name of the table to delete the items from
+//     keys: a slice of structures containing the key values of the items
+//     conf: the concurrency & retry settings
+//
+//   Example:
+//     err := BatchDeleteItemsWithContext(ctx, mySession, "fred", myKeys, BatchConf{})
+func BatchDeleteItemsWithContext(ctx context.Context, sess *session.Session, tableName string, keys interface{}, conf BatchConf) error {
+
+	return batchWrite(ctx, sess, tableName, keys, conf, func(key map[string]*dynamodb.AttributeValue) *dynamodb.WriteRequest {
+		return &dynamodb.WriteRequest{DeleteRequest: &dynamodb.DeleteRequest{Key: key}}
+	})
+}
+
+// BatchGetItems - This function reads the items with the given keys from the
+// specified table, MaxBatchGetItems at a time. Missing items are skipped &
+// the items are not returned in any particular order.
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     tableName: the name of the table to read the items from
+//     keys: a slice of structures containing the key values of the items
+//     response: a pointer to the array the items should be returned in
+//     conf: the concurrency & retry settings
+//     opts: read option(s) such as WithConsistentRead or WithProjection
+//
+//   Example:
+//     err := BatchGetItems(mySession, "fred", myKeys, &myArray, BatchConf{Concurrency: 4})
+func BatchGetItems(sess *session.Session, tableName string, keys interface{}, response interface{}, conf BatchConf, opts ...ReadOption) error {
+	return BatchGetItemsWithContext(context.Background(), sess, tableName, keys, response, conf, opts...)
+}
+
+// BatchGetItemsWithContext - This function reads the items with the given keys
+// from the specified table, MaxBatchGetItems at a time. Missing items are
+// skipped & the items are not returned in any particular order.
+//
+//   Parameters:
+//     ctx: the context used to cancel the requests
+//     sess: a valid AWS session
+//     tableName: the name of the table to read the items from
+//     keys: a slice of structures containing the key values of the items
+//     response: a pointer to the array the items should be returned in
+//     conf: the concurrency & retry settings
+//     opts: read option(s) such as WithConsistentRead or WithProjection
+//
+//   Example:
+//     err := BatchGetItemsWithContext(ctx, mySession, "fred", myKeys, &myArray, BatchConf{})
+func BatchGetItemsWithContext(ctx context.Context, sess *session.Session, tableName string, keys interface{}, response interface{}, conf BatchConf, opts ...ReadOption) error {
+
+	// Sanity check
+	if tableName == "" {
+		return newErrorTableNameNotProvided()
+	}
+	itemKeys, err := marshalItems(keys)
+	if err != nil {
+		return err
+	}
+
+	// Work out the read options
+	var read readConf
+	for _, opt := range opts {
+		opt(&read)
+	}
+	template := dynamodb.KeysAndAttributes{ConsistentRead: aws.Bool(read.consistent)}
+	template.ProjectionExpression, template.ExpressionAttributeNames, err = read.projection()
+	if err != nil {
+		return err
+	}
+
+	// Read the chunks
+	svc := clients.DynamoDB(sess)
+	var mu sync.Mutex
+	var items []map[string]*dynamodb.AttributeValue
+	chunks := chunkItems(itemKeys, MaxBatchGetItems)
+	err = runChunks(ctx, len(chunks), conf.Concurrency, func(ctx context.Context, i int) error {
+
+		// Retry until every key has been processed
+		request := template
+		request.Keys = chunks[i]
+		return retryBatch(ctx, conf, func() (int, error) {
+			result, err := svc.BatchGetItemWithContext(ctx, &dynamodb.BatchGetItemInput{
+				RequestItems: map[string]*dynamodb.KeysAndAttributes{tableName: &request},
+			})
+			if err != nil {
+				return 0, err
+			}
+			mu.Lock()
+			items = append(items, result.Responses[tableName]...)
+			mu.Unlock()
+			unprocessed, ok := result.UnprocessedKeys[tableName]
+			if !ok || unprocessed == nil {
+				return 0, nil
+			}
+			request.Keys = unprocessed.Keys
+			return len(unprocessed.Keys), nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	// Massage the result(s) & return
+	return dynamodbattribute.UnmarshalListOfMaps(items, response)
+}
+
+// batchWrite marshals the items & sends them in chunks, building a
+// write request for each item
+func batchWrite(ctx context.Context, sess *session.Session, tableName string, items interface{}, conf BatchConf, newRequest func(map[string]*dynamodb.AttributeValue) *dynamodb.WriteRequest) error {
+
+	// Sanity check
+	if tableName == "" {
+		return newErrorTableNameNotProvided()
+	}
+	marshalled, err := marshalItems(items)
+	if err != nil {
+		return err
+	}
+
+	// Send the chunks
+	svc := clients.DynamoDB(sess)
+	chunks := chunkItems(marshalled, MaxBatchWriteItems)
+	return runChunks(ctx, len(chunks), conf.Concurrency, func(ctx context.Context, i int) error {
+
+		// Retry until every item has been processed
+		var pending []*dynamodb.WriteRequest
+		for _, item := range chunks[i] {
+			pending = append(pending, newRequest(item))
+		}
+		return retryBatch(ctx, conf, func() (int, error) {
+			result, err := svc.BatchWriteItemWithContext(ctx, &dynamodb.BatchWriteItemInput{
+				RequestItems: map[string][]*dynamodb.WriteRequest{tableName: pending},
+			})
+			if err != nil {
+				return 0, err
+			}
+			pending = result.UnprocessedItems[tableName]
+			return len(pending), nil
+		})
+	})
+}
+
+// marshalItems marshals each element of a slice
+func marshalItems(items interface{}) ([]map[string]*dynamodb.AttributeValue, error) {
+
+	// If we were given a pointer, resolve it's value
+	val := reflect.ValueOf(items)
+	if val.Kind() == reflect.Ptr {
+		val = reflect.Indirect(val)
+	}
+
+	// Double check to make sure we have a slice
+	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+		return nil, newErrorItemsNotSlice()
+	}
+	if val.Len() == 0 {
+		return nil, newErrorItemsNotProvided()
+	}
+
+	// Marshall each item
+	var result []map[string]*dynamodb.AttributeValue
+	for i := 0; i < val.Len(); i++ {
+		item, err := dynamodbattribute.MarshalMap(val.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+// chunkItems splits the items into chunks of at most size items
+func chunkItems(items []map[string]*dynamodb.AttributeValue, size int) [][]map[string]*dynamodb.AttributeValue {
+
+	var chunks [][]map[string]*dynamodb.AttributeValue
+	for start := 0; start < len(items); start += size {
+		end := start + size
+		if end > len(items) {
+			end = len(items)
+		}
+		chunks = append(chunks, items[start:end])
+	}
+	return chunks
+}
+
+// runChunks calls send for each chunk using a pool of at most concurrency
+// workers, stopping at the first failure
+func runChunks(ctx context.Context, count int, concurrency int, send func(ctx context.Context, i int) error) error {
+
+	// Fill in the defaults
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > count {
+		concurrency = count
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Start the workers
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				err := send(ctx, i)
+				if err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+	// Hand out the chunks until done or cancelled
+feed:
+	for i := 0; i < count; i++ {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	// Report the first failure
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// retryBatch calls send until it reports nothing left unprocessed, backing
+// off between attempts
+func retryBatch(ctx context.Context, conf BatchConf, send func() (int, error)) error {
+
+	// Fill in the defaults
+	if conf.MaxRetries <= 0 {
+		conf.MaxRetries = DefaultBatchMaxRetries
+	}
+	if conf.Delay <= 0 {
+		conf.Delay = DefaultBatchDelay
+	}
+	if conf.MaxDelay <= 0 {
+		conf.MaxDelay = DefaultBatchMaxDelay
+	}
+
+	// Send until everything is processed
+	delay := conf.Delay
+	for attempt := 0; ; attempt++ {
+		remaining, err := send()
+		if err != nil {
+			return err
+		}
+		if remaining == 0 {
+			return nil
+		}
+		if attempt >= conf.MaxRetries {
+			return newErrorBatchIncomplete(remaining, attempt)
+		}
+
+		// Back off before trying again
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		delay *= 2
+		if delay > conf.MaxDelay {
+			delay = conf.MaxDelay
+		}
+	}
+}
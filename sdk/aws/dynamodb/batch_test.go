@@ -0,0 +1,234 @@
+package dynamodb_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bradmccoydev/self-service-sdk/internal"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/dynamodb"
+)
+
+// fastBatch keeps the retry tests quick
+var fastBatch = dynamodb.BatchConf{Delay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+
+// newTestItems builds count test items
+func newTestItems(count int) []TestTableFullItem {
+
+	var items []TestTableFullItem
+	for i := 0; i < count; i++ {
+		items = append(items, TestTableFullItem{Name: fmt.Sprintf("item-%d", i), Description: "Blah"})
+	}
+	return items
+}
+
+// writeRequests returns the write requests for the testing table in a BatchWriteItem call
+func writeRequests(input map[string]interface{}) []interface{} {
+	return input["RequestItems"].(map[string]interface{})[TestTableNameValid].([]interface{})
+}
+
+// unprocessedOnce returns a BatchWriteItem handler that leaves the last
+// request of each chunk unprocessed the first time it is seen
+func unprocessedOnce() FakeHandler {
+
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	return func(input map[string]interface{}) (int, interface{}) {
+		requests := writeRequests(input)
+		last := requests[len(requests)-1]
+		key := fmt.Sprint(last)
+		mu.Lock()
+		defer mu.Unlock()
+		if len(requests) > 1 && !seen[key] {
+			seen[key] = true
+			return http.StatusOK, map[string]interface{}{"UnprocessedItems": map[string]interface{}{TestTableNameValid: []interface{}{last}}}
+		}
+		return http.StatusOK, map[string]interface{}{}
+	}
+}
+
+// Test BatchPutItems & BatchDeleteItems validation
+func TestBatchWriteValidation(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+
+	// Setup test data
+	tests := []struct {
+		desc      string
+		tableName string
+		items     interface{}
+	}{
+		{"No table name", "", newTestItems(1)},
+		{"Not a slice", TestTableNameValid, TestTableFullItem{}},
+		{"No items", TestTableNameValid, []TestTableFullItem{}},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Run the test
+			internal.HasError(t, dynamodb.BatchPutItems(fake.Session(), test.tableName, test.items, fastBatch))
+			internal.HasError(t, dynamodb.BatchDeleteItems(fake.Session(), test.tableName, test.items, fastBatch))
+		})
+	}
+	internal.Equals(t, 0, len(fake.Calls("BatchWriteItem")))
+}
+
+// Test BatchPutItems chunks the items & retries unprocessed items
+func TestBatchPutItems(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("BatchWriteItem", unprocessedOnce())
+
+	// Run the test
+	err := dynamodb.BatchPutItems(fake.Session(), TestTableNameValid, newTestItems(60), fastBatch)
+	internal.NoError(t, err)
+
+	// Three chunks, each with a retry of one item
+	calls := fake.Calls("BatchWriteItem")
+	var sizes []int
+	for _, c := range calls {
+		sizes = append(sizes, len(writeRequests(c.Input)))
+	}
+	internal.Equals(t, []int{25, 1, 25, 1, 10, 1}, sizes)
+	first := writeRequests(calls[0].Input)[0].(map[string]interface{})
+	internal.Equals(t, map[string]interface{}{"Item": map[string]interface{}{
+		"name":        map[string]interface{}{"S": "item-0"},
+		"description": map[string]interface{}{"S": "Blah"},
+	}}, first["PutRequest"])
+}
+
+// Test BatchDeleteItems gives up once the retries are used up
+func TestBatchDeleteItems(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("BatchWriteItem", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{"UnprocessedItems": map[string]interface{}{TestTableNameValid: writeRequests(input)}}
+	})
+
+	// Run the test
+	keys := []TestTableKeys{{Name: "a"}, {Name: "b"}}
+	conf := fastBatch
+	conf.MaxRetries = 2
+	err := dynamodb.BatchDeleteItems(fake.Session(), TestTableNameValid, keys, conf)
+	internal.HasError(t, err)
+	internal.Assert(t, errors.Is(err, dynamodb.ErrBatchIncomplete), "expected ErrBatchIncomplete, got %v", err)
+	calls := fake.Calls("BatchWriteItem")
+	internal.Equals(t, 3, len(calls))
+	internal.Equals(t, map[string]interface{}{"Key": map[string]interface{}{"name": map[string]interface{}{"S": "a"}}}, writeRequests(calls[0].Input)[0].(map[string]interface{})["DeleteRequest"])
+
+	// Errors are returned straight away
+	fake.Handle("BatchWriteItem", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusBadRequest, FakeError("ValidationException", "bad item")
+	})
+	err = dynamodb.BatchDeleteItems(fake.Session(), TestTableNameValid, keys, fastBatch)
+	internal.HasError(t, err)
+	internal.Equals(t, 4, len(fake.Calls("BatchWriteItem")))
+}
+
+// Test the chunks are sent by a bounded pool of workers
+func TestBatchConcurrency(t *testing.T) {
+
+	// Setup backend
+	var inFlight, maxInFlight int32
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("BatchWriteItem", func(input map[string]interface{}) (int, interface{}) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		return http.StatusOK, map[string]interface{}{}
+	})
+
+	// Run the test
+	conf := fastBatch
+	conf.Concurrency = 4
+	err := dynamodb.BatchPutItems(fake.Session(), TestTableNameValid, newTestItems(250), conf)
+	internal.NoError(t, err)
+	internal.Equals(t, 10, len(fake.Calls("BatchWriteItem")))
+	max := atomic.LoadInt32(&maxInFlight)
+	internal.Assert(t, max > 1 && max <= 4, "expected between 2 & 4 chunks in flight, got %d", max)
+}
+
+// Test BatchGetItems
+func TestBatchGetItems(t *testing.T) {
+
+	// Setup backend, leaving the first key of each chunk unprocessed the first time
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("BatchGetItem", func(input map[string]interface{}) (int, interface{}) {
+		request := input["RequestItems"].(map[string]interface{})[TestTableNameValid].(map[string]interface{})
+		keys := request["Keys"].([]interface{})
+		first := fmt.Sprint(keys[0])
+		mu.Lock()
+		defer mu.Unlock()
+		if len(keys) > 1 && !seen[first] {
+			seen[first] = true
+			unprocessed := map[string]interface{}{}
+			for k, v := range request {
+				unprocessed[k] = v
+			}
+			unprocessed["Keys"] = keys[:1]
+			return http.StatusOK, map[string]interface{}{
+				"Responses":       map[string]interface{}{TestTableNameValid: keys[1:]},
+				"UnprocessedKeys": map[string]interface{}{TestTableNameValid: unprocessed},
+			}
+		}
+		return http.StatusOK, map[string]interface{}{"Responses": map[string]interface{}{TestTableNameValid: keys}}
+	})
+
+	// Setup test data
+	var keys []TestTableKeys
+	for i := 0; i < 150; i++ {
+		keys = append(keys, TestTableKeys{Name: fmt.Sprintf("item-%d", i)})
+	}
+
+	// Run the test
+	var items []TestTableFullItem
+	conf := fastBatch
+	conf.Concurrency = 2
+	err := dynamodb.BatchGetItems(fake.Session(), TestTableNameValid, keys, &items, conf, dynamodb.WithConsistentRead(), dynamodb.WithProjection("name"))
+	internal.NoError(t, err)
+	internal.Equals(t, 150, len(items))
+	names := make(map[string]bool)
+	for _, i := range items {
+		names[i.Name] = true
+	}
+	internal.Equals(t, 150, len(names))
+
+	// Two chunks, each with a retry of one key
+	calls := fake.Calls("BatchGetItem")
+	internal.Equals(t, 4, len(calls))
+	var sizes int
+	for _, c := range calls {
+		request := c.Input["RequestItems"].(map[string]interface{})[TestTableNameValid].(map[string]interface{})
+		sizes += len(request["Keys"].([]interface{}))
+		internal.Equals(t, true, request["ConsistentRead"])
+		internal.Equals(t, "#0", request["ProjectionExpression"])
+	}
+	internal.Equals(t, 152, sizes)
+
+	// Sanity checks
+	internal.HasError(t, dynamodb.BatchGetItems(fake.Session(), "", keys, &items, conf))
+	internal.HasError(t, dynamodb.BatchGetItems(fake.Session(), TestTableNameValid, nil, &items, conf))
+}
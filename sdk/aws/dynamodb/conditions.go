@@ -0,0 +1,117 @@
+// This file contains all the bits & pieces related to
+// conditional writes, so an item is only created,
+// updated or deleted when it is in the expected state
+
+package dynamodb
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// ConditionFailedError - structure used to represent a write that was
+// rejected because the item didn't meet the condition(s)
+type ConditionFailedError struct {
+	TableName string
+	cause     error
+}
+
+// Error - This function describes the failed write
+func (e *ConditionFailedError) Error() string {
+	return fmt.Sprintf("%s for table %s", ErrConditionFailed.Error(), e.TableName)
+}
+
+// Is - This function lets errors.Is match ErrConditionFailed
+func (e *ConditionFailedError) Is(target error) bool {
+	return target == ErrConditionFailed
+}
+
+// Unwrap - This function returns the underlying Dynamo DB error
+func (e *ConditionFailedError) Unwrap() error {
+	return e.cause
+}
+
+// IfNotExists - This function creates a condition that only allows the
+// write when the item doesn't exist yet
+//
+//   Parameters:
+//     keyField: the name of the partition key attribute
+//
+//   Example:
+//     err := CreateItem(mySession, "fred", myStruct, IfNotExists("name"))
+func IfNotExists(keyField string) Condition {
+	return Condition{Field: keyField, Operator: AttributeNotExists}
+}
+
+// IfExists - This function creates a condition that only allows the
+// write when the item already exists
+//
+//   Parameters:
+//     keyField: the name of the partition key attribute
+//
+//   Example:
+//     err := UpdateItem(mySession, "fred", myKeys, myChanges, IfExists("name"))
+func IfExists(keyField string) Condition {
+	return Condition{Field: keyField, Operator: AttributeExists}
+}
+
+// IfAttributeEquals - This function creates a condition that only allows
+// the write when an attribute of the existing item has the given value
+//
+//   Parameters:
+//     field: the name of the attribute to check
+//     value: the value the attribute must have
+//
+//   Example:
+//     err := DeleteItem(mySession, "fred", myKeys, IfAttributeEquals("status", "retired"))
+func IfAttributeEquals(field string, value string) Condition {
+	return Condition{Field: field, Operator: Equals, Value: value}
+}
+
+// newWriteExpression builds the update & condition expression for a
+// write operation, nil if there is neither. The version, if any, is
+// added to the condition(s).
+func newWriteExpression(update *expression.UpdateBuilder, conditions []Condition, version *itemVersion) (*expression.Expression, error) {
+
+	if update == nil && len(conditions) == 0 && version == nil {
+		return nil, nil
+	}
+	builder := expression.NewBuilder()
+	if update != nil {
+		builder = builder.WithUpdate(*update)
+	}
+	if len(conditions) > 0 || version != nil {
+		var cond expression.ConditionBuilder
+		if len(conditions) > 0 {
+			var err error
+			cond, err = newFilterExpression(conditions)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if version != nil && len(conditions) > 0 {
+			cond = cond.And(version.condition())
+		} else if version != nil {
+			cond = version.condition()
+		}
+		builder = builder.WithCondition(cond)
+	}
+	expr, err := builder.Build()
+	if err != nil {
+		return nil, err
+	}
+	return &expr, nil
+}
+
+// newConditionError maps a failed condition to a ConditionFailedError
+func newConditionError(tableName string, err error) error {
+
+	var failed *dynamodb.ConditionalCheckFailedException
+	if errors.As(err, &failed) {
+		return &ConditionFailedError{TableName: tableName, cause: err}
+	}
+	return err
+}
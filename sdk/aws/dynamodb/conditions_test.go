@@ -0,0 +1,108 @@
+package dynamodb_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/bradmccoydev/self-service-sdk/internal"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/dynamodb"
+)
+
+// conditionFailed answers a write with a failed condition
+func conditionFailed(input map[string]interface{}) (int, interface{}) {
+	return http.StatusBadRequest, FakeError("ConditionalCheckFailedException", "The conditional request failed")
+}
+
+// Test conditional CreateItem, UpdateItem & DeleteItem
+func TestConditionalWrites(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	ok := func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{}
+	}
+	fake.Handle("PutItem", ok)
+	fake.Handle("UpdateItem", ok)
+	fake.Handle("DeleteItem", ok)
+
+	// Setup test data
+	item := TestTableFullItem{Name: "fred", Description: "Blah"}
+	keys := TestTableKeys{Name: "fred"}
+	tests := []struct {
+		desc              string
+		op                string
+		write             func() error
+		expectedCondition interface{}
+		expectedNames     interface{}
+		expectedValues    interface{}
+	}{
+		{"Create without condition", "PutItem", func() error {
+			return dynamodb.CreateItem(fake.Session(), TestTableNameValid, item)
+		}, nil, nil, nil},
+		{"Create if not exists", "PutItem", func() error {
+			return dynamodb.CreateItem(fake.Session(), TestTableNameValid, item, dynamodb.IfNotExists("name"))
+		}, "attribute_not_exists (#0)", map[string]interface{}{"#0": "name"}, nil},
+		{"Update if exists", "UpdateItem", func() error {
+			return dynamodb.UpdateItem(fake.Session(), TestTableNameValid, keys, TestTableFullItem{Description: "New"}, dynamodb.IfExists("name"))
+		}, "attribute_exists (#0)", nil, nil},
+		{"Delete if attribute equals", "DeleteItem", func() error {
+			return dynamodb.DeleteItem(fake.Session(), TestTableNameValid, keys, dynamodb.IfAttributeEquals("description", "retired"))
+		}, "#0 = :0", map[string]interface{}{"#0": "description"}, map[string]interface{}{":0": map[string]interface{}{"S": "retired"}}},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Run the test
+			internal.NoError(t, test.write())
+			calls := fake.Calls(test.op)
+			input := calls[len(calls)-1].Input
+			internal.Equals(t, test.expectedCondition, input["ConditionExpression"])
+			if test.expectedNames != nil {
+				internal.Equals(t, test.expectedNames, input["ExpressionAttributeNames"])
+			}
+			if test.expectedValues != nil {
+				internal.Equals(t, test.expectedValues, input["ExpressionAttributeValues"])
+			}
+		})
+	}
+
+	// An invalid condition is rejected before calling Dynamo DB
+	err := dynamodb.DeleteItem(fake.Session(), TestTableNameValid, keys, dynamodb.Condition{Field: "name"})
+	internal.HasError(t, err)
+	internal.Equals(t, 1, len(fake.Calls("DeleteItem")))
+}
+
+// Test the error returned when a condition fails
+func TestConditionFailed(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("PutItem", conditionFailed)
+	fake.Handle("UpdateItem", conditionFailed)
+	fake.Handle("DeleteItem", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusBadRequest, FakeError("ValidationException", "bad")
+	})
+
+	// Failed conditions can be checked with errors.Is & errors.As
+	keys := TestTableKeys{Name: "fred"}
+	for _, err := range []error{
+		dynamodb.CreateItem(fake.Session(), TestTableNameValid, TestTableFullItem{Name: "fred"}, dynamodb.IfNotExists("name")),
+		dynamodb.UpdateItem(fake.Session(), TestTableNameValid, keys, TestTableFullItem{Description: "New"}, dynamodb.IfExists("name")),
+	} {
+		internal.Assert(t, errors.Is(err, dynamodb.ErrConditionFailed), "expected ErrConditionFailed, got %v", err)
+		var failed *dynamodb.ConditionFailedError
+		internal.Assert(t, errors.As(err, &failed), "expected a ConditionFailedError, got %T", err)
+		internal.Equals(t, TestTableNameValid, failed.TableName)
+	}
+
+	// Other errors are returned as is
+	err := dynamodb.DeleteItem(fake.Session(), TestTableNameValid, keys, dynamodb.IfExists("name"))
+	internal.HasError(t, err)
+	internal.Assert(t, !errors.Is(err, dynamodb.ErrConditionFailed), "unexpected ErrConditionFailed")
+}
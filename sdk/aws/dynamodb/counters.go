@@ -0,0 +1,349 @@
+// This file contains all the bits & pieces related to
+// atomically changing counters & sets without reading
+// the item first
+
+package dynamodb
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/clients"
+)
+
+// IncrementCounter - This function atomically adds delta to a numeric
+// attribute & returns the new value. A missing attribute (or item) starts
+// from 0. Attributes nested in maps can be named with a dotted path, but
+// the maps must already exist as Dynamo DB rejects the update otherwise.
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     tableName: the name of the table containing the item
+//     keys: the structure containing the item keys
+//     attribute: the name (or dotted path) of the numeric attribute
+//     delta: the amount to add
+//     conditions: optional condition(s) the existing item must meet
+//
+//   Example:
+//     count, err := IncrementCounter(mySession, "fred", myKeys, "invocations", 1, IfExists("name"))
+func IncrementCounter(sess *session.Session, tableName string, keys interface{}, attribute string, delta int64, conditions ...Condition) (int64, error) {
+	return IncrementCounterWithContext(context.Background(), sess, tableName, keys, attribute, delta, conditions...)
+}
+
+// IncrementCounterWithContext - This function atomically adds delta to a
+// numeric attribute & returns the new value, in the same way as IncrementCounter
+//
+//   Parameters:
+//     ctx: the context used to cancel the request
+//     sess: a valid AWS session
+//     tableName: the name of the table containing the item
+//     keys: the structure containing the item keys
+//     attribute: the name (or dotted path) of the numeric attribute
+//     delta: the amount to add
+//     conditions: optional condition(s) the existing item must meet
+//
+//   Example:
+//     count, err := IncrementCounterWithContext(ctx, mySession, "fred", myKeys, "stats.invocations", 1)
+func IncrementCounterWithContext(ctx context.Context, sess *session.Session, tableName string, keys interface{}, attribute string, delta int64, conditions ...Condition) (int64, error) {
+
+	var value int64
+	update := expression.Add(expression.Name(attribute), expression.Value(delta))
+	err := updateAttribute(ctx, sess, tableName, keys, attribute, update, &value, conditions)
+	return value, err
+}
+
+// DecrementCounter - This function atomically subtracts delta from a numeric
+// attribute & returns the new value. A missing attribute (or item) starts
+// from 0, while a dotted path needs the maps along it to exist.
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     tableName: the name of the table containing the item
+//     keys: the structure containing the item keys
+//     attribute: the name (or dotted path) of the numeric attribute
+//     delta: the amount to subtract
+//     conditions: optional condition(s) the existing item must meet
+//
+//   Example:
+//     remaining, err := DecrementCounter(mySession, "fred", myKeys, "quota", 1)
+func DecrementCounter(sess *session.Session, tableName string, keys interface{}, attribute string, delta int64, conditions ...Condition) (int64, error) {
+	return IncrementCounterWithContext(context.Background(), sess, tableName, keys, attribute, -delta, conditions...)
+}
+
+// DecrementCounterWithContext - This function atomically subtracts delta
+// from a numeric attribute & returns the new value, in the same way as
+// DecrementCounter
+//
+//   Parameters:
+//     ctx: the context used to cancel the request
+//     sess: a valid AWS session
+//     tableName: the name of the table containing the item
+//     keys: the structure containing the item keys
+//     attribute: the name (or dotted path) of the numeric attribute
+//     delta: the amount to subtract
+//     conditions: optional condition(s) the existing item must meet
+//
+//   Example:
+//     remaining, err := DecrementCounterWithContext(ctx, mySession, "fred", myKeys, "quota", 1)
+func DecrementCounterWithContext(ctx context.Context, sess *session.Session, tableName string, keys interface{}, attribute string, delta int64, conditions ...Condition) (int64, error) {
+	return IncrementCounterWithContext(ctx, sess, tableName, keys, attribute, -delta, conditions...)
+}
+
+// AddToStringSet - This function atomically adds members to a string set
+// attribute & returns the new set
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     tableName: the name of the table containing the item
+//     keys: the structure containing the item keys
+//     attribute: the name (or dotted path) of the string set attribute
+//     members: the strings to add
+//     conditions: optional condition(s) the existing item must meet
+//
+//   Example:
+//     tags, err := AddToStringSet(mySession, "fred", myKeys, "tags", []string{"beta", "internal"})
+func AddToStringSet(sess *session.Session, tableName string, keys interface{}, attribute string, members []string, conditions ...Condition) ([]string, error) {
+	return AddToStringSetWithContext(context.Background(), sess, tableName, keys, attribute, members, conditions...)
+}
+
+// AddToStringSetWithContext - This function atomically adds members to a
+// string set attribute & returns the new set
+//
+//   Parameters:
+//     ctx: the context used to cancel the request
+//     sess: a valid AWS session
+//     tableName: the name of the table containing the item
+//     keys: the structure containing the item keys
+//     attribute: the name (or dotted path) of the string set attribute
+//     members: the strings to add
+//     conditions: optional condition(s) the existing item must meet
+//
+//   Example:
+//     tags, err := AddToStringSetWithContext(ctx, mySession, "fred", myKeys, "tags", []string{"beta"}, IfExists("name"))
+func AddToStringSetWithContext(ctx context.Context, sess *session.Session, tableName string, keys interface{}, attribute string, members []string, conditions ...Condition) ([]string, error) {
+
+	var set []string
+	if len(members) == 0 {
+		return set, newErrorSetMembersNotProvided()
+	}
+	value := expression.Value(rawValue{av: &dynamodb.AttributeValue{SS: aws.StringSlice(members)}})
+	update := expression.Add(expression.Name(attribute), value)
+	err := updateAttribute(ctx, sess, tableName, keys, attribute, update, &set, conditions)
+	return set, err
+}
+
+// RemoveFromStringSet - This function atomically removes members from a
+// string set attribute & returns the new set, empty once the last member
+// has been removed
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     tableName: the name of the table containing the item
+//     keys: the structure containing the item keys
+//     attribute: the name (or dotted path) of the string set attribute
+//     members: the strings to remove
+//     conditions: optional condition(s) the existing item must meet
+//
+//   Example:
+//     tags, err := RemoveFromStringSet(mySession, "fred", myKeys, "tags", []string{"beta"})
+func RemoveFromStringSet(sess *session.Session, tableName string, keys interface{}, attribute string, members []string, conditions ...Condition) ([]string, error) {
+	return RemoveFromStringSetWithContext(context.Background(), sess, tableName, keys, attribute, members, conditions...)
+}
+
+// RemoveFromStringSetWithContext - This function atomically removes members
+// from a string set attribute & returns the new set, empty once the last
+// member has been removed
+//
+//   Parameters:
+//     ctx: the context used to cancel the request
+//     sess: a valid AWS session
+//     tableName: the name of the table containing the item
+//     keys: the structure containing the item keys
+//     attribute: the name (or dotted path) of the string set attribute
+//     members: the strings to remove
+//     conditions: optional condition(s) the existing item must meet
+//
+//   Example:
+//     tags, err := RemoveFromStringSetWithContext(ctx, mySession, "fred", myKeys, "tags", []string{"beta"})
+func RemoveFromStringSetWithContext(ctx context.Context, sess *session.Session, tableName string, keys interface{}, attribute string, members []string, conditions ...Condition) ([]string, error) {
+
+	var set []string
+	if len(members) == 0 {
+		return set, newErrorSetMembersNotProvided()
+	}
+	value := expression.Value(rawValue{av: &dynamodb.AttributeValue{SS: aws.StringSlice(members)}})
+	update := expression.Delete(expression.Name(attribute), value)
+	err := updateAttribute(ctx, sess, tableName, keys, attribute, update, &set, conditions)
+	return set, err
+}
+
+// AddToNumberSet - This function atomically adds members to a number set
+// attribute & returns the new set
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     tableName: the name of the table containing the item
+//     keys: the structure containing the item keys
+//     attribute: the name (or dotted path) of the number set attribute
+//     members: the numbers to add
+//     conditions: optional condition(s) the existing item must meet
+//
+//   Example:
+//     ports, err := AddToNumberSet(mySession, "fred", myKeys, "ports", []int64{80, 443})
+func AddToNumberSet(sess *session.Session, tableName string, keys interface{}, attribute string, members []int64, conditions ...Condition) ([]int64, error) {
+	return AddToNumberSetWithContext(context.Background(), sess, tableName, keys, attribute, members, conditions...)
+}
+
+// AddToNumberSetWithContext - This function atomically adds members to a
+// number set attribute & returns the new set
+//
+//   Parameters:
+//     ctx: the context used to cancel the request
+//     sess: a valid AWS session
+//     tableName: the name of the table containing the item
+//     keys: the structure containing the item keys
+//     attribute: the name (or dotted path) of the number set attribute
+//     members: the numbers to add
+//     conditions: optional condition(s) the existing item must meet
+//
+//   Example:
+//     ports, err := AddToNumberSetWithContext(ctx, mySession, "fred", myKeys, "ports", []int64{80})
+func AddToNumberSetWithContext(ctx context.Context, sess *session.Session, tableName string, keys interface{}, attribute string, members []int64, conditions ...Condition) ([]int64, error) {
+
+	var set []int64
+	if len(members) == 0 {
+		return set, newErrorSetMembersNotProvided()
+	}
+	update := expression.Add(expression.Name(attribute), newNumberSetValue(members))
+	err := updateAttribute(ctx, sess, tableName, keys, attribute, update, &set, conditions)
+	return set, err
+}
+
+// RemoveFromNumberSet - This function atomically removes members from a
+// number set attribute & returns the new set, empty once the last member
+// has been removed
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     tableName: the name of the table containing the item
+//     keys: the structure containing the item keys
+//     attribute: the name (or dotted path) of the number set attribute
+//     members: the numbers to remove
+//     conditions: optional condition(s) the existing item must meet
+//
+//   Example:
+//     ports, err := RemoveFromNumberSet(mySession, "fred", myKeys, "ports", []int64{80})
+func RemoveFromNumberSet(sess *session.Session, tableName string, keys interface{}, attribute string, members []int64, conditions ...Condition) ([]int64, error) {
+	return RemoveFromNumberSetWithContext(context.Background(), sess, tableName, keys, attribute, members, conditions...)
+}
+
+// RemoveFromNumberSetWithContext - This function atomically removes members
+// from a number set attribute & returns the new set, empty once the last
+// member has been removed
+//
+//   Parameters:
+//     ctx: the context used to cancel the request
+//     sess: a valid AWS session
+//     tableName: the name of the table containing the item
+//     keys: the structure containing the item keys
+//     attribute: the name (or dotted path) of the number set attribute
+//     members: the numbers to remove
+//     conditions: optional condition(s) the existing item must meet
+//
+//   Example:
+//     ports, err := RemoveFromNumberSetWithContext(ctx, mySession, "fred", myKeys, "ports", []int64{80})
+func RemoveFromNumberSetWithContext(ctx context.Context, sess *session.Session, tableName string, keys interface{}, attribute string, members []int64, conditions ...Condition) ([]int64, error) {
+
+	var set []int64
+	if len(members) == 0 {
+		return set, newErrorSetMembersNotProvided()
+	}
+	update := expression.Delete(expression.Name(attribute), newNumberSetValue(members))
+	err := updateAttribute(ctx, sess, tableName, keys, attribute, update, &set, conditions)
+	return set, err
+}
+
+// newNumberSetValue builds a number set for an update expression
+func newNumberSetValue(members []int64) expression.ValueBuilder {
+
+	var numbers []*string
+	for _, m := range members {
+		numbers = append(numbers, aws.String(strconv.FormatInt(m, 10)))
+	}
+	return expression.Value(rawValue{av: &dynamodb.AttributeValue{NS: numbers}})
+}
+
+// attributePath splits a dotted attribute name into the names of the
+// nested maps leading to it. List indexes aren't supported.
+func attributePath(attribute string) ([]string, error) {
+
+	if attribute == "" {
+		return nil, newErrorAttributeNameNotProvided()
+	}
+	path := strings.Split(attribute, ".")
+	for _, p := range path {
+		if p == "" || strings.ContainsAny(p, "[]") {
+			return nil, newErrorAttributePathInvalid(attribute)
+		}
+	}
+	return path, nil
+}
+
+// updateAttribute applies an update to a single attribute, returning its
+// new value in output. Output is left alone if the attribute no longer exists.
+func updateAttribute(ctx context.Context, sess *session.Session, tableName string, keys interface{}, attribute string, update expression.UpdateBuilder, output interface{}, conditions []Condition) error {
+
+	// Sanity check
+	if tableName == "" {
+		return newErrorTableNameNotProvided()
+	}
+	path, err := attributePath(attribute)
+	if err != nil {
+		return err
+	}
+
+	// Marshall the keys
+	itemKeys, err := dynamodbattribute.MarshalMap(&keys)
+	if err != nil {
+		return err
+	}
+
+	// Create an update expression
+	expr, err := newWriteExpression(&update, conditions, nil)
+	if err != nil {
+		return err
+	}
+
+	// Make the call to DynamoDB
+	svc := clients.DynamoDB(sess)
+	result, err := svc.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		Key:                       itemKeys,
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+		ReturnValues:              aws.String(dynamodb.ReturnValueUpdatedNew),
+		TableName:                 aws.String(tableName),
+	})
+	if err != nil {
+		return newConditionError(tableName, err)
+	}
+
+	// Follow the path to the new value
+	value := &dynamodb.AttributeValue{M: result.Attributes}
+	for _, p := range path {
+		value = value.M[p]
+		if value == nil {
+			return nil
+		}
+	}
+
+	// Massage the result(s) & return
+	return dynamodbattribute.Unmarshal(value, output)
+}
@@ -0,0 +1,152 @@
+package dynamodb_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bradmccoydev/self-service-sdk/internal"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/dynamodb"
+)
+
+// Test IncrementCounter & DecrementCounter
+func TestCounters(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("UpdateItem", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{"Attributes": map[string]interface{}{"invocations": map[string]interface{}{"N": "42"}}}
+	})
+
+	// Increment the counter
+	keys := TestTableKeys{Name: "fred"}
+	count, err := dynamodb.IncrementCounter(fake.Session(), TestTableNameValid, keys, "invocations", 5)
+	internal.NoError(t, err)
+	internal.Equals(t, int64(42), count)
+	input := fake.Calls("UpdateItem")[0].Input
+	internal.Equals(t, "ADD #0 :0\n", input["UpdateExpression"])
+	internal.Equals(t, map[string]interface{}{"#0": "invocations"}, input["ExpressionAttributeNames"])
+	internal.Equals(t, map[string]interface{}{":0": map[string]interface{}{"N": "5"}}, input["ExpressionAttributeValues"])
+	internal.Equals(t, "UPDATED_NEW", input["ReturnValues"])
+
+	// Decrement it, only if the item exists
+	_, err = dynamodb.DecrementCounter(fake.Session(), TestTableNameValid, keys, "invocations", 2, dynamodb.IfExists("name"))
+	internal.NoError(t, err)
+	input = fake.Calls("UpdateItem")[1].Input
+	internal.Equals(t, map[string]interface{}{":0": map[string]interface{}{"N": "-2"}}, input["ExpressionAttributeValues"])
+	internal.Equals(t, "attribute_exists (#0)", input["ConditionExpression"])
+
+	// Failed conditions & missing parameters
+	fake.Handle("UpdateItem", conditionFailed)
+	_, err = dynamodb.IncrementCounter(fake.Session(), TestTableNameValid, keys, "invocations", 1, dynamodb.IfExists("name"))
+	internal.Assert(t, errors.Is(err, dynamodb.ErrConditionFailed), "expected ErrConditionFailed, got %v", err)
+	_, err = dynamodb.IncrementCounter(fake.Session(), "", keys, "invocations", 1)
+	internal.HasError(t, err)
+	_, err = dynamodb.IncrementCounter(fake.Session(), TestTableNameValid, keys, "", 1)
+	internal.HasError(t, err)
+	_, err = dynamodb.IncrementCounter(fake.Session(), TestTableNameValid, keys, "stats..count", 1)
+	internal.HasError(t, err)
+	_, err = dynamodb.IncrementCounter(fake.Session(), TestTableNameValid, keys, "counts[0]", 1)
+	internal.HasError(t, err)
+	internal.Equals(t, 3, len(fake.Calls("UpdateItem")))
+}
+
+// Test counters nested in maps
+func TestNestedCounter(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("UpdateItem", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{"Attributes": map[string]interface{}{
+			"stats": map[string]interface{}{"M": map[string]interface{}{"invocations": map[string]interface{}{"N": "7"}}},
+		}}
+	})
+
+	// The new value is found along the path
+	keys := TestTableKeys{Name: "fred"}
+	count, err := dynamodb.IncrementCounterWithContext(context.Background(), fake.Session(), TestTableNameValid, keys, "stats.invocations", 1)
+	internal.NoError(t, err)
+	internal.Equals(t, int64(7), count)
+	input := fake.Calls("UpdateItem")[0].Input
+	internal.Equals(t, "ADD #0.#1 :0\n", input["UpdateExpression"])
+	internal.Equals(t, map[string]interface{}{"#0": "stats", "#1": "invocations"}, input["ExpressionAttributeNames"])
+
+	// A path that isn't returned leaves the value alone
+	count, err = dynamodb.DecrementCounterWithContext(context.Background(), fake.Session(), TestTableNameValid, keys, "stats.missing.count", 1)
+	internal.NoError(t, err)
+	internal.Equals(t, int64(0), count)
+}
+
+// Test a counter nested in a map that doesn't exist is rejected
+func TestNestedCounterMissingMap(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("UpdateItem", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusBadRequest, FakeError("ValidationException", "The document path provided in the update expression is invalid for update")
+	})
+
+	// The error is returned as is
+	keys := TestTableKeys{Name: "fred"}
+	count, err := dynamodb.IncrementCounter(fake.Session(), TestTableNameValid, keys, "stats.invocations", 1)
+	internal.HasError(t, err)
+	internal.Assert(t, strings.Contains(err.Error(), "ValidationException"), "expected a ValidationException, got %v", err)
+	internal.Assert(t, !errors.Is(err, dynamodb.ErrConditionFailed), "unexpected ErrConditionFailed")
+	internal.Equals(t, int64(0), count)
+	internal.Equals(t, 1, len(fake.Calls("UpdateItem")))
+}
+
+// Test adding & removing set members
+func TestSets(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("UpdateItem", func(input map[string]interface{}) (int, interface{}) {
+		switch input["ExpressionAttributeNames"].(map[string]interface{})["#0"] {
+		case "tags":
+			return http.StatusOK, map[string]interface{}{"Attributes": map[string]interface{}{"tags": map[string]interface{}{"SS": []interface{}{"alpha", "beta"}}}}
+		case "ports":
+			return http.StatusOK, map[string]interface{}{"Attributes": map[string]interface{}{"ports": map[string]interface{}{"NS": []interface{}{"80", "443"}}}}
+		}
+		return http.StatusOK, map[string]interface{}{}
+	})
+	keys := TestTableKeys{Name: "fred"}
+
+	// String sets
+	tags, err := dynamodb.AddToStringSet(fake.Session(), TestTableNameValid, keys, "tags", []string{"beta"})
+	internal.NoError(t, err)
+	internal.Equals(t, []string{"alpha", "beta"}, tags)
+	input := fake.Calls("UpdateItem")[0].Input
+	internal.Equals(t, "ADD #0 :0\n", input["UpdateExpression"])
+	internal.Equals(t, map[string]interface{}{":0": map[string]interface{}{"SS": []interface{}{"beta"}}}, input["ExpressionAttributeValues"])
+	_, err = dynamodb.RemoveFromStringSet(fake.Session(), TestTableNameValid, keys, "tags", []string{"gamma"}, dynamodb.IfExists("name"))
+	internal.NoError(t, err)
+	input = fake.Calls("UpdateItem")[1].Input
+	internal.Equals(t, "DELETE #1 :0\n", input["UpdateExpression"])
+	internal.Equals(t, "attribute_exists (#0)", input["ConditionExpression"])
+
+	// Number sets
+	ports, err := dynamodb.AddToNumberSetWithContext(context.Background(), fake.Session(), TestTableNameValid, keys, "ports", []int64{80, 443})
+	internal.NoError(t, err)
+	internal.Equals(t, []int64{80, 443}, ports)
+	input = fake.Calls("UpdateItem")[2].Input
+	internal.Equals(t, map[string]interface{}{":0": map[string]interface{}{"NS": []interface{}{"80", "443"}}}, input["ExpressionAttributeValues"])
+
+	// Removing the last member leaves an empty set
+	empty, err := dynamodb.RemoveFromNumberSet(fake.Session(), TestTableNameValid, keys, "empty", []int64{80})
+	internal.NoError(t, err)
+	internal.Equals(t, 0, len(empty))
+
+	// Members must be given
+	_, err = dynamodb.AddToStringSet(fake.Session(), TestTableNameValid, keys, "tags", nil)
+	internal.HasError(t, err)
+	_, err = dynamodb.RemoveFromNumberSet(fake.Session(), TestTableNameValid, keys, "ports", []int64{})
+	internal.HasError(t, err)
+	internal.Equals(t, 4, len(fake.Calls("UpdateItem")))
+}
@@ -5,13 +5,16 @@
 package dynamodb
 
 import (
+	"context"
 	"reflect"
+	"sort"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/clients"
 )
 
 // CreateItem - This function adds a new item to the specified table
@@ -20,10 +23,15 @@ import (
 //     sess: a valid AWS session
 //     tableName: the name of the table to add the item to
 //     input: the structure containing the new item properties
+//     conditions: optional condition(s) an existing item must meet to be replaced
+//
+//   A field tagged `dynamo:"version"` is incremented & the write only succeeds
+//   if the stored version is unchanged (or missing for a version of 0). When
+//   input is a pointer its version field is updated after a successful write.
 //
 //   Example:
-//     err := CreateItem(mySession, "fred", myStruct)
-func CreateItem(sess *session.Session, tableName string, input interface{}) error {
+//     err := CreateItem(mySession, "fred", myStruct, IfNotExists("name"))
+func CreateItem(sess *session.Session, tableName string, input interface{}, conditions ...Condition) error {
 
 	// Sanity check
 	if tableName == "" {
@@ -38,20 +46,49 @@ func CreateItem(sess *session.Session, tableName string, input interface{}) erro
 		return err
 	}
 
+	// Bump the version, if there is one
+	version, err := findVersion(input)
+	if err != nil {
+		return err
+	}
+	if version != nil {
+		err = version.apply(item)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Build the condition
+	expr, err := newWriteExpression(nil, conditions, version)
+	if err != nil {
+		return err
+	}
+
 	// Build the input params
 	params := &dynamodb.PutItemInput{
 		Item:      item,
 		TableName: aws.String(tableName),
 	}
+	if expr != nil {
+		params.ConditionExpression = expr.Condition()
+		params.ExpressionAttributeNames = expr.Names()
+		params.ExpressionAttributeValues = expr.Values()
+	}
 
 	// Create the DynamoDB client
-	svc := dynamodb.New(sess)
+	svc := clients.DynamoDB(sess)
 
 	// Make the call to DynamoDB
 	_, err = svc.PutItem(params)
+	if err != nil {
+		return newVersionError(context.Background(), svc, tableName, item, version, conditions, err)
+	}
 
 	// Return
-	return err
+	if version != nil {
+		version.commit()
+	}
+	return nil
 }
 
 // DeleteItem - This function deletes an item from the specified table
@@ -60,10 +97,11 @@ func CreateItem(sess *session.Session, tableName string, input interface{}) erro
 //     sess: a valid AWS session
 //     tableName: the name of the table to delete the item from
 //     input: the structure containing the key values for the item to be deleted
+//     conditions: optional condition(s) the item must meet to be deleted
 //
 //   Example:
-//     err := DeleteItem(mySession, "fred", myStruct)
-func DeleteItem(sess *session.Session, tableName string, input interface{}) error {
+//     err := DeleteItem(mySession, "fred", myStruct, IfAttributeEquals("status", "retired"))
+func DeleteItem(sess *session.Session, tableName string, input interface{}, conditions ...Condition) error {
 
 	// Sanity check
 	if tableName == "" {
@@ -78,23 +116,168 @@ func DeleteItem(sess *session.Session, tableName string, input interface{}) erro
 		return err
 	}
 
+	// Build the condition
+	expr, err := newWriteExpression(nil, conditions, nil)
+	if err != nil {
+		return err
+	}
+
 	// Build the delete params
 	params := &dynamodb.DeleteItemInput{
 		Key:       item,
 		TableName: aws.String(tableName),
 	}
+	if expr != nil {
+		params.ConditionExpression = expr.Condition()
+		params.ExpressionAttributeNames = expr.Names()
+		params.ExpressionAttributeValues = expr.Values()
+	}
 
 	// Create the DynamoDB client
-	svc := dynamodb.New(sess)
+	svc := clients.DynamoDB(sess)
 
 	// Make the call to DynamoDB
 	_, err = svc.DeleteItem(params)
 
 	// Return
-	return err
+	return newConditionError(tableName, err)
+}
+
+// ReadOption - a function that modifies how items are read
+type ReadOption func(conf *readConf)
+
+// readConf - the settings built up by the read options
+type readConf struct {
+	consistent bool
+	fields     []Field
+}
+
+// WithConsistentRead - This function requests a strongly consistent read
+//
+//   Example:
+//     err := GetItem(mySession, "fred", keys, &item, WithConsistentRead())
+func WithConsistentRead() ReadOption {
+	return func(conf *readConf) {
+		conf.consistent = true
+	}
+}
+
+// WithProjection - This function restricts the attributes returned to the named fields
+//
+//   Parameters:
+//     fields: the names of the attributes to return
+//
+//   Example:
+//     err := GetItem(mySession, "fred", keys, &item, WithProjection("name", "owner"))
+func WithProjection(fields ...string) ReadOption {
+	return func(conf *readConf) {
+		for _, f := range fields {
+			conf.fields = append(conf.fields, Field{Name: f})
+		}
+	}
+}
+
+// projection builds the projection expression & names for the read options
+func (conf readConf) projection() (*string, map[string]*string, error) {
+
+	if conf.fields == nil {
+		return nil, nil, nil
+	}
+	proj, err := newProjectionExpression(conf.fields)
+	if err != nil {
+		return nil, nil, err
+	}
+	expr, err := expression.NewBuilder().WithProjection(proj).Build()
+	if err != nil {
+		return nil, nil, err
+	}
+	return expr.Projection(), expr.Names(), nil
+}
+
+// GetItem - This function reads a single item from the specified table by its
+// keys. If there is no such item the error wraps ErrItemNotFound.
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     tableName: the name of the table to read the item from
+//     keys: the structure containing the key values for the item
+//     output: a pointer to the structure the item should be returned in
+//     opts: read option(s) such as WithConsistentRead or WithProjection
+//
+//   Example:
+//     err := GetItem(mySession, "fred", myKeys, &myStruct, WithConsistentRead())
+//     if errors.Is(err, ErrItemNotFound) { ... }
+func GetItem(sess *session.Session, tableName string, keys interface{}, output interface{}, opts ...ReadOption) error {
+	return GetItemWithContext(context.Background(), sess, tableName, keys, output, opts...)
+}
+
+// GetItemWithContext - This function reads a single item from the specified
+// table by its keys. If there is no such item the error wraps ErrItemNotFound.
+//
+//   Parameters:
+//     ctx: the context used to cancel the request
+//     sess: a valid AWS session
+//     tableName: the name of the table to read the item from
+//     keys: the structure containing the key values for the item
+//     output: a pointer to the structure the item should be returned in
+//     opts: read option(s) such as WithConsistentRead or WithProjection
+//
+//   Example:
+//     err := GetItemWithContext(ctx, mySession, "fred", myKeys, &myStruct)
+func GetItemWithContext(ctx context.Context, sess *session.Session, tableName string, keys interface{}, output interface{}, opts ...ReadOption) error {
+
+	// Sanity check
+	if tableName == "" {
+		return newErrorTableNameNotProvided()
+	}
+	val := reflect.ValueOf(output)
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		return newErrorOutputNotPointer()
+	}
+
+	// Marshall the keys
+	itemKeys, err := dynamodbattribute.MarshalMap(keys)
+	if err != nil {
+		return err
+	}
+	if len(itemKeys) == 0 {
+		return newErrorItemKeysNotProvided()
+	}
+
+	// Build the get params
+	var conf readConf
+	for _, opt := range opts {
+		opt(&conf)
+	}
+	params := &dynamodb.GetItemInput{
+		Key:            itemKeys,
+		TableName:      aws.String(tableName),
+		ConsistentRead: aws.Bool(conf.consistent),
+	}
+	params.ProjectionExpression, params.ExpressionAttributeNames, err = conf.projection()
+	if err != nil {
+		return err
+	}
+
+	// Create the DynamoDB client
+	svc := clients.DynamoDB(sess)
+
+	// Make the call to DynamoDB
+	result, err := svc.GetItemWithContext(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	// An empty item means it wasn't found
+	if len(result.Item) == 0 {
+		return newErrorItemNotFound(tableName)
+	}
+	return dynamodbattribute.UnmarshalMap(result.Item, output)
 }
 
-// QueryItems - This function makes a query call of the specified table to find matching item(s)
+// QueryItems - This function makes a query call of the specified table to find matching item(s).
+// Only the first page (up to 1 MB) of results is returned, use QueryAllItems or
+// NewQueryIterator to read the rest.
 //
 //   Parameters:
 //     sess: a valid AWS session
@@ -125,7 +308,7 @@ func QueryItems(sess *session.Session, tableName string, expr expression.Express
 	}
 
 	// Create the DynamoDB client
-	svc := dynamodb.New(sess)
+	svc := clients.DynamoDB(sess)
 
 	// Make the call to DynamoDB
 	result, err := svc.Query(params)
@@ -140,7 +323,9 @@ func QueryItems(sess *session.Session, tableName string, expr expression.Express
 	return err
 }
 
-// ScanItems - This function makes a scan call of the specified table to find matching item(s)
+// ScanItems - This function makes a scan call of the specified table to find matching item(s).
+// Only the first page (up to 1 MB) of results is returned, use ScanAllItems or
+// NewScanIterator to read the rest.
 //
 //   Parameters:
 //     sess: a valid AWS session
@@ -167,7 +352,7 @@ func ScanItems(sess *session.Session, tableName string, expr expression.Expressi
 	}
 
 	// Create the DynamoDB client
-	svc := dynamodb.New(sess)
+	svc := clients.DynamoDB(sess)
 
 	// Make the call to DynamoDB
 	result, err := svc.Scan(params)
@@ -182,17 +367,59 @@ func ScanItems(sess *session.Session, tableName string, expr expression.Expressi
 	return err
 }
 
-// UpdateItem - This function updates an item in the specified table
+// UpdateItem - This function updates an item in the specified table. Only
+// the attributes in input are changed, other attributes are left alone.
 //
 //   Parameters:
 //     sess: a valid AWS session
 //     tableName: the name of the table to update
 //     keys: the structure containing the item keys
 //     input: the structure containing the item properties to update
+//     conditions: optional condition(s) the existing item must meet
+//
+//   Fields are named using their dynamodbav or json tags & key attributes are
+//   never updated. Empty fields tagged omitempty are left alone, while other
+//   nil pointers remove the attribute. Fields tagged `dynamo:"add"` are added
+//   to a number or set (slices of strings, numbers or bytes are sent as sets,
+//   other types are rejected) & fields tagged `dynamo:"append"` are appended to a list. The fields of
+//   embedded structures are included.
+//
+//   A field tagged `dynamo:"version"` is incremented & the update only succeeds
+//   if the stored version is unchanged (or missing for a version of 0). When
+//   input is a pointer its version field is updated after a successful update.
+//
+//   Example:
+//     err := UpdateItem(mySession, "fred", myKeys, myStruct, IfExists("name"))
+func UpdateItem(sess *session.Session, tableName string, keys interface{}, input interface{}, conditions ...Condition) error {
+	return updateItem(context.Background(), sess, tableName, keys, input, nil, conditions)
+}
+
+// UpdateItemWithResult - This function updates an item in the specified table,
+// in the same way as UpdateItem, & returns the updated item
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     tableName: the name of the table to update
+//     keys: the structure containing the item keys
+//     input: the structure containing the item properties to update
+//     output: a pointer to the structure the updated item should be returned in
+//     conditions: optional condition(s) the existing item must meet
 //
 //   Example:
-//     err := UpdateItem(mySession, "fred", myStruct)
-func UpdateItem(sess *session.Session, tableName string, keys interface{}, input interface{}) error {
+//     var updated myStruct
+//     err := UpdateItemWithResult(mySession, "fred", myKeys, myChanges, &updated)
+func UpdateItemWithResult(sess *session.Session, tableName string, keys interface{}, input interface{}, output interface{}, conditions ...Condition) error {
+
+	// Sanity check
+	val := reflect.ValueOf(output)
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		return newErrorOutputNotPointer()
+	}
+	return updateItem(context.Background(), sess, tableName, keys, input, output, conditions)
+}
+
+// updateItem updates an item, returning the updated item in output if it isn't nil
+func updateItem(ctx context.Context, sess *session.Session, tableName string, keys interface{}, input interface{}, output interface{}, conditions []Condition) error {
 
 	// Sanity check
 	if tableName == "" {
@@ -205,6 +432,84 @@ func UpdateItem(sess *session.Session, tableName string, keys interface{}, input
 		return err
 	}
 
+	// Find the version, if there is one
+	version, err := findVersion(input)
+	if err != nil {
+		return err
+	}
+
+	// Build the update definition
+	update, err := newUpdateBuilder(input, itemKeys, version)
+	if err != nil {
+		return err
+	}
+
+	// Create an update expression
+	expr, err := newWriteExpression(&update, conditions, version)
+	if err != nil {
+		return err
+	}
+
+	// Build the update params
+	returnValues := dynamodb.ReturnValueNone
+	if output != nil {
+		returnValues = dynamodb.ReturnValueAllNew
+	}
+	params := &dynamodb.UpdateItemInput{
+		Key:                       itemKeys,
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+		ReturnValues:              aws.String(returnValues),
+		TableName:                 aws.String(tableName),
+	}
+
+	// Create the DynamoDB client
+	svc := clients.DynamoDB(sess)
+
+	// Make the call to DynamoDB
+	result, err := svc.UpdateItemWithContext(ctx, params)
+	if err != nil {
+		return newVersionError(ctx, svc, tableName, itemKeys, version, conditions, err)
+	}
+	if version != nil {
+		version.commit()
+	}
+
+	// Massage the result(s) & return
+	if output == nil {
+		return nil
+	}
+	return dynamodbattribute.UnmarshalMap(result.Attributes, output)
+}
+
+const (
+	// UpdateTagAdd - the value of the dynamo struct tag that adds a field to a number or set
+	UpdateTagAdd string = "add"
+
+	// UpdateTagAppend - the value of the dynamo struct tag that appends a field to a list
+	UpdateTagAppend string = "append"
+
+	// updateRemove - how nil pointers are updated
+	updateRemove string = "remove"
+)
+
+// rawValue passes an already marshalled value to the expression builder
+type rawValue struct {
+	av *dynamodb.AttributeValue
+}
+
+// MarshalDynamoDBAttributeValue - This function copies the marshalled value
+func (r rawValue) MarshalDynamoDBAttributeValue(av *dynamodb.AttributeValue) error {
+	*av = *r.av
+	return nil
+}
+
+// newUpdateBuilder builds an update from the fields of the input structure,
+// leaving out the keys. The version, if any, is set to its next value.
+func newUpdateBuilder(input interface{}, keys map[string]*dynamodb.AttributeValue, version *itemVersion) (expression.UpdateBuilder, error) {
+
 	// Process the input interface
 	var update expression.UpdateBuilder
 	val := reflect.ValueOf(input)
@@ -216,51 +521,116 @@ func UpdateItem(sess *session.Session, tableName string, keys interface{}, input
 
 	// Double check to make sure we have a struct
 	if val.Kind() != reflect.Struct {
-		return newErrorTableUnexpectedDataTypeProvided()
+		return update, newErrorTableUnexpectedDataTypeProvided()
 	}
 
-	// Iterate the structure
-	typeDef := val.Type()
-	for i := 0; i < typeDef.NumField(); i++ {
+	// Marshall the input, so the tag options are honoured
+	item, err := dynamodbattribute.MarshalMap(val.Interface())
+	if err != nil {
+		return update, err
+	}
+
+	// Work out how each field should be updated
+	modes := make(map[string]string)
+	for _, f := range storedFields(val) {
 
-		// Grab the attribute name
-		attrib := typeDef.Field(i)
-		attribName := attrib.Tag.Get("json")
-		if attribName == "" {
-			attribName = attrib.Name
+		if f.name == "" {
+			continue
+		}
+		mode := f.field.Tag.Get(TagName)
+		if mode == "" && !f.omitEmpty && f.value.Kind() == reflect.Ptr && f.value.IsNil() {
+			mode = updateRemove
 		}
+		modes[f.name] = mode
+	}
 
-		// Grab the attribute value
-		valueField := val.Field(i)
-		attribValue := valueField.Interface()
+	// Build the update definition, in a stable order
+	var names []string
+	for name := range item {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	count := 0
+	for _, name := range names {
 
-		// Build the update definition
-		update = update.Set(expression.Name(attribName), expression.Value(attribValue))
+		// Leave out the keys & version
+		if _, ok := keys[name]; ok {
+			continue
+		}
+		if version != nil && name == version.name {
+			continue
+		}
+
+		// Add the attribute
+		attrib := expression.Name(name)
+		value := expression.Value(rawValue{av: item[name]})
+		empty := aws.BoolValue(item[name].NULL)
+		switch modes[name] {
+		case updateRemove:
+			update = update.Remove(attrib)
+		case UpdateTagAdd:
+			set, err := newAddValue(name, item[name])
+			if err != nil {
+				return update, err
+			}
+			if set == nil {
+				continue
+			}
+			update = update.Add(attrib, expression.Value(rawValue{av: set}))
+		case UpdateTagAppend:
+			if empty {
+				continue
+			}
+			emptyList := expression.Value(rawValue{av: &dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{}}})
+			update = update.Set(attrib, expression.ListAppend(expression.IfNotExists(attrib, emptyList), value))
+		default:
+			update = update.Set(attrib, value)
+		}
+		count++
 	}
 
-	// Create an update expression
-	builder := expression.NewBuilder().WithUpdate(update)
-	expression, err := builder.Build()
-	if err != nil {
-		return err
+	// Bump the version
+	if version != nil {
+		update = update.Set(expression.Name(version.name), expression.Value(version.next()))
+		count++
 	}
 
-	// Build the update params
-	params := &dynamodb.UpdateItemInput{
-		Key:                       itemKeys,
-		ExpressionAttributeNames:  expression.Names(),
-		ExpressionAttributeValues: expression.Values(),
-		UpdateExpression:          expression.Update(),
-		ReturnValues:              aws.String("UPDATED_NEW"),
-		TableName:                 aws.String(tableName),
+	// Return it
+	if count == 0 {
+		return update, newErrorUpdateNotProvided()
 	}
+	return update, nil
+}
 
-	// Create the DynamoDB client
-	svc := dynamodb.New(sess)
+// newAddValue converts a list to the matching set, as ADD only works with
+// numbers & sets, rejecting anything else. Nil is returned when there is
+// nothing to add.
+func newAddValue(name string, av *dynamodb.AttributeValue) (*dynamodb.AttributeValue, error) {
 
-	// Make the call to DynamoDB
-	_, err = svc.UpdateItem(params)
+	// Anything to add?
+	if aws.BoolValue(av.NULL) || (av.L != nil && len(av.L) == 0) {
+		return nil, nil
+	}
+	if av.N != nil || av.SS != nil || av.NS != nil || av.BS != nil {
+		return av, nil
+	}
+	if av.L == nil {
+		return nil, newErrorUpdateAddInvalid(name)
+	}
 
-	// Return
-	return err
+	// Every member must be the same scalar type
+	set := &dynamodb.AttributeValue{}
+	for _, m := range av.L {
+		switch {
+		case m.S != nil && set.NS == nil && set.BS == nil:
+			set.SS = append(set.SS, m.S)
+		case m.N != nil && set.SS == nil && set.BS == nil:
+			set.NS = append(set.NS, m.N)
+		case m.B != nil && set.SS == nil && set.NS == nil:
+			set.BS = append(set.BS, m.B)
+		default:
+			return nil, newErrorUpdateAddInvalid(name)
+		}
+	}
+	return set, nil
 }
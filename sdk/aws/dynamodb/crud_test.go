@@ -1,7 +1,9 @@
 package dynamodb_test
 
 import (
+	"errors"
 	"log"
+	"net/http"
 	"testing"
 	"time"
 
@@ -34,6 +36,9 @@ func CreateTestTableItem() (string, error) {
 // Test CreateItem
 func TestCreateItem(t *testing.T) {
 
+	// Only run against a real AWS account
+	internal.SkipAwsTests(t)
+
 	// Setup backend
 	createerr := CreateTableIfNotExists(TestTableConf)
 	if createerr != nil {
@@ -89,6 +94,9 @@ func TestCreateItem(t *testing.T) {
 // Test DeleteItem
 func TestDeleteItem(t *testing.T) {
 
+	// Only run against a real AWS account
+	internal.SkipAwsTests(t)
+
 	// Setup backend
 	itemKey, createerr := CreateTestTableItem()
 	if createerr != nil {
@@ -141,6 +149,9 @@ func TestDeleteItem(t *testing.T) {
 // Test QueryItems
 func TestQueryItems(t *testing.T) {
 
+	// Only run against a real AWS account
+	internal.SkipAwsTests(t)
+
 	// Setup backend
 	itemKey, createerr := CreateTestTableItem()
 	if createerr != nil {
@@ -206,6 +217,9 @@ func TestQueryItems(t *testing.T) {
 // Test ScanItems
 func TestScanItems(t *testing.T) {
 
+	// Only run against a real AWS account
+	internal.SkipAwsTests(t)
+
 	// Setup backend
 	itemKey, createerr := CreateTestTableItem()
 	if createerr != nil {
@@ -265,6 +279,9 @@ func TestScanItems(t *testing.T) {
 // Test UpdateItem
 func TestUpdateItem(t *testing.T) {
 
+	// Only run against a real AWS account
+	internal.SkipAwsTests(t)
+
 	// Setup backend
 	itemKey, createerr := CreateTestTableItem()
 	if createerr != nil {
@@ -320,3 +337,235 @@ func TestUpdateItem(t *testing.T) {
 		})
 	}
 }
+
+// Test GetItem
+func TestGetItem(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("GetItem", func(input map[string]interface{}) (int, interface{}) {
+		key := input["Key"].(map[string]interface{})["name"].(map[string]interface{})["S"]
+		if key != "found" {
+			return http.StatusOK, map[string]interface{}{}
+		}
+		return http.StatusOK, map[string]interface{}{"Item": map[string]interface{}{
+			"name":        map[string]interface{}{"S": "found"},
+			"description": map[string]interface{}{"S": "Blah blah blah"},
+		}}
+	})
+
+	// Setup test data
+	tests := []struct {
+		desc        string
+		tableName   string
+		keys        interface{}
+		output      interface{}
+		opts        []dynamodb.ReadOption
+		expectErr   bool
+		expectedErr error
+	}{
+		{"No table name", "", TestTableKeys{Name: "found"}, &TestTableFullItem{}, nil, true, nil},
+		{"Output not a pointer", TestTableNameValid, TestTableKeys{Name: "found"}, TestTableFullItem{}, nil, true, nil},
+		{"No keys", TestTableNameValid, struct{}{}, &TestTableFullItem{}, nil, true, nil},
+		{"Empty projection field", TestTableNameValid, TestTableKeys{Name: "found"}, &TestTableFullItem{}, []dynamodb.ReadOption{dynamodb.WithProjection("")}, true, nil},
+		{"Not found", TestTableNameValid, TestTableKeys{Name: "missing"}, &TestTableFullItem{}, nil, true, dynamodb.ErrItemNotFound},
+		{"Found", TestTableNameValid, TestTableKeys{Name: "found"}, &TestTableFullItem{}, nil, false, nil},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Run the test
+			err := dynamodb.GetItem(fake.Session(), test.tableName, test.keys, test.output, test.opts...)
+			if test.expectErr {
+				internal.HasError(t, err)
+				if test.expectedErr != nil {
+					internal.Assert(t, errors.Is(err, test.expectedErr), "expected %v, got %v", test.expectedErr, err)
+				}
+			} else {
+				internal.NoError(t, err)
+				internal.Equals(t, &TestTableFullItem{Name: "found", Description: "Blah blah blah"}, test.output)
+			}
+		})
+	}
+
+	// Check the read options are passed on
+	var item TestTableFullItem
+	err := dynamodb.GetItem(fake.Session(), TestTableNameValid, TestTableKeys{Name: "found"}, &item, dynamodb.WithConsistentRead(), dynamodb.WithProjection("name", "description"))
+	internal.NoError(t, err)
+	calls := fake.Calls("GetItem")
+	input := calls[len(calls)-1].Input
+	internal.Equals(t, true, input["ConsistentRead"])
+	internal.Equals(t, "#0, #1", input["ProjectionExpression"])
+	internal.Equals(t, map[string]interface{}{"#0": "name", "#1": "description"}, input["ExpressionAttributeNames"])
+}
+
+// partialUpdate is a test input using the update tags
+type partialUpdate struct {
+	Name    string   `json:"name"`
+	Title   string   `dynamodbav:"title,omitempty"`
+	Owner   *string  `json:"owner"`
+	Notes   *string  `json:"notes,omitempty"`
+	Count   int      `json:"count" dynamo:"add"`
+	Tags    []string `dynamodbav:"tags,stringset,omitempty" dynamo:"add"`
+	History []string `json:"history" dynamo:"append"`
+}
+
+// Test the update expression built by UpdateItem
+func TestUpdateItemExpression(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("UpdateItem", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{}
+	})
+
+	// Run the update
+	keys := TestTableKeys{Name: "fred"}
+	input := partialUpdate{Name: "fred", Count: 2, Tags: []string{"beta"}, History: []string{"renamed"}}
+	err := dynamodb.UpdateItem(fake.Session(), TestTableNameValid, keys, input)
+	internal.NoError(t, err)
+
+	// Check what was sent
+	sent := fake.Calls("UpdateItem")[0].Input
+	internal.Equals(t, "ADD #0 :0, #1 :1\nREMOVE #2\nSET #3 = list_append(if_not_exists(#3, :2), :3)\n", sent["UpdateExpression"])
+	internal.Equals(t, map[string]interface{}{"#0": "count", "#1": "tags", "#2": "owner", "#3": "history"}, sent["ExpressionAttributeNames"])
+	internal.Equals(t, map[string]interface{}{
+		":0": map[string]interface{}{"N": "2"},
+		":1": map[string]interface{}{"SS": []interface{}{"beta"}},
+		":2": map[string]interface{}{"L": []interface{}{}},
+		":3": map[string]interface{}{"L": []interface{}{map[string]interface{}{"S": "renamed"}}},
+	}, sent["ExpressionAttributeValues"])
+	internal.Equals(t, "NONE", sent["ReturnValues"])
+
+	// Only updating the keys is rejected
+	err = dynamodb.UpdateItem(fake.Session(), TestTableNameValid, keys, TestTableKeys{Name: "fred"})
+	internal.HasError(t, err)
+	internal.Equals(t, 1, len(fake.Calls("UpdateItem")))
+}
+
+// auditFields is embedded in test inputs
+type auditFields struct {
+	Editor  *string  `json:"editor"`
+	Reviews int      `json:"reviews" dynamo:"add"`
+	Labels  []string `json:"labels" dynamo:"add"`
+}
+
+// Test UpdateItem handles embedded structures & sends added slices as sets
+func TestUpdateItemEmbedded(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("UpdateItem", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{}
+	})
+
+	// Run the update
+	type embeddedUpdate struct {
+		auditFields
+		Name    string `json:"name"`
+		Ports   []int  `json:"ports" dynamo:"add"`
+		Version int64  `json:"version" dynamo:"version"`
+	}
+	input := &embeddedUpdate{Name: "fred", Ports: []int{80, 443}, Version: 1, auditFields: auditFields{Reviews: 1, Labels: []string{"beta"}}}
+	err := dynamodb.UpdateItem(fake.Session(), TestTableNameValid, TestTableKeys{Name: "fred"}, input)
+	internal.NoError(t, err)
+	internal.Equals(t, int64(2), input.Version)
+
+	// Check what was sent
+	sent := fake.Calls("UpdateItem")[0].Input
+	internal.Equals(t, "ADD #1 :1, #2 :2, #3 :3\nREMOVE #4\nSET #0 = :4\n", sent["UpdateExpression"])
+	internal.Equals(t, map[string]interface{}{"#0": "version", "#1": "labels", "#2": "ports", "#3": "reviews", "#4": "editor"}, sent["ExpressionAttributeNames"])
+	values := sent["ExpressionAttributeValues"].(map[string]interface{})
+	internal.Equals(t, map[string]interface{}{"SS": []interface{}{"beta"}}, values[":1"])
+	internal.Equals(t, map[string]interface{}{"NS": []interface{}{"80", "443"}}, values[":2"])
+	internal.Equals(t, map[string]interface{}{"N": "1"}, values[":3"])
+	internal.Equals(t, map[string]interface{}{"N": "2"}, values[":4"])
+
+	// Empty slices are left alone & lists of other types are rejected
+	type mixedUpdate struct {
+		Empty []string      `json:"empty" dynamo:"add"`
+		Mixed []interface{} `json:"mixed" dynamo:"add"`
+	}
+	err = dynamodb.UpdateItem(fake.Session(), TestTableNameValid, TestTableKeys{Name: "fred"}, mixedUpdate{Mixed: []interface{}{"a", 1}})
+	internal.HasError(t, err)
+	err = dynamodb.UpdateItem(fake.Session(), TestTableNameValid, TestTableKeys{Name: "fred"}, mixedUpdate{Empty: []string{}})
+	internal.HasError(t, err)
+	internal.Equals(t, 1, len(fake.Calls("UpdateItem")))
+}
+
+// Test UpdateItem rejects added values other than numbers & sets
+func TestUpdateItemAddInvalid(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("UpdateItem", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{}
+	})
+
+	// Setup test data
+	type stringAdd struct {
+		Title string `json:"title" dynamo:"add"`
+	}
+	type boolAdd struct {
+		Active bool `json:"active" dynamo:"add"`
+	}
+	type mapAdd struct {
+		Stats map[string]int `json:"stats" dynamo:"add"`
+	}
+	type structAdd struct {
+		Owner TestTableKeys `json:"owner" dynamo:"add"`
+	}
+	tests := []struct {
+		desc  string
+		input interface{}
+	}{
+		{"String", stringAdd{Title: "fred"}},
+		{"Boolean", boolAdd{Active: true}},
+		{"Map", mapAdd{Stats: map[string]int{"invocations": 1}}},
+		{"Structure", structAdd{Owner: TestTableKeys{Name: "fred"}}},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+			err := dynamodb.UpdateItem(fake.Session(), TestTableNameValid, TestTableKeys{Name: "fred"}, test.input)
+			internal.HasError(t, err)
+		})
+	}
+	internal.Equals(t, 0, len(fake.Calls("UpdateItem")))
+}
+
+// Test UpdateItemWithResult
+func TestUpdateItemWithResult(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("UpdateItem", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{"Attributes": map[string]interface{}{
+			"name":        map[string]interface{}{"S": "fred"},
+			"description": map[string]interface{}{"S": "Updated"},
+		}}
+	})
+
+	// The updated item is returned
+	var updated TestTableFullItem
+	keys := TestTableKeys{Name: "fred"}
+	err := dynamodb.UpdateItemWithResult(fake.Session(), TestTableNameValid, keys, TestTableUpdateItem{Description: "Updated"}, &updated)
+	internal.NoError(t, err)
+	internal.Equals(t, TestTableFullItem{Name: "fred", Description: "Updated"}, updated)
+	internal.Equals(t, "ALL_NEW", fake.Calls("UpdateItem")[0].Input["ReturnValues"])
+
+	// The output must be a pointer
+	err = dynamodb.UpdateItemWithResult(fake.Session(), TestTableNameValid, keys, TestTableUpdateItem{Description: "Updated"}, updated)
+	internal.HasError(t, err)
+	internal.Equals(t, 1, len(fake.Calls("UpdateItem")))
+}
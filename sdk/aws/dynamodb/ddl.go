@@ -4,12 +4,13 @@
 package dynamodb
 
 import (
+	"context"
 	"strings"
-	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/clients"
 )
 
 const (
@@ -33,15 +34,18 @@ type TableAttributes struct {
 	KeyType string
 }
 
-// TableConf - structure used to represent table config metadata
+// TableConf - structure used to represent table config metadata, including
+// the optional time to live, stream, encryption & tags settings
 type TableConf struct {
 	TableName          string
 	BillingMode        string
 	ReadCapacityUnits  int64
 	WriteCapacityUnits int64
+	TableOptions
 }
 
-// CreateTable - This function creates a new table in Dynamo DB
+// CreateTable - This function creates a new table in Dynamo DB & waits
+// (using the default WaitConf) until it is ACTIVE
 //
 //   Parameters:
 //     sess: a valid AWS session
@@ -51,6 +55,23 @@ type TableConf struct {
 //   Example:
 //     err := CreateTable(mySession, tableConf, tableAttribs)
 func CreateTable(sess *session.Session, conf TableConf, attribs []TableAttributes) error {
+	return CreateTableWithContext(context.Background(), sess, conf, attribs, WaitConf{})
+}
+
+// CreateTableWithContext - This function creates a new table in Dynamo DB
+// & waits until it is ACTIVE. Time to live can only be enabled once the
+// table is ACTIVE so it is applied last.
+//
+//   Parameters:
+//     ctx: the context used to cancel the request & wait
+//     sess: a valid AWS session
+//     conf: the configuration metadata for the table
+//     attribs: an array of the attributes the table should have
+//     wait: the timeout & backoff to use while waiting
+//
+//   Example:
+//     err := CreateTableWithContext(ctx, mySession, tableConf, tableAttribs, WaitConf{Timeout: time.Minute})
+func CreateTableWithContext(ctx context.Context, sess *session.Session, conf TableConf, attribs []TableAttributes, wait WaitConf) error {
 
 	// Sanity check
 	if conf.TableName == "" {
@@ -62,6 +83,10 @@ func CreateTable(sess *session.Session, conf TableConf, attribs []TableAttribute
 	if len(attribs) == 0 {
 		return newErrorTableAttributesNotProvided()
 	}
+	err := validateTableOptions(conf.TableOptions)
+	if err != nil {
+		return err
+	}
 
 	// Setup Dyanmo objects that we need
 	var keys []*dynamodb.KeySchemaElement
@@ -120,22 +145,34 @@ func CreateTable(sess *session.Session, conf TableConf, attribs []TableAttribute
 		params = params.SetProvisionedThroughput(&thruput)
 	}
 
+	// Add the stream, encryption & tags
+	addTableOptions(params, conf.TableOptions)
+
 	// Create the DynamoDB client
-	svc := dynamodb.New(sess)
+	svc := clients.DynamoDB(sess)
 
 	// Make the call to DynamoDB
-	_, err := svc.CreateTable(params)
+	_, err = svc.CreateTableWithContext(ctx, params)
+	if err != nil {
+		return err
+	}
 
-	// If ok, sleep for 5 seconds to allow time for processing
-	if err == nil {
-		time.Sleep(5 * time.Second)
+	// Wait for the table to be ready
+	err = WaitUntilTableActive(ctx, sess, conf.TableName, wait)
+	if err != nil {
+		return err
 	}
 
-	// Return
-	return err
+	// Enable time to live
+	if conf.TTLAttribute != "" {
+		return enableTimeToLive(ctx, svc, conf.TableName, conf.TTLAttribute)
+	}
+	return nil
 }
 
 // DeleteTable - This function deletes the specified table from Dynamo DB
+// & waits (using the default WaitConf) until it is gone. Use SafeDeleteTable
+// to guard against deleting the wrong table.
 //
 //   Parameters:
 //     sess: a valid AWS session
@@ -144,6 +181,21 @@ func CreateTable(sess *session.Session, conf TableConf, attribs []TableAttribute
 //   Example:
 //     err := DeleteTable(mySession, "fred")
 func DeleteTable(sess *session.Session, tableName string) error {
+	return DeleteTableWithContext(context.Background(), sess, tableName, WaitConf{})
+}
+
+// DeleteTableWithContext - This function deletes the specified table from
+// Dynamo DB & waits until it is gone
+//
+//   Parameters:
+//     ctx: the context used to cancel the request & wait
+//     sess: a valid AWS session
+//     tableName: the name of the table to delete
+//     wait: the timeout & backoff to use while waiting
+//
+//   Example:
+//     err := DeleteTableWithContext(ctx, mySession, "fred", WaitConf{})
+func DeleteTableWithContext(ctx context.Context, sess *session.Session, tableName string, wait WaitConf) error {
 
 	// Sanity check
 	if tableName == "" {
@@ -156,16 +208,14 @@ func DeleteTable(sess *session.Session, tableName string) error {
 	}
 
 	// Create the DynamoDB client
-	svc := dynamodb.New(sess)
+	svc := clients.DynamoDB(sess)
 
 	// Make the call to DynamoDB
-	_, err := svc.DeleteTable(params)
-
-	// If ok, sleep for 5 seconds to allow time for processing
-	if err == nil {
-		time.Sleep(5 * time.Second)
+	_, err := svc.DeleteTableWithContext(ctx, params)
+	if err != nil {
+		return err
 	}
 
-	// Return
-	return err
+	// Wait for the table to go
+	return WaitUntilTableDeleted(ctx, sess, tableName, wait)
 }
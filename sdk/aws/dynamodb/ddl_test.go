@@ -12,6 +12,9 @@ import (
 // Test CreateTable
 func TestCreateTable(t *testing.T) {
 
+	// Only run against a real AWS account
+	internal.SkipAwsTests(t)
+
 	// Setup backend
 	delerr := DeleteTableIfExists(TestTableNameValid)
 	if delerr != nil {
@@ -73,6 +76,9 @@ func TestCreateTable(t *testing.T) {
 // Test DeleteTable
 func TestDeleteTable(t *testing.T) {
 
+	// Only run against a real AWS account
+	internal.SkipAwsTests(t)
+
 	// Setup test data
 	tests := []struct {
 		desc      string
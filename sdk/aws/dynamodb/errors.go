@@ -8,6 +8,29 @@ import (
 	"fmt"
 )
 
+var (
+	// ErrItemNotFound - no item has the given keys
+	ErrItemNotFound = errors.New("The item was not found")
+
+	// ErrConditionFailed - a write was rejected by its condition, see ConditionFailedError
+	ErrConditionFailed = errors.New("The condition was not met")
+
+	// ErrVersionConflict - a write was rejected because the item version changed, see VersionConflictError
+	ErrVersionConflict = errors.New("The item version has changed")
+
+	// ErrBatchIncomplete - some batch items were still unprocessed after retrying
+	ErrBatchIncomplete = errors.New("The batch was not completed")
+
+	// ErrTransactionCancelled - a transaction was cancelled, see TransactionCancelledError
+	ErrTransactionCancelled = errors.New("The transaction was cancelled")
+
+	// ErrTableProtected - the table carries the deletion protection tag
+	ErrTableProtected = errors.New("The table is protected from deletion")
+
+	// ErrDeleteNotConfirmed - the table ARN was not given to confirm the delete
+	ErrDeleteNotConfirmed = errors.New("The table delete was not confirmed")
+)
+
 /***
 Expression errors
 ***/
@@ -48,6 +71,94 @@ func newErrorProjExpressionFieldsNotProvided() error {
 	return fmt.Errorf("At least one field must be provided for a projection expression")
 }
 
+/***
+Item errors
+***/
+
+func newErrorItemNotFound(name string) error {
+	return fmt.Errorf("%w in table %s", ErrItemNotFound, name)
+}
+
+func newErrorUpdateNotProvided() error {
+	return errors.New("At least one attribute other than the keys must be updated")
+}
+
+func newErrorUpdateAddInvalid(name string) error {
+	return fmt.Errorf("The attribute %s can only be added if it is a number, a set or a list of strings, numbers or binary values", name)
+}
+
+func newErrorAttributeNameNotProvided() error {
+	return errors.New("An attribute name must be provided")
+}
+
+func newErrorSetMembersNotProvided() error {
+	return errors.New("At least one set member must be provided")
+}
+
+func newErrorAttributePathInvalid(attribute string) error {
+	return fmt.Errorf("The attribute %s must be a name or a dotted path of map keys", attribute)
+}
+
+func newErrorItemKeysNotProvided() error {
+	return errors.New("Item keys must be provided")
+}
+
+func newErrorLimitInvalid(limit int64) error {
+	return fmt.Errorf("The limit %d is not valid, it cannot be negative", limit)
+}
+
+func newErrorPageTokenInvalid(err error) error {
+	return fmt.Errorf("The continuation token is not valid: %v", err)
+}
+
+func newErrorItemsNotSlice() error {
+	return errors.New("Expected a slice to be provided for parameter items")
+}
+
+func newErrorItemsNotProvided() error {
+	return errors.New("At least one item must be provided")
+}
+
+func newErrorBatchIncomplete(remaining int, retries int) error {
+	return fmt.Errorf("%w: %d item(s) were unprocessed after %d retries", ErrBatchIncomplete, remaining, retries)
+}
+
+func newErrorOutputNotPointer() error {
+	return errors.New("Expected a non-nil pointer to be provided for parameter output")
+}
+
+/***
+Version errors
+***/
+
+func newErrorVersionFieldInvalid(name string) error {
+	return fmt.Errorf("The version field %s must be a stored integer", name)
+}
+
+func newErrorVersionFieldDuplicated(name string) error {
+	return fmt.Errorf("The version field %s is not the only field tagged as the version", name)
+}
+
+/***
+Transaction errors
+***/
+
+func newErrorConditionsNotProvided() error {
+	return errors.New("At least one condition must be provided")
+}
+
+func newErrorTransactionEmpty() error {
+	return errors.New("The transaction does not have any operations")
+}
+
+func newErrorTransactionTooLarge(count int, max int) error {
+	return fmt.Errorf("The transaction has %d operations, the most allowed is %d", count, max)
+}
+
+func newErrorTransactionItemsNotFound(indexes []int) error {
+	return fmt.Errorf("%w for get operation(s) %v", ErrItemNotFound, indexes)
+}
+
 /***
 Table errors
 ***/
@@ -56,6 +167,18 @@ func newErrorBillingModeNotProvided() error {
 	return errors.New("Billing mode must be provided")
 }
 
+func newErrorBillingModeInvalid(mode string) error {
+	return fmt.Errorf("The billing mode %s is not valid", mode)
+}
+
+func newErrorCapacityNotAllowed(name string) error {
+	return fmt.Errorf("Capacity units can only be set for %s when billing mode is provisioned", name)
+}
+
+func newErrorCapacityUnitsNotProvided(name string) error {
+	return fmt.Errorf("Read & write capacity units must be provided for %s when billing mode is provisioned", name)
+}
+
 func newErrorTableAttributeNameNotProvided() error {
 	return errors.New("Table attribute must have a name")
 }
@@ -64,6 +187,18 @@ func newErrorTableAttributeTypeNotProvided() error {
 	return errors.New("Table attribute must have a type")
 }
 
+func newErrorTableAttributeDuplicated(name string) error {
+	return fmt.Errorf("The attribute %s is defined more than once", name)
+}
+
+func newErrorTableAttributeTypeConflict(name string, current string) error {
+	return fmt.Errorf("The attribute %s is already defined with type %s", name, current)
+}
+
+func newErrorTableAttributeNotUsed(name string) error {
+	return fmt.Errorf("The attribute %s is not used as a key by the table or any index", name)
+}
+
 func newErrorTableAttributesNotProvided() error {
 	return errors.New("Table attributes must be provided")
 }
@@ -76,6 +211,10 @@ func newErrorTableKeyFieldKeyTypeNotProvided(keyName string) error {
 	return fmt.Errorf("The key field %s did not include a key type", keyName)
 }
 
+func newErrorKeyAttributeNotDefined(owner string, keyName string) error {
+	return fmt.Errorf("The key %s used by %s does not have an attribute definition", keyName, owner)
+}
+
 func newErrorTableDetailsNotProvided() error {
 	return errors.New("Table details were not returned")
 }
@@ -84,6 +223,118 @@ func newErrorTableNameNotProvided() error {
 	return errors.New("Table name must be provided")
 }
 
+func newErrorTableNotActive(name string, status string) error {
+	return fmt.Errorf("The table %s cannot be changed while it is %s", name, status)
+}
+
+func newErrorStreamViewTypeInvalid(viewType string) error {
+	return fmt.Errorf("The stream view type %s is not valid", viewType)
+}
+
+func newErrorStreamViewTypeChange(name string, current string) error {
+	return fmt.Errorf("The stream on table %s already uses view type %s, disable it before changing the view type", name, current)
+}
+
 func newErrorTableUnexpectedDataTypeProvided() error {
 	return errors.New("Expected a structure to be provided for parameter input")
 }
+
+/***
+Index errors
+***/
+
+func newErrorIndexNameNotProvided() error {
+	return errors.New("Index name must be provided")
+}
+
+func newErrorIndexNotFound(table string, name string) error {
+	return fmt.Errorf("The table %s does not have a global index called %s", table, name)
+}
+
+func newErrorIndexNameDuplicated(name string) error {
+	return fmt.Errorf("The index name %s is used more than once", name)
+}
+
+func newErrorIndexKeysNotProvided(name string) error {
+	return fmt.Errorf("The index %s must have a partition key", name)
+}
+
+func newErrorIndexProjectionInvalid(name string) error {
+	return fmt.Errorf("The index %s must list non-key attributes if, & only if, the projection type is INCLUDE", name)
+}
+
+func newErrorIndexProjectionTypeInvalid(name string, projType string) error {
+	return fmt.Errorf("The projection type %s of index %s is not valid", projType, name)
+}
+
+func newErrorLocalIndexKeysInvalid(name string) error {
+	return fmt.Errorf("The local index %s must use the table partition key & a sort key, & the table must have a sort key", name)
+}
+
+func newErrorLocalIndexCapacityProvided(name string) error {
+	return fmt.Errorf("The local index %s cannot have its own capacity units", name)
+}
+
+func newErrorTooManyIndexes(kind string, max int) error {
+	return fmt.Errorf("A table can have at most %d %s secondary indexes", max, kind)
+}
+
+/***
+Delete errors
+***/
+
+func newErrorDeleteNotConfirmed(name string) error {
+	return fmt.Errorf("%w: the ARN of table %s must be provided", ErrDeleteNotConfirmed, name)
+}
+
+func newErrorDeleteConfirmationMismatch(name string, arn string) error {
+	return fmt.Errorf("%w: %s is not the ARN of table %s", ErrDeleteNotConfirmed, arn, name)
+}
+
+func newErrorTableProtected(name string, tag string) error {
+	return fmt.Errorf("%w: table %s has the %s tag", ErrTableProtected, name, tag)
+}
+
+/***
+Backup errors
+***/
+
+func newErrorBackupNameNotProvided() error {
+	return errors.New("Backup name must be provided")
+}
+
+func newErrorBackupArnNotProvided() error {
+	return errors.New("Backup ARN must be provided")
+}
+
+func newErrorBackupDetailsNotProvided() error {
+	return errors.New("Backup details were not returned")
+}
+
+func newErrorBackupDeleted(arn string) error {
+	return fmt.Errorf("The backup %s was deleted", arn)
+}
+
+func newErrorRestoreTargetInvalid(name string) error {
+	return fmt.Errorf("Cannot restore into %s, the target must be a new table", name)
+}
+
+/***
+Schema errors
+***/
+
+func newErrorSchemaFormatNotSupported(format string) error {
+	return fmt.Errorf("The schema format %s is not supported, use JSON or YAML", format)
+}
+
+func newErrorSchemaChangeNotSupported(name string, what string) error {
+	return fmt.Errorf("The %s of table %s cannot be changed in-place, the table must be replaced", what, name)
+}
+
+func newErrorTagKeyNotProvided() error {
+	return errors.New("Tag keys must be provided")
+}
+
+func newErrorPlanActionInvalid(action string) error {
+	return fmt.Errorf("The plan action %s is not valid", action)
+}
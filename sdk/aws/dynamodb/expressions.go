@@ -11,6 +11,12 @@ import (
 )
 
 const (
+	// AttributeExists operator (the value is ignored)
+	AttributeExists string = "EX"
+
+	// AttributeNotExists operator (the value is ignored)
+	AttributeNotExists string = "NX"
+
 	// Between operator
 	Between string = "BT"
 
@@ -47,7 +53,7 @@ type Field struct {
 	Name string
 }
 
-// Condition - structure used for key condition & filter expressions
+// Condition - structure used for key condition, filter & write condition expressions
 type Condition struct {
 	Field    string
 	Operator string
@@ -135,6 +141,10 @@ func newFilterExpression(filters []Condition) (expression.ConditionBuilder, erro
 		// Build the condition
 		var tmpcond expression.ConditionBuilder
 		switch strings.ToUpper(i.Operator) {
+		case AttributeExists:
+			tmpcond = expression.AttributeExists(expression.Name(i.Field))
+		case AttributeNotExists:
+			tmpcond = expression.AttributeNotExists(expression.Name(i.Field))
 		case BeginsWith:
 			tmpcond = expression.BeginsWith(expression.Name(i.Field), i.Value)
 		case Contains:
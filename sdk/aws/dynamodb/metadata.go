@@ -4,9 +4,13 @@
 package dynamodb
 
 import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/clients"
 )
 
 // DescribeTable - This function retrieves all the metadata (ARN, item count, keys etc.) about a table
@@ -29,7 +33,7 @@ func DescribeTable(sess *session.Session, tableName string) (*dynamodb.DescribeT
 	params = params.SetTableName(tableName)
 
 	// Create the DynamoDB client
-	svc := dynamodb.New(sess)
+	svc := clients.DynamoDB(sess)
 
 	// Make the call to DynamoDB
 	result, err := svc.DescribeTable(params)
@@ -99,6 +103,151 @@ func GetTableItemCount(sess *session.Session, tableName string) (*int64, error)
 	return count, nil
 }
 
+// GetTableStream - This function retrieves the stream settings of the table
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     tableName: the name of the table to get the stream settings for
+//
+//   Example:
+//     stream, err := GetTableStream(mySession, "fred")
+func GetTableStream(sess *session.Session, tableName string) (*StreamSpec, error) {
+
+	// Get the table details
+	result, err := DescribeTable(sess, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check we retrieved something
+	if result.Table == nil {
+		return nil, newErrorTableDetailsNotProvided()
+	}
+
+	// Extract the stream settings
+	stream := &StreamSpec{}
+	if spec := result.Table.StreamSpecification; spec != nil && aws.BoolValue(spec.StreamEnabled) {
+		stream.Enabled = true
+		stream.ViewType = aws.StringValue(spec.StreamViewType)
+	}
+
+	// Return it
+	return stream, nil
+}
+
+// GetTableStreamArn - This function retrieves the Amazon Resource Name (ARN)
+// of the latest stream for the table, nil if it has never had a stream
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     tableName: the name of the table to get the stream ARN for
+//
+//   Example:
+//     val, err := GetTableStreamArn(mySession, "fred")
+func GetTableStreamArn(sess *session.Session, tableName string) (*string, error) {
+
+	// Get the table details
+	result, err := DescribeTable(sess, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check we retrieved something
+	if result.Table == nil {
+		return nil, newErrorTableDetailsNotProvided()
+	}
+
+	// Return it
+	return result.Table.LatestStreamArn, nil
+}
+
+// GetTableEncryption - This function retrieves the KMS encryption settings of
+// the table, nil if the table uses the default AWS owned key
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     tableName: the name of the table to get the encryption settings for
+//
+//   Example:
+//     enc, err := GetTableEncryption(mySession, "fred")
+func GetTableEncryption(sess *session.Session, tableName string) (*EncryptionSpec, error) {
+
+	// Get the table details
+	result, err := DescribeTable(sess, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check we retrieved something
+	if result.Table == nil {
+		return nil, newErrorTableDetailsNotProvided()
+	}
+
+	// Extract the KMS key
+	sse := result.Table.SSEDescription
+	if sse == nil || aws.StringValue(sse.SSEType) != dynamodb.SSETypeKms {
+		return nil, nil
+	}
+	switch aws.StringValue(sse.Status) {
+	case dynamodb.SSEStatusEnabled, dynamodb.SSEStatusEnabling, dynamodb.SSEStatusUpdating:
+		return &EncryptionSpec{KMSKeyID: aws.StringValue(sse.KMSMasterKeyArn)}, nil
+	}
+	return nil, nil
+}
+
+// GetTableTimeToLive - This function retrieves the time to live attribute of
+// the table, empty if time to live is disabled
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     tableName: the name of the table to get the time to live attribute for
+//
+//   Example:
+//     attr, err := GetTableTimeToLive(mySession, "fred")
+func GetTableTimeToLive(sess *session.Session, tableName string) (string, error) {
+
+	// Sanity check
+	if tableName == "" {
+		return "", newErrorTableNameNotProvided()
+	}
+
+	// Make the call to DynamoDB
+	svc := clients.DynamoDB(sess)
+	result, err := svc.DescribeTimeToLive(&dynamodb.DescribeTimeToLiveInput{TableName: aws.String(tableName)})
+	if err != nil {
+		return "", err
+	}
+
+	// Only report the attribute if time to live is on
+	ttl := result.TimeToLiveDescription
+	if ttl == nil {
+		return "", nil
+	}
+	switch aws.StringValue(ttl.TimeToLiveStatus) {
+	case dynamodb.TimeToLiveStatusEnabled, dynamodb.TimeToLiveStatusEnabling:
+		return aws.StringValue(ttl.AttributeName), nil
+	}
+	return "", nil
+}
+
+// GetTableTags - This function retrieves the tags of the table
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     tableName: the name of the table to get the tags for
+//
+//   Example:
+//     tags, err := GetTableTags(mySession, "fred")
+func GetTableTags(sess *session.Session, tableName string) (map[string]string, error) {
+
+	// Tags are read using the table ARN
+	arn, err := GetTableArn(sess, tableName)
+	if err != nil {
+		return nil, err
+	}
+	return listTags(context.Background(), clients.DynamoDB(sess), aws.StringValue(arn))
+}
+
 // GetTableList - This function retrieves a list of available tables
 //
 //   Parameters:
@@ -109,7 +258,7 @@ func GetTableItemCount(sess *session.Session, tableName string) (*int64, error)
 func GetTableList(sess *session.Session) ([]string, error) {
 
 	// Create the DynamoDB client
-	svc := dynamodb.New(sess)
+	svc := clients.DynamoDB(sess)
 
 	// Make the call to DynamoDB
 	params := &dynamodb.ListTablesInput{}
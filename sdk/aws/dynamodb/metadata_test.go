@@ -2,6 +2,7 @@ package dynamodb_test
 
 import (
 	"log"
+	"net/http"
 	"testing"
 
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -12,6 +13,9 @@ import (
 // Test DescribeTable
 func TestDescribeTable(t *testing.T) {
 
+	// Only run against a real AWS account
+	internal.SkipAwsTests(t)
+
 	// Setup backend
 	createerr := CreateTableIfNotExists(TestTableConf)
 	if createerr != nil {
@@ -56,6 +60,9 @@ func TestDescribeTable(t *testing.T) {
 // Test GetTableArn
 func TestGetTableArn(t *testing.T) {
 
+	// Only run against a real AWS account
+	internal.SkipAwsTests(t)
+
 	// Setup backend
 	createerr := CreateTableIfNotExists(TestTableConf)
 	if createerr != nil {
@@ -92,6 +99,9 @@ func TestGetTableArn(t *testing.T) {
 // Test GetTableItemCount
 func TestGetTableItemCount(t *testing.T) {
 
+	// Only run against a real AWS account
+	internal.SkipAwsTests(t)
+
 	// Setup backend
 	createerr := CreateTableIfNotExists(TestTableConf)
 	if createerr != nil {
@@ -128,6 +138,9 @@ func TestGetTableItemCount(t *testing.T) {
 // Test GetTableList
 func TestGetTableList(t *testing.T) {
 
+	// Only run against a real AWS account
+	internal.SkipAwsTests(t)
+
 	// Setup test data
 	tests := []struct {
 		desc      string
@@ -163,6 +176,9 @@ func TestGetTableList(t *testing.T) {
 // Test TableExists
 func TestTableExists(t *testing.T) {
 
+	// Only run against a real AWS account
+	internal.SkipAwsTests(t)
+
 	// Setup backend
 	createerr := CreateTableIfNotExists(TestTableConf)
 	if createerr != nil {
@@ -205,3 +221,49 @@ func TestTableExists(t *testing.T) {
 		})
 	}
 }
+
+// Test the table option getters
+func TestGetTableOptions(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("DescribeTable", func(input map[string]interface{}) (int, interface{}) {
+		body := FakeTable(TestTableNameValid, "ACTIVE")
+		table := body["Table"].(map[string]interface{})
+		table["StreamSpecification"] = map[string]interface{}{"StreamEnabled": true, "StreamViewType": "NEW_IMAGE"}
+		table["LatestStreamArn"] = "arn:aws:dynamodb:us-east-1:123456789012:table/testing/stream/1"
+		table["SSEDescription"] = map[string]interface{}{"Status": "ENABLED", "SSEType": "KMS", "KMSMasterKeyArn": "arn:aws:kms:us-east-1:123456789012:key/abc"}
+		return http.StatusOK, body
+	})
+	fake.Handle("DescribeTimeToLive", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{"TimeToLiveDescription": map[string]interface{}{"AttributeName": "expires", "TimeToLiveStatus": "ENABLING"}}
+	})
+	fake.Handle("ListTagsOfResource", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{"Tags": []interface{}{map[string]interface{}{"Key": "cost-centre", "Value": "1234"}}}
+	})
+	sess := fake.Session()
+
+	// Run the tests
+	stream, err := dynamodb.GetTableStream(sess, TestTableNameValid)
+	internal.NoError(t, err)
+	internal.Equals(t, &dynamodb.StreamSpec{Enabled: true, ViewType: "NEW_IMAGE"}, stream)
+	arn, err := dynamodb.GetTableStreamArn(sess, TestTableNameValid)
+	internal.NoError(t, err)
+	internal.Equals(t, "arn:aws:dynamodb:us-east-1:123456789012:table/testing/stream/1", *arn)
+	enc, err := dynamodb.GetTableEncryption(sess, TestTableNameValid)
+	internal.NoError(t, err)
+	internal.Equals(t, &dynamodb.EncryptionSpec{KMSKeyID: "arn:aws:kms:us-east-1:123456789012:key/abc"}, enc)
+	ttl, err := dynamodb.GetTableTimeToLive(sess, TestTableNameValid)
+	internal.NoError(t, err)
+	internal.Equals(t, "expires", ttl)
+	tags, err := dynamodb.GetTableTags(sess, TestTableNameValid)
+	internal.NoError(t, err)
+	internal.Equals(t, map[string]string{"cost-centre": "1234"}, tags)
+
+	// A table name is needed
+	_, err = dynamodb.GetTableTimeToLive(sess, "")
+	internal.HasError(t, err)
+	_, err = dynamodb.GetTableTags(sess, "")
+	internal.HasError(t, err)
+}
@@ -0,0 +1,302 @@
+// This file contains all the bits & pieces related to
+// reading query & scan results across multiple pages
+
+package dynamodb
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/clients"
+)
+
+// PageConf - structure used to represent how to page through results.
+// PageSize is the maximum number of items DynamoDB evaluates per page (0
+// lets DynamoDB decide) & Token is the continuation token returned by a
+// previous iterator, empty to start from the beginning.
+type PageConf struct {
+	PageSize int64
+	Token    string
+}
+
+// pageToken - the contents of a continuation token, tied to the table it
+// was read from so it can't be replayed against another table
+type pageToken struct {
+	TableName string                              `json:"table"`
+	LastKey   map[string]*dynamodb.AttributeValue `json:"key"`
+}
+
+// ItemIterator - structure used to read query or scan results one page at a time
+type ItemIterator struct {
+	svc       *dynamodb.DynamoDB
+	tableName string
+	expr      expression.Expression
+	query     bool
+	pageSize  int64
+	lastKey   map[string]*dynamodb.AttributeValue
+	done      bool
+}
+
+// QueryAllItems - This function queries the specified table, following each
+// page of results until there are no more or limit items have been read
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     tableName: the name of the table to query
+//     expr: the expression object to use
+//     response: a pointer to the array the results should be returned in
+//     limit: the maximum number of items to return, 0 for all of them
+//
+//   Example:
+//     err := QueryAllItems(mySession, "fred", expr, &myArray, 0)
+func QueryAllItems(sess *session.Session, tableName string, expr expression.Expression, response interface{}, limit int64) error {
+
+	// Sanity check
+	if expr.KeyCondition() == nil {
+		return newErrorKeyExpressionKeyNotProvided()
+	}
+	return readAllItems(context.Background(), sess, tableName, expr, true, response, limit)
+}
+
+// ScanAllItems - This function scans the specified table, following each
+// page of results until there are no more or limit items have been read
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     tableName: the name of the table to scan
+//     expr: the expression object to use
+//     response: a pointer to the array the results should be returned in
+//     limit: the maximum number of items to return, 0 for all of them
+//
+//   Example:
+//     err := ScanAllItems(mySession, "fred", expr, &myArray, 100)
+func ScanAllItems(sess *session.Session, tableName string, expr expression.Expression, response interface{}, limit int64) error {
+	return readAllItems(context.Background(), sess, tableName, expr, false, response, limit)
+}
+
+// NewQueryIterator - This function creates an iterator that queries the
+// specified table one page at a time
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     tableName: the name of the table to query
+//     expr: the expression object to use
+//     conf: the page size & continuation token to start from
+//
+//   Example:
+//     it, err := NewQueryIterator(mySession, "fred", expr, PageConf{PageSize: 25, Token: token})
+func NewQueryIterator(sess *session.Session, tableName string, expr expression.Expression, conf PageConf) (*ItemIterator, error) {
+
+	// Sanity check
+	if expr.KeyCondition() == nil {
+		return nil, newErrorKeyExpressionKeyNotProvided()
+	}
+	return newItemIterator(sess, tableName, expr, true, conf)
+}
+
+// NewScanIterator - This function creates an iterator that scans the
+// specified table one page at a time
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     tableName: the name of the table to scan
+//     expr: the expression object to use
+//     conf: the page size & continuation token to start from
+//
+//   Example:
+//     it, err := NewScanIterator(mySession, "fred", expr, PageConf{PageSize: 25})
+func NewScanIterator(sess *session.Session, tableName string, expr expression.Expression, conf PageConf) (*ItemIterator, error) {
+	return newItemIterator(sess, tableName, expr, false, conf)
+}
+
+// NextPage - This function reads the next page of results into page. It
+// returns false, without reading anything, once every page has been read.
+// A page may be empty when a filter removed all of its items.
+//
+//   Parameters:
+//     ctx: the context used to cancel the request
+//     page: a pointer to the array the page should be returned in
+//
+//   Example:
+//     for {
+//       var page []myStruct
+//       more, err := it.NextPage(ctx, &page)
+//       if err != nil || !more {
+//         break
+//       }
+//     }
+func (it *ItemIterator) NextPage(ctx context.Context, page interface{}) (bool, error) {
+
+	// Anything left?
+	if it.done {
+		return false, nil
+	}
+
+	// Read the page
+	items, lastKey, err := readPage(ctx, it.svc, it.tableName, it.expr, it.query, it.lastKey, it.pageSize)
+	if err != nil {
+		return false, err
+	}
+	it.lastKey = lastKey
+	it.done = len(lastKey) == 0
+
+	// Massage the result(s) & return
+	return true, dynamodbattribute.UnmarshalListOfMaps(items, page)
+}
+
+// Done - This function checks if every page has been read
+//
+//   Example:
+//     if it.Done() { ... }
+func (it *ItemIterator) Done() bool {
+	return it.done
+}
+
+// Token - This function returns an opaque continuation token that can be
+// passed in PageConf to carry on from the next page, or an empty string
+// once every page has been read. The token only works for the same table.
+//
+//   Example:
+//     token, err := it.Token()
+func (it *ItemIterator) Token() (string, error) {
+
+	if it.done || len(it.lastKey) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(pageToken{TableName: it.tableName, LastKey: it.lastKey})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// newItemIterator creates an iterator, decoding the continuation token
+func newItemIterator(sess *session.Session, tableName string, expr expression.Expression, query bool, conf PageConf) (*ItemIterator, error) {
+
+	// Sanity check
+	if tableName == "" {
+		return nil, newErrorTableNameNotProvided()
+	}
+	if conf.PageSize < 0 {
+		return nil, newErrorLimitInvalid(conf.PageSize)
+	}
+
+	// Decode where to start from
+	var lastKey map[string]*dynamodb.AttributeValue
+	if conf.Token != "" {
+		data, err := base64.RawURLEncoding.DecodeString(conf.Token)
+		if err != nil {
+			return nil, newErrorPageTokenInvalid(err)
+		}
+		var token pageToken
+		err = json.Unmarshal(data, &token)
+		if err != nil {
+			return nil, newErrorPageTokenInvalid(err)
+		}
+		if token.TableName != tableName {
+			return nil, newErrorPageTokenInvalid(fmt.Errorf("it was issued for table %s", token.TableName))
+		}
+		if len(token.LastKey) == 0 {
+			return nil, newErrorPageTokenInvalid(errors.New("no keys"))
+		}
+		lastKey = token.LastKey
+	}
+
+	return &ItemIterator{
+		svc:       clients.DynamoDB(sess),
+		tableName: tableName,
+		expr:      expr,
+		query:     query,
+		pageSize:  conf.PageSize,
+		lastKey:   lastKey,
+	}, nil
+}
+
+// readAllItems reads pages until there are no more or the limit is reached
+func readAllItems(ctx context.Context, sess *session.Session, tableName string, expr expression.Expression, query bool, response interface{}, limit int64) error {
+
+	// Sanity check
+	if tableName == "" {
+		return newErrorTableNameNotProvided()
+	}
+	if limit < 0 {
+		return newErrorLimitInvalid(limit)
+	}
+
+	// Read the pages, asking for no more than are still needed
+	svc := clients.DynamoDB(sess)
+	var items []map[string]*dynamodb.AttributeValue
+	var lastKey map[string]*dynamodb.AttributeValue
+	for {
+		var pageSize int64
+		if limit > 0 {
+			pageSize = limit - int64(len(items))
+		}
+		page, next, err := readPage(ctx, svc, tableName, expr, query, lastKey, pageSize)
+		if err != nil {
+			return err
+		}
+		items = append(items, page...)
+		if limit > 0 && int64(len(items)) >= limit {
+			items = items[:limit]
+			break
+		}
+		if len(next) == 0 {
+			break
+		}
+		lastKey = next
+	}
+
+	// Massage the result(s) & return
+	return dynamodbattribute.UnmarshalListOfMaps(items, response)
+}
+
+// readPage makes a single query or scan call starting after lastKey
+func readPage(ctx context.Context, svc *dynamodb.DynamoDB, tableName string, expr expression.Expression, query bool, lastKey map[string]*dynamodb.AttributeValue, pageSize int64) ([]map[string]*dynamodb.AttributeValue, map[string]*dynamodb.AttributeValue, error) {
+
+	var limit *int64
+	if pageSize > 0 {
+		limit = aws.Int64(pageSize)
+	}
+
+	// Query
+	if query {
+		result, err := svc.QueryWithContext(ctx, &dynamodb.QueryInput{
+			ExclusiveStartKey:         lastKey,
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			FilterExpression:          expr.Filter(),
+			KeyConditionExpression:    expr.KeyCondition(),
+			Limit:                     limit,
+			ProjectionExpression:      expr.Projection(),
+			TableName:                 aws.String(tableName),
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return result.Items, result.LastEvaluatedKey, nil
+	}
+
+	// Scan
+	result, err := svc.ScanWithContext(ctx, &dynamodb.ScanInput{
+		ExclusiveStartKey:         lastKey,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		FilterExpression:          expr.Filter(),
+		Limit:                     limit,
+		ProjectionExpression:      expr.Projection(),
+		TableName:                 aws.String(tableName),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return result.Items, result.LastEvaluatedKey, nil
+}
@@ -0,0 +1,162 @@
+package dynamodb_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/bradmccoydev/self-service-sdk/internal"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/dynamodb"
+)
+
+// pagedItems returns a Query/Scan handler serving three pages of two items
+func pagedItems(input map[string]interface{}) (int, interface{}) {
+
+	// Work out which page was asked for
+	page := 0
+	if start, ok := input["ExclusiveStartKey"].(map[string]interface{}); ok {
+		fmt.Sscanf(start["name"].(map[string]interface{})["S"].(string), "item-%d", &page)
+		page = page/2 + 1
+	}
+
+	// Build it
+	var items []interface{}
+	for i := page * 2; i < page*2+2; i++ {
+		items = append(items, map[string]interface{}{"name": map[string]interface{}{"S": fmt.Sprintf("item-%d", i)}})
+	}
+	body := map[string]interface{}{"Items": items}
+	if page < 2 {
+		body["LastEvaluatedKey"] = map[string]interface{}{"name": map[string]interface{}{"S": fmt.Sprintf("item-%d", page*2+1)}}
+	}
+	return http.StatusOK, body
+}
+
+// Test QueryAllItems & ScanAllItems
+func TestReadAllItems(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("Query", pagedItems)
+	fake.Handle("Scan", pagedItems)
+
+	// Setup test data
+	keys := []dynamodb.Condition{{Field: "name", Operator: dynamodb.Equals, Value: "x"}}
+	queryExpr, err := dynamodb.NewExpression(keys, nil, nil)
+	internal.NoError(t, err)
+	scanExpr, err := dynamodb.NewExpression(nil, nil, []dynamodb.Field{{Name: "name"}})
+	internal.NoError(t, err)
+	tests := []struct {
+		desc          string
+		query         bool
+		tableName     string
+		limit         int64
+		expectErr     bool
+		expectedItems int
+		expectedCalls int
+	}{
+		{"No table name", true, "", 0, true, 0, 0},
+		{"Negative limit", false, TestTableNameValid, -1, true, 0, 0},
+		{"Query everything", true, TestTableNameValid, 0, false, 6, 3},
+		{"Scan everything", false, TestTableNameValid, 0, false, 6, 3},
+		{"Limit within the first page", true, TestTableNameValid, 1, false, 1, 1},
+		{"Limit across pages", false, TestTableNameValid, 3, false, 3, 2},
+		{"Limit above the item count", false, TestTableNameValid, 10, false, 6, 3},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Run the test
+			var items []TestTableFullItem
+			before := len(fake.Calls("Query")) + len(fake.Calls("Scan"))
+			if test.query {
+				err = dynamodb.QueryAllItems(fake.Session(), test.tableName, queryExpr, &items, test.limit)
+			} else {
+				err = dynamodb.ScanAllItems(fake.Session(), test.tableName, scanExpr, &items, test.limit)
+			}
+			if test.expectErr {
+				internal.HasError(t, err)
+			} else {
+				internal.NoError(t, err)
+				internal.Equals(t, test.expectedItems, len(items))
+				internal.Equals(t, "item-0", items[0].Name)
+			}
+			internal.Equals(t, test.expectedCalls, len(fake.Calls("Query"))+len(fake.Calls("Scan"))-before)
+		})
+	}
+
+	// Each page only asks for the items still needed
+	calls := fake.Calls("Scan")
+	internal.Equals(t, float64(3), calls[len(calls)-5].Input["Limit"])
+	internal.Equals(t, float64(1), calls[len(calls)-4].Input["Limit"])
+	internal.Equals(t, nil, calls[0].Input["Limit"])
+
+	// A query needs a key condition
+	var items []TestTableFullItem
+	err = dynamodb.QueryAllItems(fake.Session(), TestTableNameValid, scanExpr, &items, 0)
+	internal.HasError(t, err)
+}
+
+// Test the page iterator & continuation tokens
+func TestItemIterator(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("Query", pagedItems)
+	keys := []dynamodb.Condition{{Field: "name", Operator: dynamodb.Equals, Value: "x"}}
+	expr, err := dynamodb.NewExpression(keys, nil, nil)
+	internal.NoError(t, err)
+	ctx := context.Background()
+
+	// Read the first page
+	it, err := dynamodb.NewQueryIterator(fake.Session(), TestTableNameValid, expr, dynamodb.PageConf{PageSize: 2})
+	internal.NoError(t, err)
+	var page []TestTableFullItem
+	more, err := it.NextPage(ctx, &page)
+	internal.NoError(t, err)
+	internal.Assert(t, more, "expected a page")
+	internal.Equals(t, []TestTableFullItem{{Name: "item-0"}, {Name: "item-1"}}, page)
+	internal.Equals(t, float64(2), fake.Calls("Query")[0].Input["Limit"])
+	token, err := it.Token()
+	internal.NoError(t, err)
+	internal.Assert(t, token != "", "expected a continuation token")
+	first := token
+
+	// Carry on from the token with a new iterator, as an API handler would
+	it, err = dynamodb.NewQueryIterator(fake.Session(), TestTableNameValid, expr, dynamodb.PageConf{Token: token})
+	internal.NoError(t, err)
+	var names []string
+	for {
+		var page []TestTableFullItem
+		more, err := it.NextPage(ctx, &page)
+		internal.NoError(t, err)
+		if !more {
+			break
+		}
+		for _, i := range page {
+			names = append(names, i.Name)
+		}
+	}
+	internal.Equals(t, []string{"item-2", "item-3", "item-4", "item-5"}, names)
+	internal.Assert(t, it.Done(), "expected the iterator to be done")
+	token, err = it.Token()
+	internal.NoError(t, err)
+	internal.Equals(t, "", token)
+
+	// Bad tokens & settings are rejected
+	_, err = dynamodb.NewScanIterator(fake.Session(), TestTableNameValid, expr, dynamodb.PageConf{Token: "not base64!"})
+	internal.HasError(t, err)
+	_, err = dynamodb.NewScanIterator(fake.Session(), TestTableNameValid, expr, dynamodb.PageConf{Token: "bnVsbA"})
+	internal.HasError(t, err)
+	_, err = dynamodb.NewQueryIterator(fake.Session(), TestTableNameInvalid, expr, dynamodb.PageConf{Token: first})
+	internal.HasError(t, err)
+	_, err = dynamodb.NewScanIterator(fake.Session(), TestTableNameValid, expr, dynamodb.PageConf{PageSize: -1})
+	internal.HasError(t, err)
+	_, err = dynamodb.NewScanIterator(fake.Session(), "", expr, dynamodb.PageConf{})
+	internal.HasError(t, err)
+}
@@ -0,0 +1,579 @@
+// This file contains all the bits & pieces related to
+// reconciling a live table with a table schema: working
+// out a plan of the changes needed & applying it
+
+package dynamodb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/clients"
+)
+
+const (
+	// PlanActionCreate - the table doesn't exist & will be created
+	PlanActionCreate string = "CREATE"
+
+	// PlanActionUpdate - the table exists & will be updated in-place
+	PlanActionUpdate string = "UPDATE"
+
+	// PlanActionNone - the table matches the schema
+	PlanActionNone string = "NONE"
+
+	// PlanChangeAdd - a setting will be added
+	PlanChangeAdd string = "+"
+
+	// PlanChangeModify - a setting will be changed in-place
+	PlanChangeModify string = "~"
+
+	// PlanChangeRemove - a setting will be removed
+	PlanChangeRemove string = "-"
+
+	// PlanChangeReplace - a setting will be removed & added again
+	PlanChangeReplace string = "-/+"
+)
+
+// PlanChange - structure used to represent a single change in a plan
+type PlanChange struct {
+	Type  string
+	Field string
+	Old   string
+	New   string
+}
+
+// TablePlan - structure used to represent the changes needed to make a
+// table match its schema. Use String to get a human readable version.
+type TablePlan struct {
+	TableName string
+	Action    string
+	Changes   []PlanChange
+
+	schema        TableSchema
+	tableArn      string
+	updateTable   bool
+	update        TableChanges
+	restartStream bool
+	ttl           *dynamodb.TimeToLiveSpecification
+	tagsAdd       map[string]string
+	tagsRemove    []string
+}
+
+// ReconcileConf - structure used to represent how to reconcile a table.
+// With DryRun set the plan is returned without being applied.
+type ReconcileConf struct {
+	DryRun bool
+	Wait   WaitConf
+}
+
+// PlanTable - This function works out the changes needed to make the
+// live table match the schema
+//
+//   Parameters:
+//     ctx: the context used to cancel the requests
+//     sess: a valid AWS session
+//     schema: the desired table definition
+//
+//   Example:
+//     plan, err := PlanTable(ctx, mySession, schema)
+//     fmt.Println(plan)
+func PlanTable(ctx context.Context, sess *session.Session, schema TableSchema) (TablePlan, error) {
+
+	// Sanity check
+	plan := TablePlan{TableName: schema.Name, Action: PlanActionNone, schema: schema}
+	err := schema.Validate()
+	if err != nil {
+		return plan, err
+	}
+
+	// Get the live table, if it doesn't exist then it needs creating
+	svc := clients.DynamoDB(sess)
+	result, err := svc.DescribeTableWithContext(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(schema.Name)})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeResourceNotFoundException {
+		planCreate(&plan)
+		return plan, nil
+	}
+	if err != nil {
+		return plan, err
+	}
+	if result.Table == nil {
+		return plan, newErrorTableDetailsNotProvided()
+	}
+	table := result.Table
+	plan.tableArn = aws.StringValue(table.TableArn)
+
+	// Work out the table changes
+	err = planTableChanges(&plan, table)
+	if err != nil {
+		return plan, err
+	}
+
+	// Work out the TTL changes
+	ttl, err := svc.DescribeTimeToLiveWithContext(ctx, &dynamodb.DescribeTimeToLiveInput{TableName: aws.String(schema.Name)})
+	if err != nil {
+		return plan, err
+	}
+	err = planTTLChanges(&plan, ttl.TimeToLiveDescription)
+	if err != nil {
+		return plan, err
+	}
+
+	// Work out the tag changes
+	tags, err := listTags(ctx, svc, plan.tableArn)
+	if err != nil {
+		return plan, err
+	}
+	planTagChanges(&plan, tags)
+
+	// Anything to do?
+	if len(plan.Changes) > 0 {
+		plan.Action = PlanActionUpdate
+	}
+	return plan, nil
+}
+
+// ApplyTablePlan - This function applies a plan created by PlanTable, waiting
+// for the table to be ACTIVE after each change
+//
+//   Parameters:
+//     ctx: the context used to cancel the requests & waits
+//     sess: a valid AWS session
+//     plan: the plan to apply
+//     wait: the timeout & backoff to use while waiting
+//
+//   Example:
+//     err := ApplyTablePlan(ctx, mySession, plan, WaitConf{})
+func ApplyTablePlan(ctx context.Context, sess *session.Session, plan TablePlan, wait WaitConf) error {
+
+	svc := clients.DynamoDB(sess)
+	switch plan.Action {
+	case PlanActionNone:
+		return nil
+
+	case PlanActionCreate:
+
+		// The spec includes the stream, time to live & tags
+		return CreateTableFromSpecWithContext(ctx, sess, plan.schema.Spec(), wait)
+
+	case PlanActionUpdate:
+
+		// Apply the table changes
+		if plan.updateTable {
+			err := UpdateTableWithContext(ctx, sess, plan.TableName, plan.update, wait)
+			if err != nil {
+				return err
+			}
+		}
+
+	default:
+		return newErrorPlanActionInvalid(plan.Action)
+	}
+
+	// A stream view type can only be changed by disabling then re-enabling the stream
+	if plan.restartStream {
+		err := UpdateTableWithContext(ctx, sess, plan.TableName, TableChanges{Stream: &StreamSpec{Enabled: false}}, wait)
+		if err != nil {
+			return err
+		}
+		err = UpdateTableWithContext(ctx, sess, plan.TableName, TableChanges{Stream: &StreamSpec{Enabled: true, ViewType: plan.schema.StreamViewType}}, wait)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Apply the TTL
+	if plan.ttl != nil {
+		_, err := svc.UpdateTimeToLiveWithContext(ctx, &dynamodb.UpdateTimeToLiveInput{
+			TableName:               aws.String(plan.TableName),
+			TimeToLiveSpecification: plan.ttl,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Apply the tags
+	if len(plan.tagsAdd) > 0 {
+		_, err := svc.TagResourceWithContext(ctx, &dynamodb.TagResourceInput{
+			ResourceArn: aws.String(plan.tableArn),
+			Tags:        newTags(plan.tagsAdd),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if len(plan.tagsRemove) > 0 {
+		_, err := svc.UntagResourceWithContext(ctx, &dynamodb.UntagResourceInput{
+			ResourceArn: aws.String(plan.tableArn),
+			TagKeys:     aws.StringSlice(plan.tagsRemove),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReconcileTable - This function plans the changes needed to make the live
+// table match the schema then, unless it is a dry run, applies them
+//
+//   Parameters:
+//     ctx: the context used to cancel the requests & waits
+//     sess: a valid AWS session
+//     schema: the desired table definition
+//     conf: whether this is a dry run & how to wait
+//
+//   Example:
+//     plan, err := ReconcileTable(ctx, mySession, schema, ReconcileConf{DryRun: true})
+//     fmt.Println(plan)
+func ReconcileTable(ctx context.Context, sess *session.Session, schema TableSchema, conf ReconcileConf) (TablePlan, error) {
+
+	plan, err := PlanTable(ctx, sess, schema)
+	if err != nil || conf.DryRun {
+		return plan, err
+	}
+	return plan, ApplyTablePlan(ctx, sess, plan, conf.Wait)
+}
+
+// String - This function returns the plan in a human readable form
+//
+//   Example:
+//     fmt.Println(plan)
+func (p TablePlan) String() string {
+
+	var b strings.Builder
+	switch p.Action {
+	case PlanActionCreate:
+		fmt.Fprintf(&b, "dynamodb table %q will be created:\n", p.TableName)
+	case PlanActionUpdate:
+		fmt.Fprintf(&b, "dynamodb table %q will be updated in-place:\n", p.TableName)
+	default:
+		fmt.Fprintf(&b, "dynamodb table %q is up to date, no changes.\n", p.TableName)
+		return b.String()
+	}
+
+	// List the changes
+	add, change, remove := 0, 0, 0
+	for _, c := range p.Changes {
+		switch c.Type {
+		case PlanChangeAdd:
+			add++
+			fmt.Fprintf(&b, "  + %s = %q\n", c.Field, c.New)
+		case PlanChangeRemove:
+			remove++
+			fmt.Fprintf(&b, "  - %s = %q\n", c.Field, c.Old)
+		case PlanChangeReplace:
+			add++
+			remove++
+			fmt.Fprintf(&b, "-/+ %s: %q -> %q (forces replacement)\n", c.Field, c.Old, c.New)
+		default:
+			change++
+			fmt.Fprintf(&b, "  ~ %s: %q -> %q\n", c.Field, c.Old, c.New)
+		}
+	}
+	fmt.Fprintf(&b, "\nPlan: %d to add, %d to change, %d to remove.\n", add, change, remove)
+	return b.String()
+}
+
+// addChange records a change in the plan
+func (p *TablePlan) addChange(changeType string, field string, old string, new string) {
+	p.Changes = append(p.Changes, PlanChange{Type: changeType, Field: field, Old: old, New: new})
+}
+
+// planCreate fills in the plan for a table that doesn't exist
+func planCreate(plan *TablePlan) {
+
+	plan.Action = PlanActionCreate
+	s := plan.schema
+	spec := s.Spec()
+	plan.addChange(PlanChangeAdd, "billing_mode", "", strings.ToUpper(s.BillingMode))
+	if strings.ToUpper(s.BillingMode) == BillingModeProvisioned {
+		plan.addChange(PlanChangeAdd, "capacity", "", formatCapacity(s.ReadCapacityUnits, s.WriteCapacityUnits))
+	}
+	for _, a := range spec.Attributes {
+		plan.addChange(PlanChangeAdd, "attributes."+a.Name, "", a.Type)
+	}
+	plan.addChange(PlanChangeAdd, "keys", "", formatKeys(spec.Keys))
+	for _, idx := range spec.GlobalIndexes {
+		plan.addChange(PlanChangeAdd, "global_index."+idx.IndexName, "", formatIndex(idx))
+	}
+	for _, idx := range spec.LocalIndexes {
+		plan.addChange(PlanChangeAdd, "local_index."+idx.IndexName, "", formatIndex(idx))
+	}
+	if s.StreamViewType != "" {
+		plan.addChange(PlanChangeAdd, "stream_view_type", "", strings.ToUpper(s.StreamViewType))
+	}
+	if s.TTLAttribute != "" {
+		plan.addChange(PlanChangeAdd, "ttl_attribute", "", s.TTLAttribute)
+	}
+	planTagChanges(plan, nil)
+}
+
+// planTableChanges works out the billing, index & stream changes
+func planTableChanges(plan *TablePlan, table *dynamodb.TableDescription) error {
+
+	s := plan.schema
+	spec := s.Spec()
+
+	// Keys & local indexes can't be changed
+	if formatKeys(keySchemaFromElements(table.KeySchema)) != formatKeys(spec.Keys) {
+		return newErrorSchemaChangeNotSupported(s.Name, "keys")
+	}
+	var current, target []string
+	for _, idx := range table.LocalSecondaryIndexes {
+		current = append(current, aws.StringValue(idx.IndexName)+" "+formatIndex(indexSpecFromDescription(idx.KeySchema, idx.Projection)))
+	}
+	for _, idx := range spec.LocalIndexes {
+		target = append(target, idx.IndexName+" "+formatIndex(idx))
+	}
+	sort.Strings(current)
+	sort.Strings(target)
+	if strings.Join(current, ",") != strings.Join(target, ",") {
+		return newErrorSchemaChangeNotSupported(s.Name, "local indexes")
+	}
+
+	// Billing mode & table capacity
+	currentMode := BillingModeProvisioned
+	if table.BillingModeSummary != nil && aws.StringValue(table.BillingModeSummary.BillingMode) != "" {
+		currentMode = aws.StringValue(table.BillingModeSummary.BillingMode)
+	}
+	targetMode := strings.ToUpper(s.BillingMode)
+	switching := currentMode != targetMode
+	if switching {
+		plan.update.BillingMode = targetMode
+		plan.addChange(PlanChangeModify, "billing_mode", currentMode, targetMode)
+	}
+	provisioned := targetMode == BillingModeProvisioned
+	if provisioned {
+		var read, write int64
+		if !switching && table.ProvisionedThroughput != nil {
+			read = aws.Int64Value(table.ProvisionedThroughput.ReadCapacityUnits)
+			write = aws.Int64Value(table.ProvisionedThroughput.WriteCapacityUnits)
+		}
+		if switching || read != s.ReadCapacityUnits || write != s.WriteCapacityUnits {
+			plan.update.ReadCapacityUnits = s.ReadCapacityUnits
+			plan.update.WriteCapacityUnits = s.WriteCapacityUnits
+			plan.addChange(PlanChangeModify, "capacity", formatCapacity(read, write), formatCapacity(s.ReadCapacityUnits, s.WriteCapacityUnits))
+		}
+	}
+
+	// Global indexes
+	live := make(map[string]*dynamodb.GlobalSecondaryIndexDescription)
+	for _, idx := range table.GlobalSecondaryIndexes {
+		live[aws.StringValue(idx.IndexName)] = idx
+	}
+	wanted := make(map[string]bool)
+	for _, idx := range spec.GlobalIndexes {
+		wanted[idx.IndexName] = true
+		existing, ok := live[idx.IndexName]
+		if !ok {
+			plan.update.AddGlobalIndexes = append(plan.update.AddGlobalIndexes, idx)
+			plan.addChange(PlanChangeAdd, "global_index."+idx.IndexName, "", formatIndex(idx))
+			continue
+		}
+		old := formatIndex(indexSpecFromDescription(existing.KeySchema, existing.Projection))
+		if old != formatIndex(idx) {
+			plan.update.DeleteGlobalIndexes = append(plan.update.DeleteGlobalIndexes, idx.IndexName)
+			plan.update.AddGlobalIndexes = append(plan.update.AddGlobalIndexes, idx)
+			plan.addChange(PlanChangeReplace, "global_index."+idx.IndexName, old, formatIndex(idx))
+			continue
+		}
+		if provisioned {
+			var read, write int64
+			if !switching && existing.ProvisionedThroughput != nil {
+				read = aws.Int64Value(existing.ProvisionedThroughput.ReadCapacityUnits)
+				write = aws.Int64Value(existing.ProvisionedThroughput.WriteCapacityUnits)
+			}
+			if switching || read != idx.ReadCapacityUnits || write != idx.WriteCapacityUnits {
+				plan.update.UpdateGlobalIndexes = append(plan.update.UpdateGlobalIndexes, IndexThroughput{
+					IndexName:          idx.IndexName,
+					ReadCapacityUnits:  idx.ReadCapacityUnits,
+					WriteCapacityUnits: idx.WriteCapacityUnits,
+				})
+				plan.addChange(PlanChangeModify, "global_index."+idx.IndexName+".capacity", formatCapacity(read, write), formatCapacity(idx.ReadCapacityUnits, idx.WriteCapacityUnits))
+			}
+		}
+	}
+	var removed []string
+	for n, idx := range live {
+		if !wanted[n] {
+			removed = append(removed, n)
+			plan.addChange(PlanChangeRemove, "global_index."+n, formatIndex(indexSpecFromDescription(idx.KeySchema, idx.Projection)), "")
+		}
+	}
+	sort.Strings(removed)
+	plan.update.DeleteGlobalIndexes = append(removed, plan.update.DeleteGlobalIndexes...)
+	if len(plan.update.AddGlobalIndexes) > 0 {
+		plan.update.Attributes = spec.Attributes
+	}
+
+	// Streams
+	currentView := ""
+	if table.StreamSpecification != nil && aws.BoolValue(table.StreamSpecification.StreamEnabled) {
+		currentView = aws.StringValue(table.StreamSpecification.StreamViewType)
+	}
+	targetView := strings.ToUpper(s.StreamViewType)
+	switch {
+	case currentView == targetView:
+	case targetView == "":
+		plan.update.Stream = &StreamSpec{Enabled: false}
+		plan.addChange(PlanChangeRemove, "stream_view_type", currentView, "")
+	case currentView == "":
+		plan.update.Stream = &StreamSpec{Enabled: true, ViewType: targetView}
+		plan.addChange(PlanChangeAdd, "stream_view_type", "", targetView)
+	default:
+		plan.restartStream = true
+		plan.addChange(PlanChangeModify, "stream_view_type", currentView, targetView)
+	}
+
+	// Is there anything for UpdateTable to do?
+	plan.updateTable = plan.update.BillingMode != "" || plan.update.ReadCapacityUnits != 0 ||
+		len(plan.update.AddGlobalIndexes) > 0 || len(plan.update.DeleteGlobalIndexes) > 0 ||
+		len(plan.update.UpdateGlobalIndexes) > 0 || plan.update.Stream != nil
+	return nil
+}
+
+// planTTLChanges works out the time to live changes
+func planTTLChanges(plan *TablePlan, ttl *dynamodb.TimeToLiveDescription) error {
+
+	current := ""
+	if ttl != nil {
+		switch aws.StringValue(ttl.TimeToLiveStatus) {
+		case dynamodb.TimeToLiveStatusEnabled, dynamodb.TimeToLiveStatusEnabling:
+			current = aws.StringValue(ttl.AttributeName)
+		}
+	}
+	target := plan.schema.TTLAttribute
+	switch {
+	case current == target:
+	case target == "":
+		plan.ttl = &dynamodb.TimeToLiveSpecification{Enabled: aws.Bool(false), AttributeName: aws.String(current)}
+		plan.addChange(PlanChangeRemove, "ttl_attribute", current, "")
+	case current == "":
+		plan.ttl = &dynamodb.TimeToLiveSpecification{Enabled: aws.Bool(true), AttributeName: aws.String(target)}
+		plan.addChange(PlanChangeAdd, "ttl_attribute", "", target)
+	default:
+		return newErrorSchemaChangeNotSupported(plan.schema.Name, "ttl_attribute (disable time to live first)")
+	}
+	return nil
+}
+
+// planTagChanges works out the tags to add, change & remove
+func planTagChanges(plan *TablePlan, current map[string]string) {
+
+	// Sort the keys so the plan is repeatable
+	var keys []string
+	for k := range plan.schema.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := plan.schema.Tags[k]
+		old, ok := current[k]
+		if ok && old == v {
+			continue
+		}
+		if plan.tagsAdd == nil {
+			plan.tagsAdd = make(map[string]string)
+		}
+		plan.tagsAdd[k] = v
+		if ok {
+			plan.addChange(PlanChangeModify, "tags."+k, old, v)
+		} else {
+			plan.addChange(PlanChangeAdd, "tags."+k, "", v)
+		}
+	}
+
+	// Remove anything not in the schema
+	keys = nil
+	for k := range current {
+		if _, ok := plan.schema.Tags[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		plan.tagsRemove = append(plan.tagsRemove, k)
+		plan.addChange(PlanChangeRemove, "tags."+k, current[k], "")
+	}
+}
+
+// listTags reads all the tags of a resource
+func listTags(ctx context.Context, svc *dynamodb.DynamoDB, arn string) (map[string]string, error) {
+
+	tags := make(map[string]string)
+	params := &dynamodb.ListTagsOfResourceInput{ResourceArn: aws.String(arn)}
+	for {
+		result, err := svc.ListTagsOfResourceWithContext(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range result.Tags {
+			tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+		}
+		if aws.StringValue(result.NextToken) == "" {
+			return tags, nil
+		}
+		params.NextToken = result.NextToken
+	}
+}
+
+// keySchemaFromElements converts key schema elements to a key schema
+func keySchemaFromElements(elements []*dynamodb.KeySchemaElement) KeySchema {
+
+	var keys KeySchema
+	for _, e := range elements {
+		if aws.StringValue(e.KeyType) == KeyTypePartition {
+			keys.PartitionKey = aws.StringValue(e.AttributeName)
+		} else {
+			keys.SortKey = aws.StringValue(e.AttributeName)
+		}
+	}
+	return keys
+}
+
+// indexSpecFromDescription converts the key schema & projection of a described index
+func indexSpecFromDescription(keys []*dynamodb.KeySchemaElement, proj *dynamodb.Projection) IndexSpec {
+
+	idx := IndexSpec{Keys: keySchemaFromElements(keys)}
+	if proj != nil {
+		idx.ProjectionType = aws.StringValue(proj.ProjectionType)
+		idx.NonKeyAttributes = aws.StringValueSlice(proj.NonKeyAttributes)
+	}
+	return idx
+}
+
+// formatKeys formats a key schema for a plan
+func formatKeys(keys KeySchema) string {
+
+	if keys.SortKey == "" {
+		return fmt.Sprintf("(%s)", keys.PartitionKey)
+	}
+	return fmt.Sprintf("(%s, %s)", keys.PartitionKey, keys.SortKey)
+}
+
+// formatIndex formats the keys & projection of an index for a plan
+func formatIndex(idx IndexSpec) string {
+
+	projType := strings.ToUpper(idx.ProjectionType)
+	if projType == "" {
+		projType = ProjectionTypeAll
+	}
+	attrs := append([]string{}, idx.NonKeyAttributes...)
+	sort.Strings(attrs)
+	if len(attrs) > 0 {
+		projType += "[" + strings.Join(attrs, ", ") + "]"
+	}
+	return fmt.Sprintf("keys=%s projection=%s", formatKeys(idx.Keys), projType)
+}
+
+// formatCapacity formats read & write capacity units for a plan
+func formatCapacity(read int64, write int64) string {
+	return fmt.Sprintf("read=%d write=%d", read, write)
+}
@@ -0,0 +1,251 @@
+package dynamodb_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bradmccoydev/self-service-sdk/internal"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/dynamodb"
+)
+
+// liveTable is a fake table that matches testSchemaYAML, with an extra
+// global index & tag. Its stream follows the UpdateTable calls made.
+type liveTable struct {
+	mu     sync.Mutex
+	stream string
+}
+
+// handleDescribe answers DescribeTable
+func (l *liveTable) handleDescribe(input map[string]interface{}) (int, interface{}) {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	index := func(name string, key string) map[string]interface{} {
+		return map[string]interface{}{
+			"IndexName":   name,
+			"IndexStatus": "ACTIVE",
+			"KeySchema":   []interface{}{map[string]interface{}{"AttributeName": key, "KeyType": "HASH"}},
+			"Projection":  map[string]interface{}{"ProjectionType": "ALL"},
+		}
+	}
+	table := map[string]interface{}{
+		"TableName":          TestTableNameValid,
+		"TableStatus":        "ACTIVE",
+		"TableArn":           "arn:aws:dynamodb:us-east-1:123456789012:table/" + TestTableNameValid,
+		"BillingModeSummary": map[string]interface{}{"BillingMode": dynamodb.BillingModePayPerRequest},
+		"AttributeDefinitions": []interface{}{
+			map[string]interface{}{"AttributeName": "service", "AttributeType": "S"},
+			map[string]interface{}{"AttributeName": "owner", "AttributeType": "S"},
+			map[string]interface{}{"AttributeName": "old", "AttributeType": "S"},
+		},
+		"KeySchema":              []interface{}{map[string]interface{}{"AttributeName": "service", "KeyType": "HASH"}},
+		"GlobalSecondaryIndexes": []interface{}{index("by-owner", "owner"), index("by-old", "old")},
+	}
+	if l.stream != "" {
+		table["StreamSpecification"] = map[string]interface{}{"StreamEnabled": true, "StreamViewType": l.stream}
+	}
+	return http.StatusOK, map[string]interface{}{"Table": table}
+}
+
+// handleUpdate answers UpdateTable, tracking the stream
+func (l *liveTable) handleUpdate(input map[string]interface{}) (int, interface{}) {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if stream, ok := input["StreamSpecification"].(map[string]interface{}); ok {
+		l.stream = ""
+		if stream["StreamEnabled"] == true {
+			l.stream = stream["StreamViewType"].(string)
+		}
+	}
+	return http.StatusOK, map[string]interface{}{}
+}
+
+// newPlanBackend starts a fake server hosting the live table
+func newPlanBackend() *FakeDynamo {
+
+	fake := NewFakeDynamo()
+	live := &liveTable{stream: "NEW_IMAGE"}
+	ok := func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{}
+	}
+	fake.Handle("DescribeTable", live.handleDescribe)
+	fake.Handle("UpdateTable", live.handleUpdate)
+	fake.Handle("DescribeTimeToLive", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{"TimeToLiveDescription": map[string]interface{}{"AttributeName": "expires", "TimeToLiveStatus": "ENABLED"}}
+	})
+	fake.Handle("ListTagsOfResource", func(input map[string]interface{}) (int, interface{}) {
+		if input["NextToken"] == nil {
+			return http.StatusOK, map[string]interface{}{"Tags": []interface{}{map[string]interface{}{"Key": "cost-centre", "Value": "1234"}}, "NextToken": "page2"}
+		}
+		return http.StatusOK, map[string]interface{}{"Tags": []interface{}{map[string]interface{}{"Key": "old", "Value": "yes"}}}
+	})
+	fake.Handle("CreateTable", ok)
+	fake.Handle("UpdateTimeToLive", ok)
+	fake.Handle("TagResource", ok)
+	fake.Handle("UntagResource", ok)
+	return fake
+}
+
+// mutatingCalls counts the calls that change the table
+func mutatingCalls(fake *FakeDynamo) int {
+
+	count := 0
+	for _, op := range []string{"CreateTable", "UpdateTable", "UpdateTimeToLive", "TagResource", "UntagResource"} {
+		count += len(fake.Calls(op))
+	}
+	return count
+}
+
+// Test PlanTable for a table that needs creating
+func TestPlanTableCreate(t *testing.T) {
+
+	// Setup backend
+	fake := newPlanBackend()
+	defer fake.Server.Close()
+	fake.Handle("DescribeTable", internal.Sequence(tableMissing, tableStatus("ACTIVE")))
+
+	// A dry run shouldn't change anything
+	plan, err := dynamodb.ReconcileTable(context.Background(), fake.Session(), newTestSchema(), dynamodb.ReconcileConf{DryRun: true, Wait: fastWait})
+	internal.NoError(t, err)
+	internal.Equals(t, dynamodb.PlanActionCreate, plan.Action)
+	internal.Equals(t, 0, mutatingCalls(fake))
+	internal.Equals(t, `dynamodb table "testing" will be created:
+  + billing_mode = "PAY_PER_REQUEST"
+  + attributes.owner = "S"
+  + attributes.service = "S"
+  + keys = "(service)"
+  + global_index.by-owner = "keys=(owner) projection=ALL"
+  + stream_view_type = "NEW_IMAGE"
+  + ttl_attribute = "expires"
+  + tags.cost-centre = "1234"
+
+Plan: 8 to add, 0 to change, 0 to remove.
+`, plan.String())
+
+	// Apply it
+	err = dynamodb.ApplyTablePlan(context.Background(), fake.Session(), plan, fastWait)
+	internal.NoError(t, err)
+	input := fake.Calls("CreateTable")[0].Input
+	internal.Equals(t, map[string]interface{}{"StreamEnabled": true, "StreamViewType": "NEW_IMAGE"}, input["StreamSpecification"])
+	internal.Equals(t, []interface{}{map[string]interface{}{"Key": "cost-centre", "Value": "1234"}}, input["Tags"])
+	internal.Equals(t, map[string]interface{}{"AttributeName": "expires", "Enabled": true}, fake.Calls("UpdateTimeToLive")[0].Input["TimeToLiveSpecification"])
+	internal.Equals(t, 2, mutatingCalls(fake))
+}
+
+// Test PlanTable for a table that needs updating
+func TestPlanTableUpdate(t *testing.T) {
+
+	// Setup backend
+	fake := newPlanBackend()
+	defer fake.Server.Close()
+
+	// Setup test data
+	schema := newTestSchema()
+	schema.BillingMode = "provisioned"
+	schema.ReadCapacityUnits, schema.WriteCapacityUnits = 10, 5
+	schema.Attributes["status"] = "S"
+	schema.GlobalIndexes[0].ReadCapacityUnits, schema.GlobalIndexes[0].WriteCapacityUnits = 2, 1
+	schema.GlobalIndexes = append(schema.GlobalIndexes, dynamodb.IndexSchema{Name: "by-status", PartitionKey: "status", ReadCapacityUnits: 1, WriteCapacityUnits: 1})
+	schema.StreamViewType = "NEW_AND_OLD_IMAGES"
+	schema.TTLAttribute = ""
+	schema.Tags = map[string]string{"cost-centre": "5678", "team": "platform"}
+
+	// A dry run shouldn't change anything
+	plan, err := dynamodb.ReconcileTable(context.Background(), fake.Session(), schema, dynamodb.ReconcileConf{DryRun: true, Wait: fastWait})
+	internal.NoError(t, err)
+	internal.Equals(t, dynamodb.PlanActionUpdate, plan.Action)
+	internal.Equals(t, 0, mutatingCalls(fake))
+	internal.Equals(t, `dynamodb table "testing" will be updated in-place:
+  ~ billing_mode: "PAY_PER_REQUEST" -> "PROVISIONED"
+  ~ capacity: "read=0 write=0" -> "read=10 write=5"
+  ~ global_index.by-owner.capacity: "read=0 write=0" -> "read=2 write=1"
+  + global_index.by-status = "keys=(status) projection=ALL"
+  - global_index.by-old = "keys=(old) projection=ALL"
+  ~ stream_view_type: "NEW_IMAGE" -> "NEW_AND_OLD_IMAGES"
+  - ttl_attribute = "expires"
+  ~ tags.cost-centre: "1234" -> "5678"
+  + tags.team = "platform"
+  - tags.old = "yes"
+
+Plan: 2 to add, 5 to change, 3 to remove.
+`, plan.String())
+
+	// Apply it
+	_, err = dynamodb.ReconcileTable(context.Background(), fake.Session(), schema, dynamodb.ReconcileConf{Wait: fastWait})
+	internal.NoError(t, err)
+
+	// Delete the old index, switch billing mode, add the new index then restart the stream
+	updates := fake.Calls("UpdateTable")
+	internal.Equals(t, 5, len(updates))
+	internal.Equals(t, []interface{}{map[string]interface{}{"Delete": map[string]interface{}{"IndexName": "by-old"}}}, updates[0].Input["GlobalSecondaryIndexUpdates"])
+	internal.Equals(t, "PROVISIONED", updates[1].Input["BillingMode"])
+	internal.Equals(t, "by-status", updates[2].Input["GlobalSecondaryIndexUpdates"].([]interface{})[0].(map[string]interface{})["Create"].(map[string]interface{})["IndexName"])
+	internal.Equals(t, map[string]interface{}{"StreamEnabled": false}, updates[3].Input["StreamSpecification"])
+	internal.Equals(t, map[string]interface{}{"StreamEnabled": true, "StreamViewType": "NEW_AND_OLD_IMAGES"}, updates[4].Input["StreamSpecification"])
+
+	// Then the TTL & tags
+	internal.Equals(t, map[string]interface{}{"AttributeName": "expires", "Enabled": false}, fake.Calls("UpdateTimeToLive")[0].Input["TimeToLiveSpecification"])
+	internal.Equals(t, 2, len(fake.Calls("TagResource")[0].Input["Tags"].([]interface{})))
+	internal.Equals(t, []interface{}{"old"}, fake.Calls("UntagResource")[0].Input["TagKeys"])
+}
+
+// Test PlanTable for changes that can't be made in-place
+func TestPlanTableChanges(t *testing.T) {
+
+	// Setup test data
+	upToDate := newTestSchema()
+	upToDate.Tags["old"] = "yes"
+	upToDate.GlobalIndexes = append(upToDate.GlobalIndexes, dynamodb.IndexSchema{Name: "by-old", PartitionKey: "old"})
+	upToDate.Attributes["old"] = "S"
+	replaceIndex := newTestSchema()
+	replaceIndex.GlobalIndexes[0].Projection = dynamodb.ProjectionTypeKeysOnly
+	newKeys := newTestSchema()
+	newKeys.Attributes["created"] = "S"
+	newKeys.SortKey = "created"
+	newTTL := newTestSchema()
+	newTTL.TTLAttribute = "expiry"
+	invalid := newTestSchema()
+	invalid.Name = ""
+
+	tests := []struct {
+		desc           string
+		schema         dynamodb.TableSchema
+		expectErr      bool
+		expectedAction string
+		expectedPlan   string
+	}{
+		{"Invalid schema", invalid, true, "", ""},
+		{"Keys changed", newKeys, true, "", ""},
+		{"TTL attribute changed", newTTL, true, "", ""},
+		{"Up to date", upToDate, false, dynamodb.PlanActionNone, "is up to date, no changes."},
+		{"Index replaced", replaceIndex, false, dynamodb.PlanActionUpdate, `-/+ global_index.by-owner: "keys=(owner) projection=ALL" -> "keys=(owner) projection=KEYS_ONLY" (forces replacement)`},
+	}
+
+	// Setup backend
+	fake := newPlanBackend()
+	defer fake.Server.Close()
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Run the test
+			plan, err := dynamodb.PlanTable(context.Background(), fake.Session(), test.schema)
+			if test.expectErr {
+				internal.HasError(t, err)
+			} else {
+				internal.NoError(t, err)
+				internal.Equals(t, test.expectedAction, plan.Action)
+				internal.Assert(t, strings.Contains(plan.String(), test.expectedPlan), "unexpected plan: %s", plan.String())
+			}
+		})
+	}
+
+	// Planning never changes anything
+	internal.Equals(t, 0, mutatingCalls(fake))
+}
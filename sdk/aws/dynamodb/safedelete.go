@@ -0,0 +1,134 @@
+// This file contains all the bits & pieces related to
+// deleting tables with guards against deleting the
+// wrong table by accident
+
+package dynamodb
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/clients"
+)
+
+const (
+	// DefaultProtectionTag - the tag that protects a table from deletion
+	DefaultProtectionTag string = "deletion-protection"
+
+	// finalBackupTimeFormat - the timestamp added to generated backup names
+	finalBackupTimeFormat string = "20060102150405"
+
+	// maxBackupNameLength - the longest backup name DynamoDB accepts
+	maxBackupNameLength int = 255
+)
+
+// DeleteGuard - structure used to represent the checks made before deleting a
+// table. ConfirmArn must be the ARN of the table (see GetTableArn). Tables
+// carrying the ProtectionTag (DefaultProtectionTag if empty) are refused
+// unless its value is "false". With FinalBackup set an on-demand backup is
+// taken first, named BackupName or "<table>-final-<timestamp>" if empty
+// (with the table name shortened to keep within DynamoDB's limit).
+type DeleteGuard struct {
+	ConfirmArn    string
+	ProtectionTag string
+	FinalBackup   bool
+	BackupName    string
+}
+
+// SafeDeleteTable - This function checks the guard, optionally takes a final
+// backup, then deletes the table & waits (using the default WaitConf) until
+// it is gone. The ARN of the final backup, if any, is returned.
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     tableName: the name of the table to delete
+//     guard: the checks to make before deleting
+//
+//   Example:
+//     arn, _ := GetTableArn(mySession, "fred")
+//     backupArn, err := SafeDeleteTable(mySession, "fred", DeleteGuard{ConfirmArn: *arn, FinalBackup: true})
+func SafeDeleteTable(sess *session.Session, tableName string, guard DeleteGuard) (string, error) {
+	return SafeDeleteTableWithContext(context.Background(), sess, tableName, guard, WaitConf{})
+}
+
+// SafeDeleteTableWithContext - This function checks the guard, optionally takes
+// a final backup, then deletes the table & waits until it is gone. The ARN of
+// the final backup, if any, is returned.
+//
+//   Parameters:
+//     ctx: the context used to cancel the requests & waits
+//     sess: a valid AWS session
+//     tableName: the name of the table to delete
+//     guard: the checks to make before deleting
+//     wait: the timeout & backoff to use while waiting
+//
+//   Example:
+//     backupArn, err := SafeDeleteTableWithContext(ctx, mySession, "fred", DeleteGuard{ConfirmArn: arn}, WaitConf{})
+func SafeDeleteTableWithContext(ctx context.Context, sess *session.Session, tableName string, guard DeleteGuard, wait WaitConf) (string, error) {
+
+	// Sanity check
+	if tableName == "" {
+		return "", newErrorTableNameNotProvided()
+	}
+	if guard.ConfirmArn == "" {
+		return "", newErrorDeleteNotConfirmed(tableName)
+	}
+
+	// The confirmation must be the ARN of this table
+	svc := clients.DynamoDB(sess)
+	result, err := svc.DescribeTableWithContext(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(tableName)})
+	if err != nil {
+		return "", err
+	}
+	if result.Table == nil {
+		return "", newErrorTableDetailsNotProvided()
+	}
+	arn := aws.StringValue(result.Table.TableArn)
+	if guard.ConfirmArn != arn {
+		return "", newErrorDeleteConfirmationMismatch(tableName, guard.ConfirmArn)
+	}
+
+	// Refuse protected tables
+	tag := guard.ProtectionTag
+	if tag == "" {
+		tag = DefaultProtectionTag
+	}
+	tags, err := listTags(ctx, svc, arn)
+	if err != nil {
+		return "", err
+	}
+	if value, ok := tags[tag]; ok && !strings.EqualFold(value, "false") {
+		return "", newErrorTableProtected(tableName, tag)
+	}
+
+	// Take the final backup
+	var backupArn string
+	if guard.FinalBackup {
+		name := guard.BackupName
+		if name == "" {
+			name = finalBackupName(tableName, time.Now())
+		}
+		backupArn, err = CreateBackupWithContext(ctx, sess, tableName, name, wait)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// Now it's safe to delete
+	return backupArn, DeleteTableWithContext(ctx, sess, tableName, wait)
+}
+
+// finalBackupName generates a backup name from the table name & time,
+// shortening the table name so the suffix always fits
+func finalBackupName(tableName string, now time.Time) string {
+
+	suffix := "-final-" + now.UTC().Format(finalBackupTimeFormat)
+	if len(tableName)+len(suffix) > maxBackupNameLength {
+		tableName = tableName[:maxBackupNameLength-len(suffix)]
+	}
+	return tableName + suffix
+}
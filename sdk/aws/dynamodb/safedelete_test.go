@@ -0,0 +1,113 @@
+package dynamodb_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bradmccoydev/self-service-sdk/internal"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/dynamodb"
+)
+
+// Test SafeDeleteTable
+func TestSafeDeleteTable(t *testing.T) {
+
+	// Setup test data
+	arn := "arn:aws:dynamodb:us-east-1:123456789012:table/" + TestTableNameValid
+	tests := []struct {
+		desc            string
+		tableName       string
+		tags            map[string]string
+		guard           dynamodb.DeleteGuard
+		expectedErr     error
+		expectedBackups int
+		expectedDeletes int
+	}{
+		{"No table name", "", nil, dynamodb.DeleteGuard{ConfirmArn: arn}, nil, 0, 0},
+		{"Not confirmed", TestTableNameValid, nil, dynamodb.DeleteGuard{}, dynamodb.ErrDeleteNotConfirmed, 0, 0},
+		{"Wrong table ARN", TestTableNameValid, nil, dynamodb.DeleteGuard{ConfirmArn: arn + "-other"}, dynamodb.ErrDeleteNotConfirmed, 0, 0},
+		{"Protected", TestTableNameValid, map[string]string{"deletion-protection": "true"}, dynamodb.DeleteGuard{ConfirmArn: arn}, dynamodb.ErrTableProtected, 0, 0},
+		{"Custom protection tag", TestTableNameValid, map[string]string{"keep": "yes"}, dynamodb.DeleteGuard{ConfirmArn: arn, ProtectionTag: "keep"}, dynamodb.ErrTableProtected, 0, 0},
+		{"Protection turned off", TestTableNameValid, map[string]string{"deletion-protection": "False"}, dynamodb.DeleteGuard{ConfirmArn: arn}, nil, 0, 1},
+		{"Other tags", TestTableNameValid, map[string]string{"keep": "yes"}, dynamodb.DeleteGuard{ConfirmArn: arn}, nil, 0, 1},
+		{"Final backup", TestTableNameValid, nil, dynamodb.DeleteGuard{ConfirmArn: arn, FinalBackup: true}, nil, 1, 1},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Setup backend
+			fake := NewFakeDynamo()
+			defer fake.Server.Close()
+			fake.Handle("DescribeTable", internal.Sequence(tableStatus("ACTIVE"), tableMissing))
+			fake.Handle("ListTagsOfResource", func(input map[string]interface{}) (int, interface{}) {
+				var tags []interface{}
+				for k, v := range test.tags {
+					tags = append(tags, map[string]interface{}{"Key": k, "Value": v})
+				}
+				return http.StatusOK, map[string]interface{}{"Tags": tags}
+			})
+			fake.Handle("CreateBackup", func(input map[string]interface{}) (int, interface{}) {
+				return http.StatusOK, map[string]interface{}{"BackupDetails": map[string]interface{}{"BackupArn": testBackupArn, "BackupStatus": "CREATING"}}
+			})
+			fake.Handle("DescribeBackup", internal.Sequence(backupStatus("AVAILABLE")))
+			fake.Handle("DeleteTable", func(input map[string]interface{}) (int, interface{}) {
+				return http.StatusOK, map[string]interface{}{}
+			})
+
+			// Run the test
+			backupArn, err := dynamodb.SafeDeleteTableWithContext(context.Background(), fake.Session(), test.tableName, test.guard, fastWait)
+			if test.expectedDeletes == 0 {
+				internal.HasError(t, err)
+				if test.expectedErr != nil {
+					internal.Assert(t, errors.Is(err, test.expectedErr), "expected %v, got %v", test.expectedErr, err)
+				}
+			} else {
+				internal.NoError(t, err)
+			}
+			internal.Equals(t, test.expectedBackups, len(fake.Calls("CreateBackup")))
+			internal.Equals(t, test.expectedDeletes, len(fake.Calls("DeleteTable")))
+
+			// The final backup is named after the table
+			if test.expectedBackups > 0 {
+				internal.Equals(t, testBackupArn, backupArn)
+				name := fake.Calls("CreateBackup")[0].Input["BackupName"].(string)
+				internal.Assert(t, strings.HasPrefix(name, TestTableNameValid+"-final-"), "unexpected backup name %s", name)
+			}
+		})
+	}
+}
+
+// Test the generated final backup name fits DynamoDB's limit
+func TestSafeDeleteTableLongName(t *testing.T) {
+
+	// Setup backend
+	tableName := strings.Repeat("t", 255)
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("DescribeTable", internal.Sequence(func() (int, interface{}) {
+		return http.StatusOK, FakeTable(tableName, "ACTIVE")
+	}, tableMissing))
+	fake.Handle("ListTagsOfResource", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{}
+	})
+	fake.Handle("CreateBackup", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{"BackupDetails": map[string]interface{}{"BackupArn": testBackupArn, "BackupStatus": "CREATING"}}
+	})
+	fake.Handle("DescribeBackup", internal.Sequence(backupStatus("AVAILABLE")))
+	fake.Handle("DeleteTable", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{}
+	})
+
+	// Run the test
+	guard := dynamodb.DeleteGuard{ConfirmArn: "arn:aws:dynamodb:us-east-1:123456789012:table/" + tableName, FinalBackup: true}
+	_, err := dynamodb.SafeDeleteTableWithContext(context.Background(), fake.Session(), tableName, guard, fastWait)
+	internal.NoError(t, err)
+	name := fake.Calls("CreateBackup")[0].Input["BackupName"].(string)
+	internal.Equals(t, 255, len(name))
+	internal.Assert(t, strings.Contains(name, "t-final-"), "unexpected backup name %s", name)
+}
@@ -0,0 +1,189 @@
+// This file contains all the bits & pieces related to
+// reading table definitions from YAML or JSON schema files
+
+package dynamodb
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// SchemaFormatJSON - JSON schema files
+	SchemaFormatJSON string = "JSON"
+
+	// SchemaFormatYAML - YAML schema files
+	SchemaFormatYAML string = "YAML"
+)
+
+// IndexSchema - structure used to represent a secondary index in a schema file
+type IndexSchema struct {
+	Name               string   `json:"name" yaml:"name"`
+	PartitionKey       string   `json:"partition_key" yaml:"partition_key"`
+	SortKey            string   `json:"sort_key,omitempty" yaml:"sort_key,omitempty"`
+	Projection         string   `json:"projection,omitempty" yaml:"projection,omitempty"`
+	NonKeyAttributes   []string `json:"non_key_attributes,omitempty" yaml:"non_key_attributes,omitempty"`
+	ReadCapacityUnits  int64    `json:"read_capacity_units,omitempty" yaml:"read_capacity_units,omitempty"`
+	WriteCapacityUnits int64    `json:"write_capacity_units,omitempty" yaml:"write_capacity_units,omitempty"`
+}
+
+// TableSchema - structure used to represent a table in a schema file.
+// Attributes maps each key attribute name to its type (S, N or B). An
+// empty StreamViewType means streams are disabled & an empty TTLAttribute
+// means time to live is disabled.
+//
+//   Example (YAML):
+//     name: services
+//     billing_mode: PAY_PER_REQUEST
+//     attributes:
+//       service: S
+//       owner: S
+//     partition_key: service
+//     global_indexes:
+//       - name: by-owner
+//         partition_key: owner
+//     ttl_attribute: expires
+//     stream_view_type: NEW_IMAGE
+//     tags:
+//       cost-centre: "1234"
+type TableSchema struct {
+	Name               string            `json:"name" yaml:"name"`
+	BillingMode        string            `json:"billing_mode" yaml:"billing_mode"`
+	ReadCapacityUnits  int64             `json:"read_capacity_units,omitempty" yaml:"read_capacity_units,omitempty"`
+	WriteCapacityUnits int64             `json:"write_capacity_units,omitempty" yaml:"write_capacity_units,omitempty"`
+	Attributes         map[string]string `json:"attributes" yaml:"attributes"`
+	PartitionKey       string            `json:"partition_key" yaml:"partition_key"`
+	SortKey            string            `json:"sort_key,omitempty" yaml:"sort_key,omitempty"`
+	GlobalIndexes      []IndexSchema     `json:"global_indexes,omitempty" yaml:"global_indexes,omitempty"`
+	LocalIndexes       []IndexSchema     `json:"local_indexes,omitempty" yaml:"local_indexes,omitempty"`
+	TTLAttribute       string            `json:"ttl_attribute,omitempty" yaml:"ttl_attribute,omitempty"`
+	StreamViewType     string            `json:"stream_view_type,omitempty" yaml:"stream_view_type,omitempty"`
+	Tags               map[string]string `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// LoadTableSchema - This function reads & validates a table schema file. The
+// format is taken from the file extension (.json, .yaml or .yml).
+//
+//   Parameters:
+//     path: the path of the schema file
+//
+//   Example:
+//     schema, err := LoadTableSchema("tables/services.yaml")
+func LoadTableSchema(path string) (TableSchema, error) {
+
+	// Work out the format
+	var format string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		format = SchemaFormatJSON
+	case ".yaml", ".yml":
+		format = SchemaFormatYAML
+	default:
+		return TableSchema{}, newErrorSchemaFormatNotSupported(filepath.Ext(path))
+	}
+
+	// Read it
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return TableSchema{}, err
+	}
+	return ParseTableSchema(data, format)
+}
+
+// ParseTableSchema - This function parses & validates a table schema
+//
+//   Parameters:
+//     data: the contents of the schema
+//     format: the format of the schema (JSON or YAML)
+//
+//   Example:
+//     schema, err := ParseTableSchema(data, SchemaFormatYAML)
+func ParseTableSchema(data []byte, format string) (TableSchema, error) {
+
+	// Parse it
+	var schema TableSchema
+	var err error
+	switch strings.ToUpper(format) {
+	case SchemaFormatJSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&schema)
+	case SchemaFormatYAML, "YML":
+		err = yaml.UnmarshalStrict(data, &schema)
+	default:
+		return schema, newErrorSchemaFormatNotSupported(format)
+	}
+	if err != nil {
+		return schema, err
+	}
+
+	// Validate it
+	err = schema.Validate()
+	return schema, err
+}
+
+// Validate - This function checks the schema is complete & consistent
+//
+//   Example:
+//     err := schema.Validate()
+func (s TableSchema) Validate() error {
+
+	return validateTableSpec(s.Spec())
+}
+
+// Spec - This function converts the schema to a table spec
+//
+//   Example:
+//     spec := schema.Spec()
+func (s TableSchema) Spec() TableSpec {
+
+	spec := TableSpec{
+		TableName:          s.Name,
+		BillingMode:        s.BillingMode,
+		ReadCapacityUnits:  s.ReadCapacityUnits,
+		WriteCapacityUnits: s.WriteCapacityUnits,
+		Keys:               KeySchema{PartitionKey: s.PartitionKey, SortKey: s.SortKey},
+		TableOptions:       TableOptions{TTLAttribute: s.TTLAttribute, Tags: s.Tags},
+	}
+	if s.StreamViewType != "" {
+		spec.Stream = &StreamSpec{Enabled: true, ViewType: s.StreamViewType}
+	}
+
+	// Sort the attributes so the spec is repeatable
+	var names []string
+	for n := range s.Attributes {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		spec.Attributes = append(spec.Attributes, AttributeDef{Name: n, Type: s.Attributes[n]})
+	}
+
+	// Add the indexes
+	for _, idx := range s.GlobalIndexes {
+		spec.GlobalIndexes = append(spec.GlobalIndexes, idx.spec())
+	}
+	for _, idx := range s.LocalIndexes {
+		spec.LocalIndexes = append(spec.LocalIndexes, idx.spec())
+	}
+	return spec
+}
+
+// spec converts the index schema to an index spec
+func (i IndexSchema) spec() IndexSpec {
+
+	return IndexSpec{
+		IndexName:          i.Name,
+		Keys:               KeySchema{PartitionKey: i.PartitionKey, SortKey: i.SortKey},
+		ProjectionType:     i.Projection,
+		NonKeyAttributes:   i.NonKeyAttributes,
+		ReadCapacityUnits:  i.ReadCapacityUnits,
+		WriteCapacityUnits: i.WriteCapacityUnits,
+	}
+}
@@ -0,0 +1,133 @@
+package dynamodb_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/bradmccoydev/self-service-sdk/internal"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/dynamodb"
+)
+
+// testSchemaYAML describes the testing table with a global index, TTL, a stream & tags
+const testSchemaYAML = `
+name: testing
+billing_mode: PAY_PER_REQUEST
+attributes:
+  service: S
+  owner: S
+partition_key: service
+global_indexes:
+  - name: by-owner
+    partition_key: owner
+ttl_attribute: expires
+stream_view_type: NEW_IMAGE
+tags:
+  cost-centre: "1234"
+`
+
+// testSchemaJSON is the JSON version of testSchemaYAML
+const testSchemaJSON = `{
+  "name": "testing",
+  "billing_mode": "PAY_PER_REQUEST",
+  "attributes": {"service": "S", "owner": "S"},
+  "partition_key": "service",
+  "global_indexes": [{"name": "by-owner", "partition_key": "owner"}],
+  "ttl_attribute": "expires",
+  "stream_view_type": "NEW_IMAGE",
+  "tags": {"cost-centre": "1234"}
+}`
+
+// newTestSchema returns the schema described by testSchemaYAML
+func newTestSchema() dynamodb.TableSchema {
+
+	return dynamodb.TableSchema{
+		Name:           TestTableNameValid,
+		BillingMode:    dynamodb.BillingModePayPerRequest,
+		Attributes:     map[string]string{"service": "S", "owner": "S"},
+		PartitionKey:   "service",
+		GlobalIndexes:  []dynamodb.IndexSchema{{Name: "by-owner", PartitionKey: "owner"}},
+		TTLAttribute:   "expires",
+		StreamViewType: "NEW_IMAGE",
+		Tags:           map[string]string{"cost-centre": "1234"},
+	}
+}
+
+// Test ParseTableSchema
+func TestParseTableSchema(t *testing.T) {
+
+	// Setup test data
+	tests := []struct {
+		desc      string
+		data      string
+		format    string
+		expectErr bool
+	}{
+		{"YAML", testSchemaYAML, dynamodb.SchemaFormatYAML, false},
+		{"JSON", testSchemaJSON, dynamodb.SchemaFormatJSON, false},
+		{"Lower case format", testSchemaYAML, "yml", false},
+		{"Unsupported format", testSchemaYAML, "toml", true},
+		{"Malformed YAML", "name: [testing", dynamodb.SchemaFormatYAML, true},
+		{"Unknown YAML field", testSchemaYAML + "garbage: true\n", dynamodb.SchemaFormatYAML, true},
+		{"Unknown JSON field", `{"name": "testing", "garbage": true}`, dynamodb.SchemaFormatJSON, true},
+		{"Invalid table", "name: testing\nbilling_mode: PAY_PER_REQUEST\n", dynamodb.SchemaFormatYAML, true},
+		{"Invalid stream view type", testSchemaYAML + "stream_view_type: garbage\n", dynamodb.SchemaFormatYAML, true},
+		{"Empty tag key", testSchemaJSON[:len(testSchemaJSON)-1] + `, "tags": {"": "x"}}`, dynamodb.SchemaFormatJSON, true},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Run the test
+			schema, err := dynamodb.ParseTableSchema([]byte(test.data), test.format)
+			if test.expectErr {
+				internal.HasError(t, err)
+			} else {
+				internal.NoError(t, err)
+				internal.Equals(t, newTestSchema(), schema)
+			}
+		})
+	}
+}
+
+// Test LoadTableSchema
+func TestLoadTableSchema(t *testing.T) {
+
+	// Setup test data
+	dir := t.TempDir()
+	tests := []struct {
+		desc      string
+		file      string
+		data      string
+		expectErr bool
+	}{
+		{"YAML file", "services.yaml", testSchemaYAML, false},
+		{"YML file", "services.yml", testSchemaYAML, false},
+		{"JSON file", "services.json", testSchemaJSON, false},
+		{"Unsupported extension", "services.txt", testSchemaYAML, true},
+		{"Missing file", "", "", true},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Run the test
+			path := filepath.Join(dir, "missing.yaml")
+			if test.file != "" {
+				path = filepath.Join(dir, test.file)
+				internal.NoError(t, ioutil.WriteFile(path, []byte(test.data), 0600))
+			}
+			schema, err := dynamodb.LoadTableSchema(path)
+			if test.expectErr {
+				internal.HasError(t, err)
+			} else {
+				internal.NoError(t, err)
+				internal.Equals(t, newTestSchema(), schema)
+			}
+		})
+	}
+}
@@ -0,0 +1,340 @@
+// This file contains all the bits & pieces related to
+// evolving an existing table (billing, throughput, global
+// indexes & streams) from a declarative change set
+
+package dynamodb
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/clients"
+)
+
+// StreamSpec - structure used to represent the stream settings of a table.
+// ViewType must be one of KEYS_ONLY, NEW_IMAGE, OLD_IMAGE or NEW_AND_OLD_IMAGES
+// when the stream is enabled.
+type StreamSpec struct {
+	Enabled  bool
+	ViewType string
+}
+
+// IndexThroughput - structure used to represent new capacity units for an
+// existing global secondary index
+type IndexThroughput struct {
+	IndexName          string
+	ReadCapacityUnits  int64
+	WriteCapacityUnits int64
+}
+
+// TableChanges - structure used to represent the changes to make to a table.
+// Empty values are left unchanged. Attributes only needs to define the keys
+// of new global indexes that the table doesn't already define.
+type TableChanges struct {
+	BillingMode         string
+	ReadCapacityUnits   int64
+	WriteCapacityUnits  int64
+	Attributes          []AttributeDef
+	AddGlobalIndexes    []IndexSpec
+	UpdateGlobalIndexes []IndexThroughput
+	DeleteGlobalIndexes []string
+	Stream              *StreamSpec
+}
+
+// UpdateTable - This function applies the changes to an existing table &
+// waits (using the default WaitConf) until the table is ACTIVE again
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     tableName: the name of the table to update
+//     changes: the changes to make
+//
+//   Example:
+//     err := UpdateTable(mySession, "fred", TableChanges{DeleteGlobalIndexes: []string{"by-owner"}})
+func UpdateTable(sess *session.Session, tableName string, changes TableChanges) error {
+	return UpdateTableWithContext(context.Background(), sess, tableName, changes, WaitConf{})
+}
+
+// UpdateTableWithContext - This function applies the changes to an existing
+// table. The changes are validated against the current table definition
+// then applied one step at a time (DynamoDB only allows one index to be
+// created or deleted per update), waiting for the table & its indexes to
+// be ACTIVE after each step.
+//
+//   Parameters:
+//     ctx: the context used to cancel the requests & waits
+//     sess: a valid AWS session
+//     tableName: the name of the table to update
+//     changes: the changes to make
+//     wait: the timeout & backoff to use while waiting
+//
+//   Example:
+//     err := UpdateTableWithContext(ctx, mySession, "fred", changes, WaitConf{})
+func UpdateTableWithContext(ctx context.Context, sess *session.Session, tableName string, changes TableChanges, wait WaitConf) error {
+
+	// Sanity check
+	if tableName == "" {
+		return newErrorTableNameNotProvided()
+	}
+
+	// Get the current table definition
+	svc := clients.DynamoDB(sess)
+	result, err := svc.DescribeTableWithContext(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(tableName)})
+	if err != nil {
+		return err
+	}
+	if result.Table == nil {
+		return newErrorTableDetailsNotProvided()
+	}
+
+	// Work out the steps
+	steps, err := planTableUpdate(result.Table, changes)
+	if err != nil {
+		return err
+	}
+
+	// Apply them one at a time
+	for _, params := range steps {
+		_, err = svc.UpdateTableWithContext(ctx, params)
+		if err != nil {
+			return err
+		}
+		err = WaitUntilTableActive(ctx, sess, tableName, wait)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// planTableUpdate validates the changes against the table & breaks them
+// down into the update requests needed to apply them
+func planTableUpdate(table *dynamodb.TableDescription, changes TableChanges) ([]*dynamodb.UpdateTableInput, error) {
+
+	// The table must be stable before changing it
+	name := aws.StringValue(table.TableName)
+	if aws.StringValue(table.TableStatus) != dynamodb.TableStatusActive {
+		return nil, newErrorTableNotActive(name, aws.StringValue(table.TableStatus))
+	}
+
+	// Work out the current state
+	currentMode := BillingModeProvisioned
+	if table.BillingModeSummary != nil && aws.StringValue(table.BillingModeSummary.BillingMode) != "" {
+		currentMode = aws.StringValue(table.BillingModeSummary.BillingMode)
+	}
+	indexes := make(map[string]bool)
+	indexThroughput := make(map[string]*dynamodb.ProvisionedThroughputDescription)
+	for _, idx := range table.GlobalSecondaryIndexes {
+		indexes[aws.StringValue(idx.IndexName)] = true
+		indexThroughput[aws.StringValue(idx.IndexName)] = idx.ProvisionedThroughput
+	}
+	deleting := make(map[string]bool)
+	for _, n := range changes.DeleteGlobalIndexes {
+		if !indexes[n] {
+			return nil, newErrorIndexNotFound(name, n)
+		}
+		if deleting[n] {
+			return nil, newErrorIndexNameDuplicated(n)
+		}
+		deleting[n] = true
+	}
+
+	// Work out the target billing mode
+	targetMode := currentMode
+	if changes.BillingMode != "" {
+		targetMode = strings.ToUpper(changes.BillingMode)
+		if targetMode != BillingModeProvisioned && targetMode != BillingModePayPerRequest {
+			return nil, newErrorBillingModeInvalid(changes.BillingMode)
+		}
+	}
+	provisioned := targetMode == BillingModeProvisioned
+
+	// Step 1: delete indexes, one per update, so they don't
+	// need capacity if the billing mode is changing
+	var steps []*dynamodb.UpdateTableInput
+	for _, n := range changes.DeleteGlobalIndexes {
+		steps = append(steps, &dynamodb.UpdateTableInput{
+			TableName: table.TableName,
+			GlobalSecondaryIndexUpdates: []*dynamodb.GlobalSecondaryIndexUpdate{{
+				Delete: &dynamodb.DeleteGlobalSecondaryIndexAction{IndexName: aws.String(n)},
+			}},
+		})
+	}
+
+	// Step 2: billing mode & throughput, leaving out any capacity that
+	// already matches unless the billing mode is changing
+	switching := targetMode != currentMode
+	capacity := make(map[string]IndexThroughput)
+	var indexUpdates []*dynamodb.GlobalSecondaryIndexUpdate
+	for _, u := range changes.UpdateGlobalIndexes {
+		if !indexes[u.IndexName] || deleting[u.IndexName] {
+			return nil, newErrorIndexNotFound(name, u.IndexName)
+		}
+		if !provisioned {
+			return nil, newErrorCapacityNotAllowed(u.IndexName)
+		}
+		if u.ReadCapacityUnits < 1 || u.WriteCapacityUnits < 1 {
+			return nil, newErrorCapacityUnitsNotProvided(u.IndexName)
+		}
+		capacity[u.IndexName] = u
+		if switching || !sameThroughput(indexThroughput[u.IndexName], u.ReadCapacityUnits, u.WriteCapacityUnits) {
+			indexUpdates = append(indexUpdates, &dynamodb.GlobalSecondaryIndexUpdate{
+				Update: &dynamodb.UpdateGlobalSecondaryIndexAction{
+					IndexName:             aws.String(u.IndexName),
+					ProvisionedThroughput: newThroughput(u.ReadCapacityUnits, u.WriteCapacityUnits),
+				},
+			})
+		}
+	}
+	throughputGiven := changes.ReadCapacityUnits != 0 || changes.WriteCapacityUnits != 0
+	if throughputGiven && !provisioned {
+		return nil, newErrorCapacityNotAllowed(name)
+	}
+	if throughputGiven && (changes.ReadCapacityUnits < 1 || changes.WriteCapacityUnits < 1) {
+		return nil, newErrorCapacityUnitsNotProvided(name)
+	}
+	throughputChanged := throughputGiven && (switching || !sameThroughput(table.ProvisionedThroughput, changes.ReadCapacityUnits, changes.WriteCapacityUnits))
+	if switching || throughputChanged || len(indexUpdates) > 0 {
+		params := &dynamodb.UpdateTableInput{TableName: table.TableName}
+		if switching {
+			params.BillingMode = aws.String(targetMode)
+
+			// Switching to provisioned needs capacity for the table & every remaining index
+			if provisioned && !throughputGiven {
+				return nil, newErrorCapacityUnitsNotProvided(name)
+			}
+			if provisioned {
+				for n := range indexes {
+					if _, ok := capacity[n]; !ok && !deleting[n] {
+						return nil, newErrorCapacityUnitsNotProvided(n)
+					}
+				}
+			}
+		}
+		if throughputChanged {
+			params.ProvisionedThroughput = newThroughput(changes.ReadCapacityUnits, changes.WriteCapacityUnits)
+		}
+		params.GlobalSecondaryIndexUpdates = indexUpdates
+		steps = append(steps, params)
+	}
+
+	// Step 3: streams
+	if changes.Stream != nil {
+		current := table.StreamSpecification
+		enabled := current != nil && aws.BoolValue(current.StreamEnabled)
+		switch {
+		case changes.Stream.Enabled && !enabled:
+			if !validStreamViewType(changes.Stream.ViewType) {
+				return nil, newErrorStreamViewTypeInvalid(changes.Stream.ViewType)
+			}
+			steps = append(steps, &dynamodb.UpdateTableInput{
+				TableName: table.TableName,
+				StreamSpecification: &dynamodb.StreamSpecification{
+					StreamEnabled:  aws.Bool(true),
+					StreamViewType: aws.String(strings.ToUpper(changes.Stream.ViewType)),
+				},
+			})
+		case changes.Stream.Enabled && enabled:
+			if !strings.EqualFold(changes.Stream.ViewType, aws.StringValue(current.StreamViewType)) {
+				return nil, newErrorStreamViewTypeChange(name, aws.StringValue(current.StreamViewType))
+			}
+		case !changes.Stream.Enabled && enabled:
+			steps = append(steps, &dynamodb.UpdateTableInput{
+				TableName:           table.TableName,
+				StreamSpecification: &dynamodb.StreamSpecification{StreamEnabled: aws.Bool(false)},
+			})
+		}
+	}
+
+	// Step 4: create indexes, one per update
+	defined := make(map[string]string)
+	for _, a := range table.AttributeDefinitions {
+		defined[aws.StringValue(a.AttributeName)] = aws.StringValue(a.AttributeType)
+	}
+	for _, a := range changes.Attributes {
+		if a.Name == "" {
+			return nil, newErrorTableAttributeNameNotProvided()
+		}
+		if a.Type == "" {
+			return nil, newErrorTableAttributeTypeNotProvided()
+		}
+		if t, ok := defined[a.Name]; ok && t != a.Type {
+			return nil, newErrorTableAttributeTypeConflict(a.Name, t)
+		}
+		defined[a.Name] = a.Type
+	}
+	names := make(map[string]bool)
+	for n := range indexes {
+		if !deleting[n] {
+			names[n] = true
+		}
+	}
+	for _, idx := range table.LocalSecondaryIndexes {
+		names[aws.StringValue(idx.IndexName)] = true
+	}
+	known := make(map[string]bool)
+	for n := range defined {
+		known[n] = true
+	}
+	for _, idx := range changes.AddGlobalIndexes {
+		used := make(map[string]bool)
+		err := checkIndex(idx, known, used, names)
+		if err != nil {
+			return nil, err
+		}
+		if provisioned && (idx.ReadCapacityUnits < 1 || idx.WriteCapacityUnits < 1) {
+			return nil, newErrorCapacityUnitsNotProvided(idx.IndexName)
+		}
+		if !provisioned && (idx.ReadCapacityUnits != 0 || idx.WriteCapacityUnits != 0) {
+			return nil, newErrorCapacityNotAllowed(idx.IndexName)
+		}
+		action := &dynamodb.CreateGlobalSecondaryIndexAction{
+			IndexName:  aws.String(idx.IndexName),
+			KeySchema:  newKeySchema(idx.Keys),
+			Projection: newProjection(idx),
+		}
+		if provisioned {
+			action.ProvisionedThroughput = newThroughput(idx.ReadCapacityUnits, idx.WriteCapacityUnits)
+		}
+		params := &dynamodb.UpdateTableInput{
+			TableName:                   table.TableName,
+			GlobalSecondaryIndexUpdates: []*dynamodb.GlobalSecondaryIndexUpdate{{Create: action}},
+		}
+		for n := range used {
+			params.AttributeDefinitions = append(params.AttributeDefinitions, &dynamodb.AttributeDefinition{
+				AttributeName: aws.String(n),
+				AttributeType: aws.String(defined[n]),
+			})
+		}
+		sort.Slice(params.AttributeDefinitions, func(i, j int) bool {
+			return aws.StringValue(params.AttributeDefinitions[i].AttributeName) < aws.StringValue(params.AttributeDefinitions[j].AttributeName)
+		})
+		steps = append(steps, params)
+	}
+
+	// Return them
+	return steps, nil
+}
+
+// sameThroughput checks if the described capacity already matches
+func sameThroughput(current *dynamodb.ProvisionedThroughputDescription, read int64, write int64) bool {
+
+	return current != nil &&
+		aws.Int64Value(current.ReadCapacityUnits) == read &&
+		aws.Int64Value(current.WriteCapacityUnits) == write
+}
+
+// validStreamViewType checks the stream view type is one DynamoDB supports
+func validStreamViewType(viewType string) bool {
+
+	for _, v := range dynamodb.StreamViewType_Values() {
+		if strings.EqualFold(v, viewType) {
+			return true
+		}
+	}
+	return false
+}
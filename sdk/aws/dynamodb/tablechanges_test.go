@@ -0,0 +1,205 @@
+package dynamodb_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/bradmccoydev/self-service-sdk/internal"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/dynamodb"
+)
+
+// describedTable builds a DescribeTable response for an on-demand table with
+// a stream & two global indexes
+func describedTable(status string) map[string]interface{} {
+
+	return map[string]interface{}{"Table": map[string]interface{}{
+		"TableName":          TestTableNameValid,
+		"TableStatus":        status,
+		"BillingModeSummary": map[string]interface{}{"BillingMode": dynamodb.BillingModePayPerRequest},
+		"AttributeDefinitions": []interface{}{
+			map[string]interface{}{"AttributeName": "service", "AttributeType": "S"},
+			map[string]interface{}{"AttributeName": "owner", "AttributeType": "S"},
+			map[string]interface{}{"AttributeName": "old", "AttributeType": "S"},
+		},
+		"GlobalSecondaryIndexes": []interface{}{
+			map[string]interface{}{"IndexName": "by-owner", "IndexStatus": "ACTIVE"},
+			map[string]interface{}{"IndexName": "by-old", "IndexStatus": "ACTIVE"},
+		},
+		"StreamSpecification": map[string]interface{}{"StreamEnabled": true, "StreamViewType": "KEYS_ONLY"},
+	}}
+}
+
+// Test UpdateTable validation
+func TestUpdateTableValidation(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("UpdateTable", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{}
+	})
+
+	// Setup test data
+	byStatus := dynamodb.IndexSpec{IndexName: "by-status", Keys: dynamodb.KeySchema{PartitionKey: "status"}}
+	tests := []struct {
+		desc        string
+		tableName   string
+		tableStatus string
+		changes     dynamodb.TableChanges
+		expectErr   bool
+	}{
+		{"No table name", "", "ACTIVE", dynamodb.TableChanges{}, true},
+		{"Table not active", TestTableNameValid, "UPDATING", dynamodb.TableChanges{DeleteGlobalIndexes: []string{"by-old"}}, true},
+		{"Delete missing index", TestTableNameValid, "ACTIVE", dynamodb.TableChanges{DeleteGlobalIndexes: []string{"garbage"}}, true},
+		{"Delete index twice", TestTableNameValid, "ACTIVE", dynamodb.TableChanges{DeleteGlobalIndexes: []string{"by-old", "by-old"}}, true},
+		{"Invalid billing mode", TestTableNameValid, "ACTIVE", dynamodb.TableChanges{BillingMode: "garbage"}, true},
+		{"Throughput on an on-demand table", TestTableNameValid, "ACTIVE", dynamodb.TableChanges{ReadCapacityUnits: 5, WriteCapacityUnits: 5}, true},
+		{"Provisioned without table capacity", TestTableNameValid, "ACTIVE", dynamodb.TableChanges{BillingMode: "PROVISIONED"}, true},
+		{"Provisioned without index capacity", TestTableNameValid, "ACTIVE", dynamodb.TableChanges{BillingMode: "PROVISIONED", ReadCapacityUnits: 5, WriteCapacityUnits: 5}, true},
+		{"Update deleted index", TestTableNameValid, "ACTIVE", dynamodb.TableChanges{BillingMode: "PROVISIONED", ReadCapacityUnits: 5, WriteCapacityUnits: 5, DeleteGlobalIndexes: []string{"by-old"}, UpdateGlobalIndexes: []dynamodb.IndexThroughput{{IndexName: "by-old", ReadCapacityUnits: 1, WriteCapacityUnits: 1}}}, true},
+		{"Change stream view type", TestTableNameValid, "ACTIVE", dynamodb.TableChanges{Stream: &dynamodb.StreamSpec{Enabled: true, ViewType: "NEW_IMAGE"}}, true},
+		{"Attribute type conflict", TestTableNameValid, "ACTIVE", dynamodb.TableChanges{Attributes: []dynamodb.AttributeDef{{Name: "owner", Type: "N"}}}, true},
+		{"New index key not defined", TestTableNameValid, "ACTIVE", dynamodb.TableChanges{AddGlobalIndexes: []dynamodb.IndexSpec{byStatus}}, true},
+		{"New index name in use", TestTableNameValid, "ACTIVE", dynamodb.TableChanges{AddGlobalIndexes: []dynamodb.IndexSpec{{IndexName: "by-owner", Keys: dynamodb.KeySchema{PartitionKey: "owner"}}}}, true},
+		{"New index with capacity on an on-demand table", TestTableNameValid, "ACTIVE", dynamodb.TableChanges{AddGlobalIndexes: []dynamodb.IndexSpec{{IndexName: "by-service", Keys: dynamodb.KeySchema{PartitionKey: "service"}, ReadCapacityUnits: 1, WriteCapacityUnits: 1}}}, true},
+		{"Nothing to change", TestTableNameValid, "ACTIVE", dynamodb.TableChanges{Stream: &dynamodb.StreamSpec{Enabled: true, ViewType: "keys_only"}}, false},
+		{"Re-use the name of a deleted index", TestTableNameValid, "ACTIVE", dynamodb.TableChanges{DeleteGlobalIndexes: []string{"by-old"}, AddGlobalIndexes: []dynamodb.IndexSpec{{IndexName: "by-old", Keys: dynamodb.KeySchema{PartitionKey: "old"}}}}, false},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Run the test
+			fake.Handle("DescribeTable", func(input map[string]interface{}) (int, interface{}) {
+				return http.StatusOK, describedTable(test.tableStatus)
+			})
+			before := len(fake.Calls("UpdateTable"))
+			err := dynamodb.UpdateTableWithContext(context.Background(), fake.Session(), test.tableName, test.changes, fastWait)
+			if test.expectErr {
+				internal.HasError(t, err)
+				internal.Equals(t, before, len(fake.Calls("UpdateTable")))
+			} else {
+				internal.NoError(t, err)
+			}
+		})
+	}
+}
+
+// Test UpdateTable applies the changes one step at a time
+func TestUpdateTable(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("DescribeTable", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, describedTable("ACTIVE")
+	})
+	fake.Handle("UpdateTable", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{}
+	})
+
+	// Apply a full change set
+	changes := dynamodb.TableChanges{
+		BillingMode:         "provisioned",
+		ReadCapacityUnits:   10,
+		WriteCapacityUnits:  5,
+		Attributes:          []dynamodb.AttributeDef{{Name: "status", Type: "S"}, {Name: "service", Type: "S"}},
+		UpdateGlobalIndexes: []dynamodb.IndexThroughput{{IndexName: "by-owner", ReadCapacityUnits: 2, WriteCapacityUnits: 1}},
+		DeleteGlobalIndexes: []string{"by-old"},
+		AddGlobalIndexes:    []dynamodb.IndexSpec{{IndexName: "by-status", Keys: dynamodb.KeySchema{PartitionKey: "status", SortKey: "service"}, ReadCapacityUnits: 1, WriteCapacityUnits: 1}},
+		Stream:              &dynamodb.StreamSpec{Enabled: false},
+	}
+	err := dynamodb.UpdateTableWithContext(context.Background(), fake.Session(), TestTableNameValid, changes, fastWait)
+	internal.NoError(t, err)
+
+	// There should be a describe, then an update & wait for each step
+	calls := fake.Calls("UpdateTable")
+	internal.Equals(t, 4, len(calls))
+	internal.Equals(t, 5, len(fake.Calls("DescribeTable")))
+
+	// Delete the old index first
+	internal.Equals(t, []interface{}{map[string]interface{}{"Delete": map[string]interface{}{"IndexName": "by-old"}}}, calls[0].Input["GlobalSecondaryIndexUpdates"])
+
+	// Then switch billing mode
+	internal.Equals(t, "PROVISIONED", calls[1].Input["BillingMode"])
+	internal.Equals(t, map[string]interface{}{"ReadCapacityUnits": float64(10), "WriteCapacityUnits": float64(5)}, calls[1].Input["ProvisionedThroughput"])
+	internal.Equals(t, 1, len(calls[1].Input["GlobalSecondaryIndexUpdates"].([]interface{})))
+
+	// Then disable the stream
+	internal.Equals(t, map[string]interface{}{"StreamEnabled": false}, calls[2].Input["StreamSpecification"])
+
+	// Then create the new index
+	internal.Equals(t, []interface{}{
+		map[string]interface{}{"AttributeName": "service", "AttributeType": "S"},
+		map[string]interface{}{"AttributeName": "status", "AttributeType": "S"},
+	}, calls[3].Input["AttributeDefinitions"])
+	create := calls[3].Input["GlobalSecondaryIndexUpdates"].([]interface{})[0].(map[string]interface{})["Create"].(map[string]interface{})
+	internal.Equals(t, "by-status", create["IndexName"])
+	internal.Equals(t, map[string]interface{}{"ProjectionType": "ALL"}, create["Projection"])
+	internal.Equals(t, map[string]interface{}{"ReadCapacityUnits": float64(1), "WriteCapacityUnits": float64(1)}, create["ProvisionedThroughput"])
+
+	// A failed update stops the remaining steps
+	fake.Handle("UpdateTable", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusBadRequest, FakeError("LimitExceededException", "too many updates")
+	})
+	err = dynamodb.UpdateTableWithContext(context.Background(), fake.Session(), TestTableNameValid, changes, fastWait)
+	internal.HasError(t, err)
+	internal.Equals(t, 5, len(fake.Calls("UpdateTable")))
+}
+
+// Test UpdateTable leaves out throughput that already matches
+func TestUpdateTableThroughput(t *testing.T) {
+
+	// Setup backend with a provisioned table
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("DescribeTable", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{"Table": map[string]interface{}{
+			"TableName":             TestTableNameValid,
+			"TableStatus":           "ACTIVE",
+			"ProvisionedThroughput": map[string]interface{}{"ReadCapacityUnits": 10, "WriteCapacityUnits": 5},
+			"GlobalSecondaryIndexes": []interface{}{
+				map[string]interface{}{"IndexName": "by-owner", "IndexStatus": "ACTIVE", "ProvisionedThroughput": map[string]interface{}{"ReadCapacityUnits": 2, "WriteCapacityUnits": 1}},
+				map[string]interface{}{"IndexName": "by-old", "IndexStatus": "ACTIVE", "ProvisionedThroughput": map[string]interface{}{"ReadCapacityUnits": 1, "WriteCapacityUnits": 1}},
+			},
+		}}
+	})
+	fake.Handle("UpdateTable", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{}
+	})
+
+	// Nothing is sent when the capacity already matches
+	changes := dynamodb.TableChanges{
+		ReadCapacityUnits:   10,
+		WriteCapacityUnits:  5,
+		UpdateGlobalIndexes: []dynamodb.IndexThroughput{{IndexName: "by-owner", ReadCapacityUnits: 2, WriteCapacityUnits: 1}},
+	}
+	err := dynamodb.UpdateTableWithContext(context.Background(), fake.Session(), TestTableNameValid, changes, fastWait)
+	internal.NoError(t, err)
+	internal.Equals(t, 0, len(fake.Calls("UpdateTable")))
+
+	// Only the index that changed is sent
+	changes.UpdateGlobalIndexes = append(changes.UpdateGlobalIndexes, dynamodb.IndexThroughput{IndexName: "by-old", ReadCapacityUnits: 3, WriteCapacityUnits: 1})
+	err = dynamodb.UpdateTableWithContext(context.Background(), fake.Session(), TestTableNameValid, changes, fastWait)
+	internal.NoError(t, err)
+	calls := fake.Calls("UpdateTable")
+	internal.Equals(t, 1, len(calls))
+	internal.Equals(t, nil, calls[0].Input["ProvisionedThroughput"])
+	internal.Equals(t, []interface{}{map[string]interface{}{"Update": map[string]interface{}{
+		"IndexName":             "by-old",
+		"ProvisionedThroughput": map[string]interface{}{"ReadCapacityUnits": float64(3), "WriteCapacityUnits": float64(1)},
+	}}}, calls[0].Input["GlobalSecondaryIndexUpdates"])
+
+	// Only the table capacity is sent when the indexes match
+	changes.UpdateGlobalIndexes = changes.UpdateGlobalIndexes[:1]
+	changes.WriteCapacityUnits = 6
+	err = dynamodb.UpdateTableWithContext(context.Background(), fake.Session(), TestTableNameValid, changes, fastWait)
+	internal.NoError(t, err)
+	calls = fake.Calls("UpdateTable")
+	internal.Equals(t, 2, len(calls))
+	internal.Equals(t, map[string]interface{}{"ReadCapacityUnits": float64(10), "WriteCapacityUnits": float64(6)}, calls[1].Input["ProvisionedThroughput"])
+	internal.Equals(t, nil, calls[1].Input["GlobalSecondaryIndexUpdates"])
+}
@@ -0,0 +1,93 @@
+// This file contains all the bits & pieces related to
+// the optional table settings (time to live, streams,
+// encryption & tags) applied when a table is created
+
+package dynamodb
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// EncryptionSpec - structure used to represent KMS encryption at rest. An
+// empty KMSKeyID uses the AWS managed key (alias/aws/dynamodb).
+type EncryptionSpec struct {
+	KMSKeyID string
+}
+
+// TableOptions - structure used to represent the optional settings of a new
+// table. An empty TTLAttribute means time to live is disabled & a nil
+// Encryption means the table uses the default AWS owned key.
+type TableOptions struct {
+	TTLAttribute string
+	Stream       *StreamSpec
+	Encryption   *EncryptionSpec
+	Tags         map[string]string
+}
+
+// validateTableOptions checks the options are complete & consistent
+func validateTableOptions(opts TableOptions) error {
+
+	if opts.Stream != nil && opts.Stream.Enabled && !validStreamViewType(opts.Stream.ViewType) {
+		return newErrorStreamViewTypeInvalid(opts.Stream.ViewType)
+	}
+	for k := range opts.Tags {
+		if k == "" {
+			return newErrorTagKeyNotProvided()
+		}
+	}
+	return nil
+}
+
+// addTableOptions adds the options DynamoDB accepts at creation to the params
+func addTableOptions(params *dynamodb.CreateTableInput, opts TableOptions) {
+
+	if opts.Stream != nil && opts.Stream.Enabled {
+		params.StreamSpecification = &dynamodb.StreamSpecification{
+			StreamEnabled:  aws.Bool(true),
+			StreamViewType: aws.String(strings.ToUpper(opts.Stream.ViewType)),
+		}
+	}
+	if opts.Encryption != nil {
+		params.SSESpecification = &dynamodb.SSESpecification{
+			Enabled: aws.Bool(true),
+			SSEType: aws.String(dynamodb.SSETypeKms),
+		}
+		if opts.Encryption.KMSKeyID != "" {
+			params.SSESpecification.KMSMasterKeyId = aws.String(opts.Encryption.KMSKeyID)
+		}
+	}
+	if len(opts.Tags) > 0 {
+		params.Tags = newTags(opts.Tags)
+	}
+}
+
+// enableTimeToLive turns on time to live for an ACTIVE table
+func enableTimeToLive(ctx context.Context, svc *dynamodb.DynamoDB, tableName string, attribute string) error {
+
+	_, err := svc.UpdateTimeToLiveWithContext(ctx, &dynamodb.UpdateTimeToLiveInput{
+		TableName: aws.String(tableName),
+		TimeToLiveSpecification: &dynamodb.TimeToLiveSpecification{
+			Enabled:       aws.Bool(true),
+			AttributeName: aws.String(attribute),
+		},
+	})
+	return err
+}
+
+// newTags converts a map of tags to DynamoDB tags, sorted by key
+func newTags(tags map[string]string) []*dynamodb.Tag {
+
+	var result []*dynamodb.Tag
+	for k, v := range tags {
+		result = append(result, &dynamodb.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return aws.StringValue(result[i].Key) < aws.StringValue(result[j].Key)
+	})
+	return result
+}
@@ -0,0 +1,122 @@
+package dynamodb_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/bradmccoydev/self-service-sdk/internal"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/dynamodb"
+)
+
+// Test the table options are applied on creation
+func TestCreateTableWithOptions(t *testing.T) {
+
+	// Setup test data
+	options := dynamodb.TableOptions{
+		TTLAttribute: "expires",
+		Stream:       &dynamodb.StreamSpec{Enabled: true, ViewType: "new_image"},
+		Encryption:   &dynamodb.EncryptionSpec{KMSKeyID: "alias/tables"},
+		Tags:         map[string]string{"team": "platform", "cost-centre": "1234"},
+	}
+	conf := TestTableConf
+	conf.TableOptions = options
+	spec := newTestTableSpec()
+	spec.TableOptions = options
+
+	tests := []struct {
+		desc   string
+		create func(fake *FakeDynamo) error
+	}{
+		{"CreateTable", func(fake *FakeDynamo) error {
+			return dynamodb.CreateTableWithContext(context.Background(), fake.Session(), conf, TestTableAttribs, fastWait)
+		}},
+		{"CreateTableFromSpec", func(fake *FakeDynamo) error {
+			return dynamodb.CreateTableFromSpecWithContext(context.Background(), fake.Session(), spec, fastWait)
+		}},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Setup backend
+			fake := NewFakeDynamo()
+			defer fake.Server.Close()
+			ok := func(input map[string]interface{}) (int, interface{}) {
+				return http.StatusOK, map[string]interface{}{}
+			}
+			fake.Handle("CreateTable", ok)
+			fake.Handle("UpdateTimeToLive", ok)
+			fake.Handle("DescribeTable", internal.Sequence(tableStatus("CREATING"), tableStatus("ACTIVE")))
+
+			// Run the test
+			err := test.create(fake)
+			internal.NoError(t, err)
+
+			// The stream, encryption & tags are part of the create request
+			input := fake.Calls("CreateTable")[0].Input
+			internal.Equals(t, map[string]interface{}{"StreamEnabled": true, "StreamViewType": "NEW_IMAGE"}, input["StreamSpecification"])
+			internal.Equals(t, map[string]interface{}{"Enabled": true, "SSEType": "KMS", "KMSMasterKeyId": "alias/tables"}, input["SSESpecification"])
+			internal.Equals(t, []interface{}{
+				map[string]interface{}{"Key": "cost-centre", "Value": "1234"},
+				map[string]interface{}{"Key": "team", "Value": "platform"},
+			}, input["Tags"])
+
+			// Time to live is only enabled once the table is active
+			internal.Equals(t, 2, len(fake.Calls("DescribeTable")))
+			ttl := fake.Calls("UpdateTimeToLive")
+			internal.Equals(t, 1, len(ttl))
+			internal.Equals(t, map[string]interface{}{"AttributeName": "expires", "Enabled": true}, ttl[0].Input["TimeToLiveSpecification"])
+		})
+	}
+}
+
+// Test the table options are validated
+func TestCreateTableOptionsValidation(t *testing.T) {
+
+	// Setup test data
+	tests := []struct {
+		desc      string
+		options   dynamodb.TableOptions
+		expectErr bool
+	}{
+		{"Invalid stream view type", dynamodb.TableOptions{Stream: &dynamodb.StreamSpec{Enabled: true, ViewType: "garbage"}}, true},
+		{"Empty tag key", dynamodb.TableOptions{Tags: map[string]string{"": "x"}}, true},
+		{"Disabled stream", dynamodb.TableOptions{Stream: &dynamodb.StreamSpec{}}, false},
+		{"AWS managed key", dynamodb.TableOptions{Encryption: &dynamodb.EncryptionSpec{}}, false},
+	}
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("CreateTable", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{}
+	})
+	fake.Handle("DescribeTable", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, FakeTable(TestTableNameValid, "ACTIVE")
+	})
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Run the test
+			conf := TestTableConf
+			conf.TableOptions = test.options
+			before := len(fake.Calls("CreateTable"))
+			err := dynamodb.CreateTableWithContext(context.Background(), fake.Session(), conf, TestTableAttribs, fastWait)
+			if test.expectErr {
+				internal.HasError(t, err)
+				internal.Equals(t, before, len(fake.Calls("CreateTable")))
+			} else {
+				internal.NoError(t, err)
+				input := fake.Calls("CreateTable")[before].Input
+				_, hasStream := input["StreamSpecification"]
+				internal.Assert(t, !hasStream, "a disabled stream should not be sent")
+			}
+		})
+	}
+}
@@ -0,0 +1,353 @@
+// This file contains all the bits & pieces related to
+// describing a table (attributes, keys & secondary indexes)
+// & creating it in Dynamo DB from that description
+
+package dynamodb
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/clients"
+)
+
+const (
+	// ProjectionTypeAll - project all attributes into the index
+	ProjectionTypeAll string = "ALL"
+
+	// ProjectionTypeKeysOnly - project only the table & index keys into the index
+	ProjectionTypeKeysOnly string = "KEYS_ONLY"
+
+	// ProjectionTypeInclude - project the keys plus the listed non-key attributes
+	ProjectionTypeInclude string = "INCLUDE"
+
+	// MaxGlobalIndexes - the maximum number of global secondary indexes per table
+	MaxGlobalIndexes int = 20
+
+	// MaxLocalIndexes - the maximum number of local secondary indexes per table
+	MaxLocalIndexes int = 5
+)
+
+// AttributeDef - structure used to represent an attribute definition. Only
+// attributes used as a key by the table or one of its indexes are defined.
+type AttributeDef struct {
+	Name string
+	Type string
+}
+
+// KeySchema - structure used to represent the keys of a table or index
+type KeySchema struct {
+	PartitionKey string
+	SortKey      string
+}
+
+// IndexSpec - structure used to represent a secondary index. An empty
+// ProjectionType means ALL. Capacity units are only used by global indexes
+// on provisioned tables.
+type IndexSpec struct {
+	IndexName          string
+	Keys               KeySchema
+	ProjectionType     string
+	NonKeyAttributes   []string
+	ReadCapacityUnits  int64
+	WriteCapacityUnits int64
+}
+
+// TableSpec - structure used to represent a full table definition, including
+// the optional time to live, stream, encryption & tags settings
+type TableSpec struct {
+	TableName          string
+	BillingMode        string
+	ReadCapacityUnits  int64
+	WriteCapacityUnits int64
+	Attributes         []AttributeDef
+	Keys               KeySchema
+	GlobalIndexes      []IndexSpec
+	LocalIndexes       []IndexSpec
+	TableOptions
+}
+
+// CreateTableFromSpec - This function creates a new table, including any
+// secondary indexes, & waits (using the default WaitConf) until it is ACTIVE
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     spec: the definition of the table
+//
+//   Example:
+//     err := CreateTableFromSpec(mySession, tableSpec)
+func CreateTableFromSpec(sess *session.Session, spec TableSpec) error {
+	return CreateTableFromSpecWithContext(context.Background(), sess, spec, WaitConf{})
+}
+
+// CreateTableFromSpecWithContext - This function creates a new table, including
+// any secondary indexes, & waits until it & its indexes are ACTIVE. Time to
+// live can only be enabled once the table is ACTIVE so it is applied last.
+//
+//   Parameters:
+//     ctx: the context used to cancel the request & wait
+//     sess: a valid AWS session
+//     spec: the definition of the table
+//     wait: the timeout & backoff to use while waiting
+//
+//   Example:
+//     err := CreateTableFromSpecWithContext(ctx, mySession, tableSpec, WaitConf{})
+func CreateTableFromSpecWithContext(ctx context.Context, sess *session.Session, spec TableSpec, wait WaitConf) error {
+
+	// Build the input params
+	params, err := newCreateTableInput(spec)
+	if err != nil {
+		return err
+	}
+
+	// Create the DynamoDB client
+	svc := clients.DynamoDB(sess)
+
+	// Make the call to DynamoDB
+	_, err = svc.CreateTableWithContext(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	// Wait for the table to be ready
+	err = WaitUntilTableActive(ctx, sess, spec.TableName, wait)
+	if err != nil {
+		return err
+	}
+
+	// Enable time to live
+	if spec.TTLAttribute != "" {
+		return enableTimeToLive(ctx, svc, spec.TableName, spec.TTLAttribute)
+	}
+	return nil
+}
+
+// newCreateTableInput validates the spec & builds the create table params
+func newCreateTableInput(spec TableSpec) (*dynamodb.CreateTableInput, error) {
+
+	// Sanity check
+	err := validateTableSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	provisioned := strings.ToUpper(spec.BillingMode) == BillingModeProvisioned
+
+	// Create a basic input structure for the request
+	params := &dynamodb.CreateTableInput{
+		TableName:   aws.String(spec.TableName),
+		BillingMode: aws.String(strings.ToUpper(spec.BillingMode)),
+		KeySchema:   newKeySchema(spec.Keys),
+	}
+
+	// Add the attribute definitions
+	for _, a := range spec.Attributes {
+		params.AttributeDefinitions = append(params.AttributeDefinitions, &dynamodb.AttributeDefinition{
+			AttributeName: aws.String(a.Name),
+			AttributeType: aws.String(a.Type),
+		})
+	}
+
+	// Add the capacity units if billing mode is provisioned
+	if provisioned {
+		params.ProvisionedThroughput = newThroughput(spec.ReadCapacityUnits, spec.WriteCapacityUnits)
+	}
+
+	// Add the global secondary indexes
+	for _, idx := range spec.GlobalIndexes {
+		gsi := &dynamodb.GlobalSecondaryIndex{
+			IndexName:  aws.String(idx.IndexName),
+			KeySchema:  newKeySchema(idx.Keys),
+			Projection: newProjection(idx),
+		}
+		if provisioned {
+			gsi.ProvisionedThroughput = newThroughput(idx.ReadCapacityUnits, idx.WriteCapacityUnits)
+		}
+		params.GlobalSecondaryIndexes = append(params.GlobalSecondaryIndexes, gsi)
+	}
+
+	// Add the local secondary indexes
+	for _, idx := range spec.LocalIndexes {
+		params.LocalSecondaryIndexes = append(params.LocalSecondaryIndexes, &dynamodb.LocalSecondaryIndex{
+			IndexName:  aws.String(idx.IndexName),
+			KeySchema:  newKeySchema(idx.Keys),
+			Projection: newProjection(idx),
+		})
+	}
+
+	// Add the stream, encryption & tags
+	addTableOptions(params, spec.TableOptions)
+
+	// Return it
+	return params, nil
+}
+
+// validateTableSpec checks the spec is complete & consistent
+func validateTableSpec(spec TableSpec) error {
+
+	// Table level checks
+	if spec.TableName == "" {
+		return newErrorTableNameNotProvided()
+	}
+	if spec.BillingMode == "" {
+		return newErrorBillingModeNotProvided()
+	}
+	mode := strings.ToUpper(spec.BillingMode)
+	if mode != BillingModeProvisioned && mode != BillingModePayPerRequest {
+		return newErrorBillingModeInvalid(spec.BillingMode)
+	}
+	provisioned := mode == BillingModeProvisioned
+	if provisioned && (spec.ReadCapacityUnits < 1 || spec.WriteCapacityUnits < 1) {
+		return newErrorCapacityUnitsNotProvided(spec.TableName)
+	}
+	if len(spec.Attributes) == 0 {
+		return newErrorTableAttributesNotProvided()
+	}
+
+	// Attribute definitions
+	defined := make(map[string]bool)
+	for _, a := range spec.Attributes {
+		if a.Name == "" {
+			return newErrorTableAttributeNameNotProvided()
+		}
+		if a.Type == "" {
+			return newErrorTableAttributeTypeNotProvided()
+		}
+		if defined[a.Name] {
+			return newErrorTableAttributeDuplicated(a.Name)
+		}
+		defined[a.Name] = true
+	}
+
+	// Table keys
+	used := make(map[string]bool)
+	if spec.Keys.PartitionKey == "" {
+		return newErrorTableKeyAttributesNotProvided()
+	}
+	err := checkKeys(spec.TableName, spec.Keys, defined, used)
+	if err != nil {
+		return err
+	}
+
+	// Indexes
+	if len(spec.GlobalIndexes) > MaxGlobalIndexes {
+		return newErrorTooManyIndexes("global", MaxGlobalIndexes)
+	}
+	if len(spec.LocalIndexes) > MaxLocalIndexes {
+		return newErrorTooManyIndexes("local", MaxLocalIndexes)
+	}
+	names := make(map[string]bool)
+	for _, idx := range spec.GlobalIndexes {
+		err = checkIndex(idx, defined, used, names)
+		if err != nil {
+			return err
+		}
+		if provisioned && (idx.ReadCapacityUnits < 1 || idx.WriteCapacityUnits < 1) {
+			return newErrorCapacityUnitsNotProvided(idx.IndexName)
+		}
+	}
+	for _, idx := range spec.LocalIndexes {
+		err = checkIndex(idx, defined, used, names)
+		if err != nil {
+			return err
+		}
+		if idx.Keys.PartitionKey != spec.Keys.PartitionKey || spec.Keys.SortKey == "" || idx.Keys.SortKey == "" {
+			return newErrorLocalIndexKeysInvalid(idx.IndexName)
+		}
+		if idx.ReadCapacityUnits != 0 || idx.WriteCapacityUnits != 0 {
+			return newErrorLocalIndexCapacityProvided(idx.IndexName)
+		}
+	}
+
+	// Every attribute definition must be used as a key
+	for _, a := range spec.Attributes {
+		if !used[a.Name] {
+			return newErrorTableAttributeNotUsed(a.Name)
+		}
+	}
+	return validateTableOptions(spec.TableOptions)
+}
+
+// checkIndex checks an index definition
+func checkIndex(idx IndexSpec, defined map[string]bool, used map[string]bool, names map[string]bool) error {
+
+	if idx.IndexName == "" {
+		return newErrorIndexNameNotProvided()
+	}
+	if names[idx.IndexName] {
+		return newErrorIndexNameDuplicated(idx.IndexName)
+	}
+	names[idx.IndexName] = true
+	if idx.Keys.PartitionKey == "" {
+		return newErrorIndexKeysNotProvided(idx.IndexName)
+	}
+	switch strings.ToUpper(idx.ProjectionType) {
+	case "", ProjectionTypeAll, ProjectionTypeKeysOnly:
+		if len(idx.NonKeyAttributes) > 0 {
+			return newErrorIndexProjectionInvalid(idx.IndexName)
+		}
+	case ProjectionTypeInclude:
+		if len(idx.NonKeyAttributes) == 0 {
+			return newErrorIndexProjectionInvalid(idx.IndexName)
+		}
+	default:
+		return newErrorIndexProjectionTypeInvalid(idx.IndexName, idx.ProjectionType)
+	}
+	return checkKeys(idx.IndexName, idx.Keys, defined, used)
+}
+
+// checkKeys checks the keys have attribute definitions & records them as used
+func checkKeys(owner string, keys KeySchema, defined map[string]bool, used map[string]bool) error {
+
+	for _, k := range []string{keys.PartitionKey, keys.SortKey} {
+		if k == "" {
+			continue
+		}
+		if !defined[k] {
+			return newErrorKeyAttributeNotDefined(owner, k)
+		}
+		used[k] = true
+	}
+	return nil
+}
+
+// newKeySchema builds the key schema elements
+func newKeySchema(keys KeySchema) []*dynamodb.KeySchemaElement {
+
+	schema := []*dynamodb.KeySchemaElement{{
+		AttributeName: aws.String(keys.PartitionKey),
+		KeyType:       aws.String(KeyTypePartition),
+	}}
+	if keys.SortKey != "" {
+		schema = append(schema, &dynamodb.KeySchemaElement{
+			AttributeName: aws.String(keys.SortKey),
+			KeyType:       aws.String(KeyTypeSort),
+		})
+	}
+	return schema
+}
+
+// newProjection builds the projection for an index
+func newProjection(idx IndexSpec) *dynamodb.Projection {
+
+	projType := strings.ToUpper(idx.ProjectionType)
+	if projType == "" {
+		projType = ProjectionTypeAll
+	}
+	proj := &dynamodb.Projection{ProjectionType: aws.String(projType)}
+	if len(idx.NonKeyAttributes) > 0 {
+		proj.NonKeyAttributes = aws.StringSlice(idx.NonKeyAttributes)
+	}
+	return proj
+}
+
+// newThroughput builds the provisioned throughput
+func newThroughput(read int64, write int64) *dynamodb.ProvisionedThroughput {
+
+	return &dynamodb.ProvisionedThroughput{
+		ReadCapacityUnits:  aws.Int64(read),
+		WriteCapacityUnits: aws.Int64(write),
+	}
+}
@@ -0,0 +1,167 @@
+package dynamodb_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/bradmccoydev/self-service-sdk/internal"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/dynamodb"
+)
+
+// newTestTableSpec builds a valid spec with a global & local index
+func newTestTableSpec() dynamodb.TableSpec {
+
+	return dynamodb.TableSpec{
+		TableName:   TestTableNameValid,
+		BillingMode: dynamodb.BillingModePayPerRequest,
+		Attributes: []dynamodb.AttributeDef{
+			{Name: "service", Type: "S"},
+			{Name: "version", Type: "N"},
+			{Name: "owner", Type: "S"},
+			{Name: "created", Type: "S"},
+		},
+		Keys: dynamodb.KeySchema{PartitionKey: "service", SortKey: "version"},
+		GlobalIndexes: []dynamodb.IndexSpec{
+			{IndexName: "by-owner", Keys: dynamodb.KeySchema{PartitionKey: "owner", SortKey: "created"}, ProjectionType: dynamodb.ProjectionTypeInclude, NonKeyAttributes: []string{"description"}},
+		},
+		LocalIndexes: []dynamodb.IndexSpec{
+			{IndexName: "by-created", Keys: dynamodb.KeySchema{PartitionKey: "service", SortKey: "created"}, ProjectionType: dynamodb.ProjectionTypeKeysOnly},
+		},
+	}
+}
+
+// Test CreateTableFromSpec validation
+func TestCreateTableFromSpecValidation(t *testing.T) {
+
+	// Setup test data
+	valid := newTestTableSpec()
+	noName := newTestTableSpec()
+	noName.TableName = ""
+	badMode := newTestTableSpec()
+	badMode.BillingMode = "garbage"
+	noCapacity := newTestTableSpec()
+	noCapacity.BillingMode = dynamodb.BillingModeProvisioned
+	noIndexCapacity := newTestTableSpec()
+	noIndexCapacity.BillingMode = dynamodb.BillingModeProvisioned
+	noIndexCapacity.ReadCapacityUnits, noIndexCapacity.WriteCapacityUnits = 5, 5
+	noAttribs := newTestTableSpec()
+	noAttribs.Attributes = nil
+	dupAttrib := newTestTableSpec()
+	dupAttrib.Attributes = append(dupAttrib.Attributes, dynamodb.AttributeDef{Name: "owner", Type: "S"})
+	unusedAttrib := newTestTableSpec()
+	unusedAttrib.Attributes = append(unusedAttrib.Attributes, dynamodb.AttributeDef{Name: "description", Type: "S"})
+	noKey := newTestTableSpec()
+	noKey.Keys = dynamodb.KeySchema{}
+	undefinedKey := newTestTableSpec()
+	undefinedKey.GlobalIndexes[0].Keys.SortKey = "garbage"
+	noIndexName := newTestTableSpec()
+	noIndexName.GlobalIndexes[0].IndexName = ""
+	dupIndexName := newTestTableSpec()
+	dupIndexName.LocalIndexes[0].IndexName = "by-owner"
+	includeNoAttribs := newTestTableSpec()
+	includeNoAttribs.GlobalIndexes[0].NonKeyAttributes = nil
+	allWithAttribs := newTestTableSpec()
+	allWithAttribs.GlobalIndexes[0].ProjectionType = dynamodb.ProjectionTypeAll
+	badProjection := newTestTableSpec()
+	badProjection.GlobalIndexes[0].ProjectionType = "garbage"
+	badLocalKey := newTestTableSpec()
+	badLocalKey.LocalIndexes[0].Keys.PartitionKey = "owner"
+	localCapacity := newTestTableSpec()
+	localCapacity.LocalIndexes[0].ReadCapacityUnits = 5
+
+	tests := []struct {
+		desc      string
+		spec      dynamodb.TableSpec
+		expectErr bool
+	}{
+		{"No table name", noName, true},
+		{"Invalid billing mode", badMode, true},
+		{"Provisioned without capacity", noCapacity, true},
+		{"Provisioned index without capacity", noIndexCapacity, true},
+		{"No attributes", noAttribs, true},
+		{"Duplicate attribute", dupAttrib, true},
+		{"Attribute not used as a key", unusedAttrib, true},
+		{"No partition key", noKey, true},
+		{"Key without a definition", undefinedKey, true},
+		{"Index without a name", noIndexName, true},
+		{"Duplicate index name", dupIndexName, true},
+		{"Include projection without attributes", includeNoAttribs, true},
+		{"All projection with attributes", allWithAttribs, true},
+		{"Invalid projection type", badProjection, true},
+		{"Local index with a different partition key", badLocalKey, true},
+		{"Local index with capacity", localCapacity, true},
+		{"Valid spec", valid, false},
+	}
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("CreateTable", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{}
+	})
+	fake.Handle("DescribeTable", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, FakeTable(TestTableNameValid, "ACTIVE", "ACTIVE")
+	})
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Run the test
+			err := dynamodb.CreateTableFromSpecWithContext(context.Background(), fake.Session(), test.spec, fastWait)
+			if test.expectErr {
+				internal.HasError(t, err)
+			} else {
+				internal.NoError(t, err)
+			}
+		})
+	}
+
+	// Only the valid spec should have reached DynamoDB
+	internal.Equals(t, 1, len(fake.Calls("CreateTable")))
+}
+
+// Test CreateTableFromSpec builds the expected request
+func TestCreateTableFromSpec(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("CreateTable", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{}
+	})
+	fake.Handle("DescribeTable", internal.Sequence(tableStatus("ACTIVE", "CREATING"), tableStatus("ACTIVE", "ACTIVE")))
+
+	// Create a provisioned table
+	spec := newTestTableSpec()
+	spec.BillingMode = "provisioned"
+	spec.ReadCapacityUnits, spec.WriteCapacityUnits = 5, 10
+	spec.GlobalIndexes[0].ReadCapacityUnits, spec.GlobalIndexes[0].WriteCapacityUnits = 2, 3
+	err := dynamodb.CreateTableFromSpecWithContext(context.Background(), fake.Session(), spec, fastWait)
+	internal.NoError(t, err)
+
+	// It should only return once the index is active
+	internal.Equals(t, 2, len(fake.Calls("DescribeTable")))
+
+	// Check the request
+	input := fake.Calls("CreateTable")[0].Input
+	internal.Equals(t, TestTableNameValid, input["TableName"])
+	internal.Equals(t, dynamodb.BillingModeProvisioned, input["BillingMode"])
+	internal.Equals(t, 4, len(input["AttributeDefinitions"].([]interface{})))
+	internal.Equals(t, []interface{}{
+		map[string]interface{}{"AttributeName": "service", "KeyType": "HASH"},
+		map[string]interface{}{"AttributeName": "version", "KeyType": "RANGE"},
+	}, input["KeySchema"])
+	internal.Equals(t, map[string]interface{}{"ReadCapacityUnits": float64(5), "WriteCapacityUnits": float64(10)}, input["ProvisionedThroughput"])
+	gsi := input["GlobalSecondaryIndexes"].([]interface{})[0].(map[string]interface{})
+	internal.Equals(t, "by-owner", gsi["IndexName"])
+	internal.Equals(t, map[string]interface{}{"ProjectionType": "INCLUDE", "NonKeyAttributes": []interface{}{"description"}}, gsi["Projection"])
+	internal.Equals(t, map[string]interface{}{"ReadCapacityUnits": float64(2), "WriteCapacityUnits": float64(3)}, gsi["ProvisionedThroughput"])
+	lsi := input["LocalSecondaryIndexes"].([]interface{})[0].(map[string]interface{})
+	internal.Equals(t, "by-created", lsi["IndexName"])
+	internal.Equals(t, map[string]interface{}{"ProjectionType": "KEYS_ONLY"}, lsi["Projection"])
+	_, hasThroughput := lsi["ProvisionedThroughput"]
+	internal.Assert(t, !hasThroughput, "local indexes should not have throughput")
+}
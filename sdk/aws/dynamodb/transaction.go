@@ -0,0 +1,604 @@
+// This file contains all the bits & pieces related to
+// reading & writing several items atomically using
+// Dynamo DB transactions
+
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/clients"
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxTransactionItems - the most operations Dynamo DB accepts in one transaction
+	MaxTransactionItems int = 25
+
+	// TransactionReasonNone - the cancellation reason of operations that didn't fail
+	TransactionReasonNone string = "None"
+
+	// TransactionReasonConditionFailed - the cancellation reason of operations whose condition failed
+	TransactionReasonConditionFailed string = "ConditionalCheckFailed"
+)
+
+// OperationError - structure used to represent why an operation caused a
+// transaction to be cancelled. Index is the position of the operation in the
+// transaction & Code is the Dynamo DB cancellation reason code.
+type OperationError struct {
+	Index     int
+	Operation string
+	TableName string
+	Code      string
+	Message   string
+	conflict  *VersionConflictError
+}
+
+// Error - This function describes the failed operation
+func (e *OperationError) Error() string {
+	return fmt.Sprintf("%s %d on table %s failed with %s: %s", e.Operation, e.Index, e.TableName, e.Code, e.Message)
+}
+
+// Is - This function lets errors.Is match ErrConditionFailed when the
+// operation's condition failed
+func (e *OperationError) Is(target error) bool {
+	return target == ErrConditionFailed && e.Code == TransactionReasonConditionFailed
+}
+
+// Unwrap - This function returns the *VersionConflictError of a versioned
+// write whose version didn't match, nil otherwise
+func (e *OperationError) Unwrap() error {
+	if e.conflict == nil {
+		return nil
+	}
+	return e.conflict
+}
+
+// TransactionCancelledError - structure used to represent a cancelled
+// transaction with an error for each of the operations that caused it
+type TransactionCancelledError struct {
+	Operations []*OperationError
+	cause      error
+}
+
+// Error - This function describes the failed operations
+func (e *TransactionCancelledError) Error() string {
+
+	var reasons []string
+	for _, op := range e.Operations {
+		reasons = append(reasons, op.Error())
+	}
+	return fmt.Sprintf("%s: %s", ErrTransactionCancelled.Error(), strings.Join(reasons, "; "))
+}
+
+// Is - This function lets errors.Is match ErrTransactionCancelled, or
+// ErrConditionFailed (& ErrVersionConflict) when an operation's condition failed
+func (e *TransactionCancelledError) Is(target error) bool {
+
+	if target == ErrTransactionCancelled {
+		return true
+	}
+	for _, op := range e.Operations {
+		if errors.Is(op, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Unwrap - This function returns the underlying Dynamo DB error
+func (e *TransactionCancelledError) Unwrap() error {
+	return e.cause
+}
+
+// operation - the name & table of an operation in a transaction, with the
+// version, item & conditions of versioned writes
+type operation struct {
+	name       string
+	tableName  string
+	version    *itemVersion
+	item       map[string]*dynamodb.AttributeValue
+	conditions []Condition
+}
+
+// WriteTransaction - structure used to build up a set of writes that either
+// all succeed or all fail. Errors while building are returned by Execute.
+type WriteTransaction struct {
+	items []*dynamodb.TransactWriteItem
+	ops   []operation
+	token string
+	err   error
+}
+
+// NewWriteTransaction - This function creates an empty write transaction with
+// a new idempotency token, so it can safely be executed again if the outcome
+// of a previous attempt is unknown
+//
+//   Example:
+//     err := NewWriteTransaction().
+//       Update("services", serviceKeys, myChanges, Condition{Field: "status", Operator: Equals, Value: "active"}).
+//       Put("names", myName).
+//       Execute(mySession)
+func NewWriteTransaction() *WriteTransaction {
+	return &WriteTransaction{token: uuid.New().String()}
+}
+
+// WithToken - This function replaces the idempotency token of the transaction
+//
+//   Parameters:
+//     token: the client request token to use
+//
+//   Example:
+//     tx := NewWriteTransaction().WithToken(requestID)
+func (t *WriteTransaction) WithToken(token string) *WriteTransaction {
+	t.token = token
+	return t
+}
+
+// Token - This function returns the idempotency token of the transaction
+//
+//   Example:
+//     token := tx.Token()
+func (t *WriteTransaction) Token() string {
+	return t.token
+}
+
+// Put - This function adds an operation that creates or replaces an item
+//
+//   Parameters:
+//     tableName: the name of the table to add the item to
+//     item: the structure containing the item properties
+//     conditions: optional condition(s) the existing item must meet
+//
+//   A field tagged `dynamo:"version"` is handled as CreateItem does, the
+//   version field of a pointer is updated once the transaction succeeds.
+//
+//   Example:
+//     tx.Put("fred", myStruct)
+func (t *WriteTransaction) Put(tableName string, item interface{}, conditions ...Condition) *WriteTransaction {
+
+	// Build the item & condition
+	if !t.check(tableName) {
+		return t
+	}
+	av, err := dynamodbattribute.MarshalMap(item)
+	if err != nil {
+		t.err = err
+		return t
+	}
+	version, err := findVersion(item)
+	if err != nil {
+		t.err = err
+		return t
+	}
+	if version != nil {
+		err = version.apply(av)
+		if err != nil {
+			t.err = err
+			return t
+		}
+	}
+	expr, err := newWriteExpression(nil, conditions, version)
+	if err != nil {
+		t.err = err
+		return t
+	}
+
+	// Add the operation
+	put := &dynamodb.Put{TableName: aws.String(tableName), Item: av}
+	if expr != nil {
+		put.ConditionExpression = expr.Condition()
+		put.ExpressionAttributeNames = expr.Names()
+		put.ExpressionAttributeValues = expr.Values()
+	}
+	t.track(operation{name: "Put", tableName: tableName, version: version, item: av, conditions: conditions})
+	return t.add(&dynamodb.TransactWriteItem{Put: put})
+}
+
+// Update - This function adds an operation that sets each field of the input
+// structure on an item
+//
+//   Parameters:
+//     tableName: the name of the table containing the item
+//     keys: the structure containing the item keys
+//     input: the structure containing the item properties to update
+//     conditions: optional condition(s) the existing item must meet
+//
+//   A field tagged `dynamo:"version"` is handled as UpdateItem does, the
+//   version field of a pointer is updated once the transaction succeeds.
+//
+//   Example:
+//     tx.Update("fred", myKeys, myChanges, Condition{Field: "status", Operator: Equals, Value: "active"})
+func (t *WriteTransaction) Update(tableName string, keys interface{}, input interface{}, conditions ...Condition) *WriteTransaction {
+
+	// Build the keys, update & condition
+	if !t.check(tableName) {
+		return t
+	}
+	itemKeys, err := dynamodbattribute.MarshalMap(keys)
+	if err != nil {
+		t.err = err
+		return t
+	}
+	version, err := findVersion(input)
+	if err != nil {
+		t.err = err
+		return t
+	}
+	update, err := newUpdateBuilder(input, itemKeys, version)
+	if err != nil {
+		t.err = err
+		return t
+	}
+	expr, err := newWriteExpression(&update, conditions, version)
+	if err != nil {
+		t.err = err
+		return t
+	}
+
+	// Add the operation
+	t.track(operation{name: "Update", tableName: tableName, version: version, item: itemKeys, conditions: conditions})
+	return t.add(&dynamodb.TransactWriteItem{Update: &dynamodb.Update{
+		TableName:                 aws.String(tableName),
+		Key:                       itemKeys,
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}})
+}
+
+// Delete - This function adds an operation that deletes an item
+//
+//   Parameters:
+//     tableName: the name of the table containing the item
+//     keys: the structure containing the item keys
+//     conditions: optional condition(s) the existing item must meet
+//
+//   Example:
+//     tx.Delete("fred", myKeys)
+func (t *WriteTransaction) Delete(tableName string, keys interface{}, conditions ...Condition) *WriteTransaction {
+
+	// Build the keys & condition
+	if !t.check(tableName) {
+		return t
+	}
+	itemKeys, err := dynamodbattribute.MarshalMap(keys)
+	if err != nil {
+		t.err = err
+		return t
+	}
+	expr, err := newWriteExpression(nil, conditions, nil)
+	if err != nil {
+		t.err = err
+		return t
+	}
+
+	// Add the operation
+	del := &dynamodb.Delete{TableName: aws.String(tableName), Key: itemKeys}
+	if expr != nil {
+		del.ConditionExpression = expr.Condition()
+		del.ExpressionAttributeNames = expr.Names()
+		del.ExpressionAttributeValues = expr.Values()
+	}
+	t.track(operation{name: "Delete", tableName: tableName})
+	return t.add(&dynamodb.TransactWriteItem{Delete: del})
+}
+
+// ConditionCheck - This function adds an operation that doesn't change an item
+// but cancels the transaction unless the item meets the condition(s)
+//
+//   Parameters:
+//     tableName: the name of the table containing the item
+//     keys: the structure containing the item keys
+//     conditions: the condition(s) the item must meet
+//
+//   Example:
+//     tx.ConditionCheck("owners", ownerKeys, Condition{Field: "status", Operator: Equals, Value: "active"})
+func (t *WriteTransaction) ConditionCheck(tableName string, keys interface{}, conditions ...Condition) *WriteTransaction {
+
+	// Build the keys & condition
+	if !t.check(tableName) {
+		return t
+	}
+	if len(conditions) == 0 {
+		t.err = newErrorConditionsNotProvided()
+		return t
+	}
+	itemKeys, err := dynamodbattribute.MarshalMap(keys)
+	if err != nil {
+		t.err = err
+		return t
+	}
+	expr, err := newWriteExpression(nil, conditions, nil)
+	if err != nil {
+		t.err = err
+		return t
+	}
+
+	// Add the operation
+	t.track(operation{name: "ConditionCheck", tableName: tableName})
+	return t.add(&dynamodb.TransactWriteItem{ConditionCheck: &dynamodb.ConditionCheck{
+		TableName:                 aws.String(tableName),
+		Key:                       itemKeys,
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}})
+}
+
+// Execute - This function runs the transaction. If it is cancelled the error
+// is a *TransactionCancelledError describing the operation(s) that failed.
+//
+//   Parameters:
+//     sess: a valid AWS session
+//
+//   Example:
+//     err := tx.Execute(mySession)
+func (t *WriteTransaction) Execute(sess *session.Session) error {
+	return t.ExecuteWithContext(context.Background(), sess)
+}
+
+// ExecuteWithContext - This function runs the transaction. If it is cancelled
+// the error is a *TransactionCancelledError describing the operation(s) that
+// failed. A versioned write whose version didn't match makes it match
+// ErrVersionConflict too.
+//
+//   Parameters:
+//     ctx: the context used to cancel the request
+//     sess: a valid AWS session
+//
+//   Example:
+//     err := tx.ExecuteWithContext(ctx, mySession)
+func (t *WriteTransaction) ExecuteWithContext(ctx context.Context, sess *session.Session) error {
+
+	// Sanity check
+	if t.err != nil {
+		return t.err
+	}
+	if len(t.items) == 0 {
+		return newErrorTransactionEmpty()
+	}
+	if len(t.items) > MaxTransactionItems {
+		return newErrorTransactionTooLarge(len(t.items), MaxTransactionItems)
+	}
+
+	// Build the params
+	params := &dynamodb.TransactWriteItemsInput{TransactItems: t.items}
+	if t.token != "" {
+		params.ClientRequestToken = aws.String(t.token)
+	}
+
+	// Make the call to DynamoDB
+	svc := clients.DynamoDB(sess)
+	_, err := svc.TransactWriteItemsWithContext(ctx, params)
+	if err != nil {
+		err = newTransactionError(err, t.ops)
+		if cancelled, ok := err.(*TransactionCancelledError); ok {
+			findVersionConflicts(ctx, svc, cancelled, t.ops)
+		}
+		return err
+	}
+
+	// Update the callers' versions
+	for _, op := range t.ops {
+		if op.version != nil {
+			op.version.commit()
+		}
+	}
+	return nil
+}
+
+// check makes sure an operation can be added
+func (t *WriteTransaction) check(tableName string) bool {
+
+	if t.err != nil {
+		return false
+	}
+	if tableName == "" {
+		t.err = newErrorTableNameNotProvided()
+		return false
+	}
+	return true
+}
+
+// track remembers an operation, so its version can be updated once the
+// transaction succeeds & its errors can be described if it doesn't
+func (t *WriteTransaction) track(op operation) {
+	t.ops = append(t.ops, op)
+}
+
+// add records the item of an operation
+func (t *WriteTransaction) add(item *dynamodb.TransactWriteItem) *WriteTransaction {
+	t.items = append(t.items, item)
+	return t
+}
+
+// GetTransaction - structure used to build up a set of reads that see a
+// consistent snapshot of the items. Errors while building are returned by
+// Execute.
+type GetTransaction struct {
+	items   []*dynamodb.TransactGetItem
+	ops     []operation
+	outputs []interface{}
+	err     error
+}
+
+// NewGetTransaction - This function creates an empty get transaction
+//
+//   Example:
+//     err := NewGetTransaction().
+//       Get("services", serviceKeys, &myService).
+//       Get("owners", ownerKeys, &myOwner, "name", "email").
+//       Execute(mySession)
+func NewGetTransaction() *GetTransaction {
+	return &GetTransaction{}
+}
+
+// Get - This function adds an operation that reads an item into output
+//
+//   Parameters:
+//     tableName: the name of the table containing the item
+//     keys: the structure containing the item keys
+//     output: a pointer to the structure the item should be returned in
+//     fields: optional names of the attributes to return
+//
+//   Example:
+//     tx.Get("fred", myKeys, &myStruct)
+func (t *GetTransaction) Get(tableName string, keys interface{}, output interface{}, fields ...string) *GetTransaction {
+
+	// Sanity check
+	if t.err != nil {
+		return t
+	}
+	if tableName == "" {
+		t.err = newErrorTableNameNotProvided()
+		return t
+	}
+	val := reflect.ValueOf(output)
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		t.err = newErrorOutputNotPointer()
+		return t
+	}
+
+	// Build the keys & projection
+	itemKeys, err := dynamodbattribute.MarshalMap(keys)
+	if err != nil {
+		t.err = err
+		return t
+	}
+	get := &dynamodb.Get{TableName: aws.String(tableName), Key: itemKeys}
+	if len(fields) > 0 {
+		var conf readConf
+		WithProjection(fields...)(&conf)
+		get.ProjectionExpression, get.ExpressionAttributeNames, err = conf.projection()
+		if err != nil {
+			t.err = err
+			return t
+		}
+	}
+
+	// Add the operation
+	t.items = append(t.items, &dynamodb.TransactGetItem{Get: get})
+	t.ops = append(t.ops, operation{name: "Get", tableName: tableName})
+	t.outputs = append(t.outputs, output)
+	return t
+}
+
+// Execute - This function runs the transaction, filling in the outputs. If any
+// of the items don't exist the others are still filled in & the error wraps
+// ErrItemNotFound.
+//
+//   Parameters:
+//     sess: a valid AWS session
+//
+//   Example:
+//     err := tx.Execute(mySession)
+func (t *GetTransaction) Execute(sess *session.Session) error {
+	return t.ExecuteWithContext(context.Background(), sess)
+}
+
+// ExecuteWithContext - This function runs the transaction, filling in the
+// outputs. If any of the items don't exist the others are still filled in &
+// the error wraps ErrItemNotFound.
+//
+//   Parameters:
+//     ctx: the context used to cancel the request
+//     sess: a valid AWS session
+//
+//   Example:
+//     err := tx.ExecuteWithContext(ctx, mySession)
+func (t *GetTransaction) ExecuteWithContext(ctx context.Context, sess *session.Session) error {
+
+	// Sanity check
+	if t.err != nil {
+		return t.err
+	}
+	if len(t.items) == 0 {
+		return newErrorTransactionEmpty()
+	}
+	if len(t.items) > MaxTransactionItems {
+		return newErrorTransactionTooLarge(len(t.items), MaxTransactionItems)
+	}
+
+	// Make the call to DynamoDB
+	svc := clients.DynamoDB(sess)
+	result, err := svc.TransactGetItemsWithContext(ctx, &dynamodb.TransactGetItemsInput{TransactItems: t.items})
+	if err != nil {
+		return newTransactionError(err, t.ops)
+	}
+
+	// Massage the result(s), noting anything missing
+	var missing []int
+	for i, output := range t.outputs {
+		if i >= len(result.Responses) || len(result.Responses[i].Item) == 0 {
+			missing = append(missing, i)
+			continue
+		}
+		err = dynamodbattribute.UnmarshalMap(result.Responses[i].Item, output)
+		if err != nil {
+			return err
+		}
+	}
+	if len(missing) > 0 {
+		return newErrorTransactionItemsNotFound(missing)
+	}
+	return nil
+}
+
+// newTransactionError maps the cancellation reasons of a cancelled
+// transaction to the operations that caused them
+func newTransactionError(err error, ops []operation) error {
+
+	var cancelled *dynamodb.TransactionCanceledException
+	if !errors.As(err, &cancelled) {
+		return err
+	}
+	result := &TransactionCancelledError{cause: err}
+	for i, reason := range cancelled.CancellationReasons {
+		code := aws.StringValue(reason.Code)
+		if code == "" || code == TransactionReasonNone {
+			continue
+		}
+		op := &OperationError{Index: i, Code: code, Message: aws.StringValue(reason.Message)}
+		if i < len(ops) {
+			op.Operation = ops[i].name
+			op.TableName = ops[i].tableName
+		}
+		result.Operations = append(result.Operations, op)
+	}
+	return result
+}
+
+// findVersionConflicts marks the versioned writes whose condition failed as
+// version conflicts. When the version wasn't the only condition the stored
+// version is read back to find out if it was the one that failed.
+func findVersionConflicts(ctx context.Context, svc *dynamodb.DynamoDB, cancelled *TransactionCancelledError, ops []operation) {
+
+	for _, opErr := range cancelled.Operations {
+
+		// Only versioned writes can conflict
+		if opErr.Code != TransactionReasonConditionFailed || opErr.Index >= len(ops) {
+			continue
+		}
+		op := ops[opErr.Index]
+		if op.version == nil {
+			continue
+		}
+		if len(op.conditions) > 0 {
+			stored, err := storedVersion(ctx, svc, op.tableName, op.item, op.version.name)
+			if err != nil || stored == op.version.current {
+				continue
+			}
+		}
+		opErr.conflict = &VersionConflictError{TableName: op.tableName, Attribute: op.version.name, Version: op.version.current, cause: cancelled.cause}
+	}
+}
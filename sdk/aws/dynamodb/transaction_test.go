@@ -0,0 +1,182 @@
+package dynamodb_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/bradmccoydev/self-service-sdk/internal"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/dynamodb"
+)
+
+// transactItems returns the operations sent in a transaction call
+func transactItems(fake *FakeDynamo, op string) []interface{} {
+	return fake.Calls(op)[0].Input["TransactItems"].([]interface{})
+}
+
+// Test WriteTransaction
+func TestWriteTransaction(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("TransactWriteItems", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{}
+	})
+
+	// Build & run the transaction
+	keys := TestTableKeys{Name: "fred"}
+	active := dynamodb.Condition{Field: "description", Operator: dynamodb.Equals, Value: "active"}
+	tx := dynamodb.NewWriteTransaction().
+		Put(TestTableNameValid, TestTableFullItem{Name: "new", Description: "Blah"}).
+		Update(TestTableNameValid, keys, TestTableFullItem{Description: "Updated"}, active).
+		Delete(TestTableNameValid, TestTableKeys{Name: "old"}).
+		ConditionCheck("owners", keys, active)
+	err := tx.Execute(fake.Session())
+	internal.NoError(t, err)
+
+	// Check what was sent
+	internal.Equals(t, tx.Token(), fake.Calls("TransactWriteItems")[0].Input["ClientRequestToken"])
+	internal.Assert(t, tx.Token() != "", "expected a generated token")
+	items := transactItems(fake, "TransactWriteItems")
+	internal.Equals(t, 4, len(items))
+	put := items[0].(map[string]interface{})["Put"].(map[string]interface{})
+	internal.Equals(t, map[string]interface{}{"name": map[string]interface{}{"S": "new"}, "description": map[string]interface{}{"S": "Blah"}}, put["Item"])
+	internal.Equals(t, nil, put["ConditionExpression"])
+	update := items[1].(map[string]interface{})["Update"].(map[string]interface{})
+	internal.Equals(t, "#0 = :0", update["ConditionExpression"])
+	internal.Assert(t, update["UpdateExpression"] != nil, "expected an update expression")
+	internal.Equals(t, map[string]interface{}{"name": map[string]interface{}{"S": "old"}}, items[2].(map[string]interface{})["Delete"].(map[string]interface{})["Key"])
+	check := items[3].(map[string]interface{})["ConditionCheck"].(map[string]interface{})
+	internal.Equals(t, "owners", check["TableName"])
+	internal.Equals(t, map[string]interface{}{"#0": "description"}, check["ExpressionAttributeNames"])
+
+	// Running it again reuses the token, unless one is given
+	internal.NoError(t, tx.Execute(fake.Session()))
+	internal.Equals(t, tx.Token(), fake.Calls("TransactWriteItems")[1].Input["ClientRequestToken"])
+	internal.NoError(t, tx.WithToken("request-1").Execute(fake.Session()))
+	internal.Equals(t, "request-1", fake.Calls("TransactWriteItems")[2].Input["ClientRequestToken"])
+}
+
+// Test WriteTransaction validation
+func TestWriteTransactionValidation(t *testing.T) {
+
+	// Setup test data
+	tooMany := dynamodb.NewWriteTransaction()
+	for i := 0; i <= dynamodb.MaxTransactionItems; i++ {
+		tooMany.Delete(TestTableNameValid, TestTableKeys{Name: "fred"})
+	}
+	tests := []struct {
+		desc string
+		tx   *dynamodb.WriteTransaction
+	}{
+		{"No operations", dynamodb.NewWriteTransaction()},
+		{"Too many operations", tooMany},
+		{"No table name", dynamodb.NewWriteTransaction().Put("", TestTableFullItem{Name: "fred"})},
+		{"Condition check without conditions", dynamodb.NewWriteTransaction().ConditionCheck(TestTableNameValid, TestTableKeys{Name: "fred"})},
+		{"Invalid condition", dynamodb.NewWriteTransaction().Delete(TestTableNameValid, TestTableKeys{Name: "fred"}, dynamodb.Condition{Field: "name"})},
+		{"Invalid update", dynamodb.NewWriteTransaction().Update(TestTableNameValid, TestTableKeys{Name: "fred"}, "fred")},
+	}
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+			internal.HasError(t, test.tx.Execute(fake.Session()))
+		})
+	}
+
+	// Nothing should have been sent
+	internal.Equals(t, 0, len(fake.Calls("TransactWriteItems")))
+}
+
+// Test WriteTransaction cancellation
+func TestWriteTransactionCancelled(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("TransactWriteItems", func(input map[string]interface{}) (int, interface{}) {
+		body := FakeError("TransactionCanceledException", "Transaction cancelled, please refer cancellation reasons for specific reasons [None, ConditionalCheckFailed]")
+		body["CancellationReasons"] = []interface{}{
+			map[string]interface{}{"Code": "None"},
+			map[string]interface{}{"Code": "ConditionalCheckFailed", "Message": "The conditional request failed"},
+		}
+		return http.StatusBadRequest, body
+	})
+
+	// Run the transaction
+	err := dynamodb.NewWriteTransaction().
+		Put(TestTableNameValid, TestTableFullItem{Name: "new"}).
+		Delete("owners", TestTableKeys{Name: "fred"}, dynamodb.Condition{Field: "description", Operator: dynamodb.Equals, Value: "active"}).
+		Execute(fake.Session())
+
+	// Check the error
+	internal.Assert(t, errors.Is(err, dynamodb.ErrTransactionCancelled), "expected ErrTransactionCancelled, got %v", err)
+	internal.Assert(t, errors.Is(err, dynamodb.ErrConditionFailed), "expected ErrConditionFailed, got %v", err)
+	var cancelled *dynamodb.TransactionCancelledError
+	internal.Assert(t, errors.As(err, &cancelled), "expected a TransactionCancelledError, got %T", err)
+	internal.Equals(t, []*dynamodb.OperationError{{
+		Index:     1,
+		Operation: "Delete",
+		TableName: "owners",
+		Code:      dynamodb.TransactionReasonConditionFailed,
+		Message:   "The conditional request failed",
+	}}, cancelled.Operations)
+
+	// Other errors are returned as is
+	fake.Handle("TransactWriteItems", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusBadRequest, FakeError("ValidationException", "bad")
+	})
+	err = dynamodb.NewWriteTransaction().Delete(TestTableNameValid, TestTableKeys{Name: "fred"}).Execute(fake.Session())
+	internal.HasError(t, err)
+	internal.Assert(t, !errors.Is(err, dynamodb.ErrTransactionCancelled), "unexpected ErrTransactionCancelled")
+}
+
+// Test GetTransaction
+func TestGetTransaction(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("TransactGetItems", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{"Responses": []interface{}{
+			map[string]interface{}{"Item": map[string]interface{}{"name": map[string]interface{}{"S": "fred"}, "description": map[string]interface{}{"S": "Blah"}}},
+			map[string]interface{}{},
+			map[string]interface{}{"Item": map[string]interface{}{"name": map[string]interface{}{"S": "barney"}}},
+		}}
+	})
+
+	// Run the transaction
+	var fred, wilma TestTableFullItem
+	var barney TestTableKeys
+	err := dynamodb.NewGetTransaction().
+		Get(TestTableNameValid, TestTableKeys{Name: "fred"}, &fred).
+		Get(TestTableNameValid, TestTableKeys{Name: "wilma"}, &wilma).
+		Get("owners", TestTableKeys{Name: "barney"}, &barney, "name").
+		Execute(fake.Session())
+
+	// The found items are filled in & the missing one reported
+	internal.Assert(t, errors.Is(err, dynamodb.ErrItemNotFound), "expected ErrItemNotFound, got %v", err)
+	internal.Equals(t, TestTableFullItem{Name: "fred", Description: "Blah"}, fred)
+	internal.Equals(t, TestTableFullItem{}, wilma)
+	internal.Equals(t, TestTableKeys{Name: "barney"}, barney)
+
+	// Check what was sent
+	items := transactItems(fake, "TransactGetItems")
+	internal.Equals(t, 3, len(items))
+	get := items[2].(map[string]interface{})["Get"].(map[string]interface{})
+	internal.Equals(t, "owners", get["TableName"])
+	internal.Equals(t, "#0", get["ProjectionExpression"])
+
+	// Validation
+	var notPointer TestTableFullItem
+	internal.HasError(t, dynamodb.NewGetTransaction().Execute(fake.Session()))
+	internal.HasError(t, dynamodb.NewGetTransaction().Get(TestTableNameValid, TestTableKeys{Name: "fred"}, notPointer).Execute(fake.Session()))
+	internal.HasError(t, dynamodb.NewGetTransaction().Get("", TestTableKeys{Name: "fred"}, &fred).Execute(fake.Session()))
+	internal.Equals(t, 1, len(fake.Calls("TransactGetItems")))
+}
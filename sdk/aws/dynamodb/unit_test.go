@@ -1,10 +1,17 @@
 package dynamodb_test
 
 import (
-	"log"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/bradmccoydev/self-service-sdk/internal"
 	"github.com/bradmccoydev/self-service-sdk/sdk/aws/dynamodb"
 )
@@ -83,17 +90,113 @@ func DeleteTableIfExists(tableName string) error {
 	return err
 }
 
-// TestMain routine for controlling setup/destruction for all tests in this package
-func TestMain(m *testing.M) {
+// FakeHandler handles a single DynamoDB operation, returning the HTTP
+// status & the body to send back
+type FakeHandler func(input map[string]interface{}) (int, interface{})
+
+// FakeCall records a single call made to the fake DynamoDB server
+type FakeCall struct {
+	Operation string
+	Input     map[string]interface{}
+}
+
+// FakeDynamo is a minimal stand-in for the DynamoDB API where each
+// operation is answered by a handler registered by the test
+type FakeDynamo struct {
+	Server   *httptest.Server
+	mu       sync.Mutex
+	handlers map[string]FakeHandler
+	calls    []FakeCall
+}
+
+// NewFakeDynamo starts a fake DynamoDB server
+func NewFakeDynamo() *FakeDynamo {
+
+	fake := &FakeDynamo{handlers: make(map[string]FakeHandler)}
+	fake.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		// Work out the operation & record the call
+		op := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "DynamoDB_20120810.")
+		input := make(map[string]interface{})
+		json.NewDecoder(r.Body).Decode(&input)
+		fake.mu.Lock()
+		fake.calls = append(fake.calls, FakeCall{Operation: op, Input: input})
+		handler, ok := fake.handlers[op]
+		fake.mu.Unlock()
+
+		// Answer it
+		status, body := http.StatusBadRequest, interface{}(FakeError("UnknownOperationException", op))
+		if ok {
+			status, body = handler(input)
+		}
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	}))
+	return fake
+}
+
+// Handle registers the handler for an operation
+func (f *FakeDynamo) Handle(op string, handler FakeHandler) {
+
+	f.mu.Lock()
+	f.handlers[op] = handler
+	f.mu.Unlock()
+}
 
-	// Do we need to do these tests?
-	var doTests bool = internal.PerformAwsTests()
-	if doTests == false {
-		log.Printf("AWS testing variable: %s not set or set to false", internal.TestAwsEnabled)
-		os.Exit(0)
+// Calls returns the calls made for an operation
+func (f *FakeDynamo) Calls(op string) []FakeCall {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var calls []FakeCall
+	for _, c := range f.calls {
+		if c.Operation == op {
+			calls = append(calls, c)
+		}
 	}
+	return calls
+}
+
+// Session creates a session with static credentials that talks to the fake server
+func (f *FakeDynamo) Session() *session.Session {
+
+	return session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(f.Server.URL),
+		Credentials: credentials.NewStaticCredentials("AKIDDYNAMO", "SECRET", ""),
+		MaxRetries:  aws.Int(0),
+	}))
+}
+
+// FakeError builds a DynamoDB style error body
+func FakeError(code string, message string) map[string]interface{} {
+	return map[string]interface{}{"__type": "com.amazonaws.dynamodb.v20120810#" + code, "message": message}
+}
+
+// FakeTable builds a DescribeTable response body
+func FakeTable(name string, status string, indexStatus ...string) map[string]interface{} {
+
+	table := map[string]interface{}{
+		"TableName":   name,
+		"TableStatus": status,
+		"TableArn":    "arn:aws:dynamodb:us-east-1:123456789012:table/" + name,
+	}
+	var indexes []map[string]interface{}
+	for i, s := range indexStatus {
+		indexes = append(indexes, map[string]interface{}{"IndexName": "gsi" + string(rune('a'+i)), "IndexStatus": s})
+	}
+	if len(indexes) > 0 {
+		table["GlobalSecondaryIndexes"] = indexes
+	}
+	return map[string]interface{}{"Table": table}
+}
+
+// TestMain routine for controlling setup/destruction for all tests in this package
+func TestMain(m *testing.M) {
 
-	// Run the various tests then exit
+	// Run the various tests then exit. Tests that need a real AWS
+	// account skip themselves unless AWS testing is enabled.
 	exitVal := m.Run()
 	os.Exit(exitVal)
 }
@@ -0,0 +1,290 @@
+// This file contains all the bits & pieces related to
+// optimistic locking, where a version attribute guards
+// against lost updates from concurrent writers
+
+package dynamodb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+const (
+	// TagName - the struct tag used to mark the version field & how fields are updated
+	TagName string = "dynamo"
+
+	// VersionTagValue - the value of the struct tag that marks the version field
+	VersionTagValue string = "version"
+)
+
+// VersionConflictError - structure used to represent a write that was
+// rejected because the item was changed since the expected version was read
+type VersionConflictError struct {
+	TableName string
+	Attribute string
+	Version   int64
+	cause     error
+}
+
+// Error - This function describes the conflicting write
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("%s for table %s: expected %s to be %d", ErrVersionConflict.Error(), e.TableName, e.Attribute, e.Version)
+}
+
+// Is - This function lets errors.Is match ErrVersionConflict & ErrConditionFailed
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrVersionConflict || target == ErrConditionFailed
+}
+
+// Unwrap - This function returns the underlying Dynamo DB error
+func (e *VersionConflictError) Unwrap() error {
+	return e.cause
+}
+
+// itemVersion - the version field of an item being written
+type itemVersion struct {
+	name    string
+	current int64
+	field   reflect.Value
+}
+
+// findVersion looks for a field tagged `dynamo:"version"`, returning nil
+// if the structure doesn't have one
+func findVersion(input interface{}) (*itemVersion, error) {
+
+	// Resolve the structure
+	val := reflect.ValueOf(input)
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return nil, nil
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	// Look for the tagged field
+	var version *itemVersion
+	for _, f := range storedFields(val) {
+
+		if f.field.Tag.Get(TagName) != VersionTagValue {
+			continue
+		}
+		if version != nil {
+			return nil, newErrorVersionFieldDuplicated(f.field.Name)
+		}
+		if f.name == "" {
+			return nil, newErrorVersionFieldInvalid(f.field.Name)
+		}
+
+		// Grab the current value
+		version = &itemVersion{name: f.name, field: f.value}
+		switch f.value.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			version.current = f.value.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			version.current = int64(f.value.Uint())
+		default:
+			return nil, newErrorVersionFieldInvalid(f.field.Name)
+		}
+	}
+	return version, nil
+}
+
+// storedField - a field of a structure & the attribute it is stored as
+type storedField struct {
+	name      string
+	omitEmpty bool
+	field     reflect.StructField
+	value     reflect.Value
+}
+
+// storedFields returns the fields of a structure, flattening embedded
+// structures the way the encoder does. Fields of the outer structure hide
+// embedded fields stored under the same name. The name is empty for fields
+// that aren't stored.
+func storedFields(val reflect.Value) []storedField {
+
+	var fields []storedField
+	var embedded []reflect.Value
+	seen := make(map[string]bool)
+	typeDef := val.Type()
+	for i := 0; i < typeDef.NumField(); i++ {
+
+		// Embedded structures are read after the outer fields
+		field := typeDef.Field(i)
+		value := val.Field(i)
+		if field.Anonymous && fieldTag(field) != "-" {
+			if value.Kind() == reflect.Ptr && value.Type().Elem().Kind() == reflect.Struct {
+				if value.IsNil() {
+					continue
+				}
+				value = value.Elem()
+			}
+			if value.Kind() == reflect.Struct {
+				embedded = append(embedded, value)
+				continue
+			}
+		}
+		name, omitEmpty := attributeName(field)
+		fields = append(fields, storedField{name: name, omitEmpty: omitEmpty, field: field, value: value})
+		if name != "" {
+			seen[name] = true
+		}
+	}
+	for _, e := range embedded {
+		for _, f := range storedFields(e) {
+			if f.name != "" && seen[f.name] {
+				continue
+			}
+			fields = append(fields, f)
+			if f.name != "" {
+				seen[f.name] = true
+			}
+		}
+	}
+	return fields
+}
+
+// fieldTag returns the tag naming the attribute, dynamodbav wins over json
+func fieldTag(field reflect.StructField) string {
+
+	tag, ok := field.Tag.Lookup("dynamodbav")
+	if !ok {
+		tag = field.Tag.Get("json")
+	}
+	return tag
+}
+
+// attributeName returns the name a field is stored under, honouring the
+// dynamodbav & json tags, & whether it is omitted when empty. The name
+// is empty if the field isn't stored.
+func attributeName(field reflect.StructField) (string, bool) {
+
+	// Unexported fields are never stored
+	if field.PkgPath != "" {
+		return "", false
+	}
+
+	// Find the tag
+	tag := fieldTag(field)
+	if tag == "-" {
+		return "", false
+	}
+	parts := strings.Split(tag, ",")
+	name := parts[0]
+	if name == "" {
+		name = field.Name
+	}
+	omitEmpty := false
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty
+}
+
+// next returns the version to write
+func (v *itemVersion) next() int64 {
+	return v.current + 1
+}
+
+// condition makes sure the stored version hasn't changed, or that there
+// isn't one for a new item
+func (v *itemVersion) condition() expression.ConditionBuilder {
+
+	if v.current == 0 {
+		return expression.AttributeNotExists(expression.Name(v.name))
+	}
+	return expression.Name(v.name).Equal(expression.Value(v.current))
+}
+
+// apply sets the new version in a marshalled item
+func (v *itemVersion) apply(item map[string]*dynamodb.AttributeValue) error {
+
+	av, err := dynamodbattribute.Marshal(v.next())
+	if err != nil {
+		return err
+	}
+	item[v.name] = av
+	return nil
+}
+
+// commit updates the version field of the caller's structure, when it can be set
+func (v *itemVersion) commit() {
+
+	if !v.field.CanSet() {
+		return
+	}
+	switch v.field.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.field.SetUint(uint64(v.next()))
+	default:
+		v.field.SetInt(v.next())
+	}
+}
+
+// newVersionError maps a failed condition to a VersionConflictError. When
+// the version wasn't the only condition the stored version is read back to
+// find out if it was the one that failed.
+func newVersionError(ctx context.Context, svc *dynamodb.DynamoDB, tableName string, item map[string]*dynamodb.AttributeValue, version *itemVersion, conditions []Condition, err error) error {
+
+	err = newConditionError(tableName, err)
+	failed, ok := err.(*ConditionFailedError)
+	if version == nil || !ok {
+		return err
+	}
+	if len(conditions) > 0 {
+		stored, readErr := storedVersion(ctx, svc, tableName, item, version.name)
+		if readErr != nil || stored == version.current {
+			return failed
+		}
+	}
+	return &VersionConflictError{TableName: tableName, Attribute: version.name, Version: version.current, cause: failed.cause}
+}
+
+// storedVersion reads the version currently stored for the item, 0 if the
+// item or its version doesn't exist. The item may hold more than its keys.
+func storedVersion(ctx context.Context, svc *dynamodb.DynamoDB, tableName string, item map[string]*dynamodb.AttributeValue, name string) (int64, error) {
+
+	// Pick the keys out of the item
+	table, err := svc.DescribeTableWithContext(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(tableName)})
+	if err != nil {
+		return 0, err
+	}
+	if table.Table == nil {
+		return 0, newErrorTableDetailsNotProvided()
+	}
+	keys := make(map[string]*dynamodb.AttributeValue)
+	for _, k := range table.Table.KeySchema {
+		keys[aws.StringValue(k.AttributeName)] = item[aws.StringValue(k.AttributeName)]
+	}
+
+	// Read the version
+	result, err := svc.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		Key:                      keys,
+		ConsistentRead:           aws.Bool(true),
+		ProjectionExpression:     aws.String("#v"),
+		ExpressionAttributeNames: map[string]*string{"#v": aws.String(name)},
+		TableName:                aws.String(tableName),
+	})
+	if err != nil {
+		return 0, err
+	}
+	var stored int64
+	av, ok := result.Item[name]
+	if !ok {
+		return 0, nil
+	}
+	err = dynamodbattribute.Unmarshal(av, &stored)
+	return stored, err
+}
@@ -0,0 +1,291 @@
+package dynamodb_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bradmccoydev/self-service-sdk/internal"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/dynamodb"
+)
+
+// versionedItem is a test item using optimistic locking
+type versionedItem struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Version     int64  `json:"version" dynamo:"version"`
+}
+
+// Test CreateItem & UpdateItem with a version field
+func TestVersionedWrites(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	ok := func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{}
+	}
+	fake.Handle("PutItem", ok)
+	fake.Handle("UpdateItem", ok)
+
+	// A new item must not exist yet & starts at version 1
+	item := versionedItem{Name: "fred", Description: "Blah"}
+	err := dynamodb.CreateItem(fake.Session(), TestTableNameValid, &item)
+	internal.NoError(t, err)
+	input := fake.Calls("PutItem")[0].Input
+	internal.Equals(t, "attribute_not_exists (#0)", input["ConditionExpression"])
+	internal.Equals(t, map[string]interface{}{"#0": "version"}, input["ExpressionAttributeNames"])
+	internal.Equals(t, map[string]interface{}{"N": "1"}, input["Item"].(map[string]interface{})["version"])
+	internal.Equals(t, int64(1), item.Version)
+
+	// Replacing it expects the stored version, combined with other conditions
+	err = dynamodb.CreateItem(fake.Session(), TestTableNameValid, &item, dynamodb.IfExists("name"))
+	internal.NoError(t, err)
+	input = fake.Calls("PutItem")[1].Input
+	internal.Equals(t, "(attribute_exists (#0)) AND (#1 = :0)", input["ConditionExpression"])
+	internal.Equals(t, map[string]interface{}{":0": map[string]interface{}{"N": "1"}}, input["ExpressionAttributeValues"])
+	internal.Equals(t, map[string]interface{}{"N": "2"}, input["Item"].(map[string]interface{})["version"])
+	internal.Equals(t, int64(2), item.Version)
+
+	// Updating it sets the next version, even when not given a pointer
+	err = dynamodb.UpdateItem(fake.Session(), TestTableNameValid, TestTableKeys{Name: "fred"}, versionedItem{Name: "fred", Description: "New", Version: 2})
+	internal.NoError(t, err)
+	input = fake.Calls("UpdateItem")[0].Input
+	names := input["ExpressionAttributeNames"].(map[string]interface{})
+	values := input["ExpressionAttributeValues"].(map[string]interface{})
+	update := input["UpdateExpression"].(string)
+	condition := input["ConditionExpression"].(string)
+	for name, attr := range names {
+		if attr != "version" {
+			continue
+		}
+		internal.Assert(t, strings.Count(update, name) == 1, "version set more than once: %s", update)
+		internal.Assert(t, strings.HasPrefix(condition, name+" = "), "unexpected condition: %s", condition)
+		internal.Equals(t, map[string]interface{}{"N": "2"}, values[strings.TrimPrefix(condition, name+" = ")])
+	}
+	internal.Assert(t, len(values) == 3, "unexpected values: %v", values)
+}
+
+// Test a version conflict
+func TestVersionConflict(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("PutItem", conditionFailed)
+	fake.Handle("UpdateItem", conditionFailed)
+
+	// Conflicts are reported & the version is left alone
+	item := versionedItem{Name: "fred", Version: 4}
+	for _, err := range []error{
+		dynamodb.CreateItem(fake.Session(), TestTableNameValid, &item),
+		dynamodb.UpdateItem(fake.Session(), TestTableNameValid, TestTableKeys{Name: "fred"}, &item),
+	} {
+		internal.Assert(t, errors.Is(err, dynamodb.ErrVersionConflict), "expected ErrVersionConflict, got %v", err)
+		internal.Assert(t, errors.Is(err, dynamodb.ErrConditionFailed), "expected ErrConditionFailed, got %v", err)
+		var conflict *dynamodb.VersionConflictError
+		internal.Assert(t, errors.As(err, &conflict), "expected a VersionConflictError, got %T", err)
+		internal.Equals(t, "version", conflict.Attribute)
+		internal.Equals(t, int64(4), conflict.Version)
+	}
+	internal.Equals(t, int64(4), item.Version)
+
+	// Items without a version report a plain failed condition
+	err := dynamodb.CreateItem(fake.Session(), TestTableNameValid, TestTableFullItem{Name: "fred"}, dynamodb.IfNotExists("name"))
+	internal.Assert(t, errors.Is(err, dynamodb.ErrConditionFailed), "expected ErrConditionFailed, got %v", err)
+	internal.Assert(t, !errors.Is(err, dynamodb.ErrVersionConflict), "unexpected ErrVersionConflict")
+}
+
+// Test a failed write with other conditions only reports a version conflict
+// when the stored version has changed
+func TestVersionConflictWithConditions(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("PutItem", conditionFailed)
+	fake.Handle("UpdateItem", conditionFailed)
+	fake.Handle("TransactWriteItems", func(input map[string]interface{}) (int, interface{}) {
+		body := FakeError("TransactionCanceledException", "Transaction cancelled, please refer cancellation reasons for specific reasons [ConditionalCheckFailed]")
+		body["CancellationReasons"] = []interface{}{map[string]interface{}{"Code": "ConditionalCheckFailed"}}
+		return http.StatusBadRequest, body
+	})
+	fake.Handle("DescribeTable", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{"Table": map[string]interface{}{
+			"TableName": TestTableNameValid,
+			"KeySchema": []interface{}{map[string]interface{}{"AttributeName": "name", "KeyType": "HASH"}},
+		}}
+	})
+	stored := func(version string) FakeHandler {
+		return func(input map[string]interface{}) (int, interface{}) {
+			if version == "" {
+				return http.StatusOK, map[string]interface{}{}
+			}
+			return http.StatusOK, map[string]interface{}{"Item": map[string]interface{}{"version": map[string]interface{}{"N": version}}}
+		}
+	}
+
+	// Setup test data
+	tests := []struct {
+		desc           string
+		stored         string
+		version        int64
+		expectConflict bool
+	}{
+		{"Version changed", "5", 4, true},
+		{"Version unchanged", "4", 4, false},
+		{"Item created since", "1", 0, true},
+		{"Item still missing", "", 0, false},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			fake.Handle("GetItem", stored(test.stored))
+			item := versionedItem{Name: "fred", Description: "Blah", Version: test.version}
+			for _, err := range []error{
+				dynamodb.CreateItem(fake.Session(), TestTableNameValid, &item, dynamodb.IfAttributeEquals("description", "Blah")),
+				dynamodb.UpdateItem(fake.Session(), TestTableNameValid, TestTableKeys{Name: "fred"}, &item, dynamodb.IfExists("name")),
+				dynamodb.NewWriteTransaction().Put(TestTableNameValid, &item, dynamodb.IfExists("name")).Execute(fake.Session()),
+			} {
+				internal.Assert(t, errors.Is(err, dynamodb.ErrConditionFailed), "expected ErrConditionFailed, got %v", err)
+				internal.Equals(t, test.expectConflict, errors.Is(err, dynamodb.ErrVersionConflict))
+			}
+		})
+	}
+
+	// The stored version is read using just the keys
+	input := fake.Calls("GetItem")[0].Input
+	internal.Equals(t, map[string]interface{}{"name": map[string]interface{}{"S": "fred"}}, input["Key"])
+	internal.Equals(t, true, input["ConsistentRead"])
+
+	// Without other conditions the version is the one that failed
+	before := len(fake.Calls("GetItem"))
+	err := dynamodb.CreateItem(fake.Session(), TestTableNameValid, &versionedItem{Name: "fred", Version: 4})
+	internal.Assert(t, errors.Is(err, dynamodb.ErrVersionConflict), "expected ErrVersionConflict, got %v", err)
+	internal.Equals(t, before, len(fake.Calls("GetItem")))
+}
+
+// Test transactions honour the version field
+func TestVersionedTransaction(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("TransactWriteItems", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{}
+	})
+
+	// Put a new item & update an existing one
+	created := versionedItem{Name: "fred", Description: "Blah"}
+	updated := versionedItem{Name: "nerk", Description: "New", Version: 3}
+	tx := dynamodb.NewWriteTransaction().
+		Put(TestTableNameValid, &created).
+		Update(TestTableNameValid, TestTableKeys{Name: "nerk"}, &updated)
+	internal.NoError(t, tx.Execute(fake.Session()))
+	items := transactItems(fake, "TransactWriteItems")
+	put := items[0].(map[string]interface{})["Put"].(map[string]interface{})
+	internal.Equals(t, "attribute_not_exists (#0)", put["ConditionExpression"])
+	internal.Equals(t, map[string]interface{}{"N": "1"}, put["Item"].(map[string]interface{})["version"])
+	update := items[1].(map[string]interface{})["Update"].(map[string]interface{})
+	condition := update["ConditionExpression"].(string)
+	values := update["ExpressionAttributeValues"].(map[string]interface{})
+	internal.Equals(t, map[string]interface{}{"N": "3"}, values[condition[strings.Index(condition, ":"):]])
+	internal.Equals(t, int64(1), created.Version)
+	internal.Equals(t, int64(4), updated.Version)
+
+	// A cancelled transaction leaves the versions alone
+	fake.Handle("TransactWriteItems", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusBadRequest, FakeError("TransactionCanceledException", "Transaction cancelled")
+	})
+	err := dynamodb.NewWriteTransaction().Put(TestTableNameValid, &created).Execute(fake.Session())
+	internal.HasError(t, err)
+	internal.Equals(t, int64(1), created.Version)
+}
+
+// Test a cancelled transaction reports the versioned writes whose version
+// didn't match as version conflicts
+func TestVersionedTransactionConflict(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("TransactWriteItems", func(input map[string]interface{}) (int, interface{}) {
+		body := FakeError("TransactionCanceledException", "Transaction cancelled, please refer cancellation reasons for specific reasons [ConditionalCheckFailed, None]")
+		body["CancellationReasons"] = []interface{}{
+			map[string]interface{}{"Code": "ConditionalCheckFailed", "Message": "The conditional request failed"},
+			map[string]interface{}{"Code": "None"},
+		}
+		return http.StatusBadRequest, body
+	})
+
+	// The versioned put conflicts
+	item := versionedItem{Name: "fred", Version: 4}
+	err := dynamodb.NewWriteTransaction().
+		Put(TestTableNameValid, &item).
+		Delete(TestTableNameValid, TestTableKeys{Name: "nerk"}).
+		Execute(fake.Session())
+	internal.Assert(t, errors.Is(err, dynamodb.ErrVersionConflict), "expected ErrVersionConflict, got %v", err)
+	internal.Assert(t, errors.Is(err, dynamodb.ErrConditionFailed), "expected ErrConditionFailed, got %v", err)
+	var cancelled *dynamodb.TransactionCancelledError
+	internal.Assert(t, errors.As(err, &cancelled), "expected a TransactionCancelledError, got %T", err)
+	var conflict *dynamodb.VersionConflictError
+	internal.Assert(t, errors.As(cancelled.Operations[0], &conflict), "expected a VersionConflictError, got %v", cancelled.Operations[0])
+	internal.Equals(t, "version", conflict.Attribute)
+	internal.Equals(t, int64(4), conflict.Version)
+	internal.Equals(t, int64(4), item.Version)
+
+	// An unversioned write only fails its condition
+	err = dynamodb.NewWriteTransaction().
+		Put(TestTableNameValid, TestTableKeys{Name: "fred"}, dynamodb.IfNotExists("name")).
+		Delete(TestTableNameValid, TestTableKeys{Name: "nerk"}).
+		Execute(fake.Session())
+	internal.Assert(t, errors.Is(err, dynamodb.ErrConditionFailed), "expected ErrConditionFailed, got %v", err)
+	internal.Assert(t, !errors.Is(err, dynamodb.ErrVersionConflict), "unexpected ErrVersionConflict")
+}
+
+// Test invalid version fields
+func TestVersionFieldInvalid(t *testing.T) {
+
+	// Setup test data
+	type textVersion struct {
+		Name    string `json:"name"`
+		Version string `json:"version" dynamo:"version"`
+	}
+	type twoVersions struct {
+		Name    string `json:"name"`
+		Version int    `json:"version" dynamo:"version"`
+		Other   int    `json:"other" dynamo:"version"`
+	}
+	type skippedVersion struct {
+		Name    string `json:"name"`
+		Version int    `json:"-" dynamo:"version"`
+	}
+	tests := []struct {
+		desc  string
+		input interface{}
+	}{
+		{"Not an integer", textVersion{Name: "fred"}},
+		{"Two version fields", twoVersions{Name: "fred"}},
+		{"Version not stored", skippedVersion{Name: "fred"}},
+	}
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+			internal.HasError(t, dynamodb.CreateItem(fake.Session(), TestTableNameValid, test.input))
+			internal.HasError(t, dynamodb.UpdateItem(fake.Session(), TestTableNameValid, TestTableKeys{Name: "fred"}, test.input))
+		})
+	}
+
+	// Nothing should have been sent
+	internal.Equals(t, 0, len(fake.Calls("PutItem"))+len(fake.Calls("UpdateItem")))
+}
@@ -0,0 +1,222 @@
+// This file contains all the bits & pieces related to
+// waiting for tables & backups to reach a particular state
+
+package dynamodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/clients"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/waiter"
+)
+
+const (
+	// DefaultWaitTimeout - how long to wait before giving up
+	DefaultWaitTimeout time.Duration = 5 * time.Minute
+
+	// DefaultRestoreWaitTimeout - how long to wait for a restored table, as
+	// restores take much longer than other changes
+	DefaultRestoreWaitTimeout time.Duration = time.Hour
+
+	// DefaultWaitDelay - the delay before the first re-check
+	DefaultWaitDelay time.Duration = 500 * time.Millisecond
+
+	// DefaultWaitMaxDelay - the longest delay between checks
+	DefaultWaitMaxDelay time.Duration = 10 * time.Second
+)
+
+// WaitConf - structure used to represent how to poll while waiting.
+// The delay doubles after each check up to MaxDelay. Zero values use
+// the defaults above.
+type WaitConf = waiter.Conf
+
+// WaitUntilTableActive - This function waits until the table & all of its
+// global secondary indexes are ACTIVE. A table that doesn't exist yet is
+// waited on too, as a new table may not be described straight away.
+//
+//   Parameters:
+//     ctx: the context used to cancel the wait
+//     sess: a valid AWS session
+//     tableName: the name of the table
+//     conf: the timeout & backoff to use
+//
+//   Example:
+//     err := WaitUntilTableActive(ctx, mySession, "fred", WaitConf{Timeout: time.Minute})
+func WaitUntilTableActive(ctx context.Context, sess *session.Session, tableName string, conf WaitConf) error {
+
+	// Sanity check
+	if tableName == "" {
+		return newErrorTableNameNotProvided()
+	}
+
+	// Wait for it
+	svc := clients.DynamoDB(sess)
+	return wait(ctx, conf, "table "+tableName, dynamodb.TableStatusActive, func(ctx context.Context) (bool, error) {
+		result, err := svc.DescribeTableWithContext(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(tableName)})
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeResourceNotFoundException {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return tableActive(result.Table), nil
+	})
+}
+
+// WaitUntilTableDeleted - This function waits until the table no longer exists
+//
+//   Parameters:
+//     ctx: the context used to cancel the wait
+//     sess: a valid AWS session
+//     tableName: the name of the table
+//     conf: the timeout & backoff to use
+//
+//   Example:
+//     err := WaitUntilTableDeleted(ctx, mySession, "fred", WaitConf{})
+func WaitUntilTableDeleted(ctx context.Context, sess *session.Session, tableName string, conf WaitConf) error {
+
+	// Sanity check
+	if tableName == "" {
+		return newErrorTableNameNotProvided()
+	}
+
+	// Wait for it
+	svc := clients.DynamoDB(sess)
+	return wait(ctx, conf, "table "+tableName, "DELETED", func(ctx context.Context) (bool, error) {
+		_, err := svc.DescribeTableWithContext(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(tableName)})
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeResourceNotFoundException {
+			return true, nil
+		}
+		return false, err
+	})
+}
+
+// WaitUntilBackupAvailable - This function waits until the backup is AVAILABLE
+//
+//   Parameters:
+//     ctx: the context used to cancel the wait
+//     sess: a valid AWS session
+//     backupArn: the ARN of the backup
+//     conf: the timeout & backoff to use
+//
+//   Example:
+//     err := WaitUntilBackupAvailable(ctx, mySession, arn, WaitConf{})
+func WaitUntilBackupAvailable(ctx context.Context, sess *session.Session, backupArn string, conf WaitConf) error {
+
+	// Sanity check
+	if backupArn == "" {
+		return newErrorBackupArnNotProvided()
+	}
+
+	// Wait for it
+	svc := clients.DynamoDB(sess)
+	return wait(ctx, conf, "backup "+backupArn, dynamodb.BackupStatusAvailable, func(ctx context.Context) (bool, error) {
+		result, err := svc.DescribeBackupWithContext(ctx, &dynamodb.DescribeBackupInput{BackupArn: aws.String(backupArn)})
+		if err != nil {
+			return false, err
+		}
+		if result.BackupDescription == nil || result.BackupDescription.BackupDetails == nil {
+			return false, newErrorBackupDetailsNotProvided()
+		}
+		status := aws.StringValue(result.BackupDescription.BackupDetails.BackupStatus)
+		if status == dynamodb.BackupStatusDeleted {
+			return false, newErrorBackupDeleted(backupArn)
+		}
+		return status == dynamodb.BackupStatusAvailable, nil
+	})
+}
+
+// WaitUntilBackupDeleted - This function waits until the backup no longer exists
+//
+//   Parameters:
+//     ctx: the context used to cancel the wait
+//     sess: a valid AWS session
+//     backupArn: the ARN of the backup
+//     conf: the timeout & backoff to use
+//
+//   Example:
+//     err := WaitUntilBackupDeleted(ctx, mySession, arn, WaitConf{})
+func WaitUntilBackupDeleted(ctx context.Context, sess *session.Session, backupArn string, conf WaitConf) error {
+
+	// Sanity check
+	if backupArn == "" {
+		return newErrorBackupArnNotProvided()
+	}
+
+	// Wait for it
+	svc := clients.DynamoDB(sess)
+	return wait(ctx, conf, "backup "+backupArn, dynamodb.BackupStatusDeleted, func(ctx context.Context) (bool, error) {
+		result, err := svc.DescribeBackupWithContext(ctx, &dynamodb.DescribeBackupInput{BackupArn: aws.String(backupArn)})
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeBackupNotFoundException {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if result.BackupDescription == nil || result.BackupDescription.BackupDetails == nil {
+			return false, newErrorBackupDetailsNotProvided()
+		}
+		return aws.StringValue(result.BackupDescription.BackupDetails.BackupStatus) == dynamodb.BackupStatusDeleted, nil
+	})
+}
+
+// WaitUntilPointInTimeRecovery - This function waits until point in time
+// recovery for the table is ENABLED (or DISABLED)
+//
+//   Parameters:
+//     ctx: the context used to cancel the wait
+//     sess: a valid AWS session
+//     tableName: the name of the table
+//     enabled: whether to wait for it to be on or off
+//     conf: the timeout & backoff to use
+//
+//   Example:
+//     err := WaitUntilPointInTimeRecovery(ctx, mySession, "fred", true, WaitConf{})
+func WaitUntilPointInTimeRecovery(ctx context.Context, sess *session.Session, tableName string, enabled bool, conf WaitConf) error {
+
+	// Sanity check
+	if tableName == "" {
+		return newErrorTableNameNotProvided()
+	}
+
+	// Wait for it
+	state := dynamodb.PointInTimeRecoveryStatusDisabled
+	if enabled {
+		state = dynamodb.PointInTimeRecoveryStatusEnabled
+	}
+	svc := clients.DynamoDB(sess)
+	return wait(ctx, conf, "point in time recovery of table "+tableName, state, func(ctx context.Context) (bool, error) {
+		result, err := svc.DescribeContinuousBackupsWithContext(ctx, &dynamodb.DescribeContinuousBackupsInput{TableName: aws.String(tableName)})
+		if err != nil {
+			return false, err
+		}
+		pitr := pointInTimeRecovery(result.ContinuousBackupsDescription)
+		return pitr != nil && aws.StringValue(pitr.PointInTimeRecoveryStatus) == state, nil
+	})
+}
+
+// tableActive checks if the table & its global secondary indexes are active
+func tableActive(table *dynamodb.TableDescription) bool {
+
+	if table == nil || aws.StringValue(table.TableStatus) != dynamodb.TableStatusActive {
+		return false
+	}
+	for _, idx := range table.GlobalSecondaryIndexes {
+		if aws.StringValue(idx.IndexStatus) != dynamodb.IndexStatusActive {
+			return false
+		}
+	}
+	return true
+}
+
+// wait polls check using the package defaults for anything not configured
+func wait(ctx context.Context, conf WaitConf, resource string, state string, check waiter.Check) error {
+
+	defaults := WaitConf{Timeout: DefaultWaitTimeout, Delay: DefaultWaitDelay, MaxDelay: DefaultWaitMaxDelay}
+	return waiter.Wait(ctx, conf.WithDefaults(defaults), resource, state, check)
+}
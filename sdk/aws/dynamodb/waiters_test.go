@@ -0,0 +1,141 @@
+package dynamodb_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bradmccoydev/self-service-sdk/internal"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/dynamodb"
+)
+
+// fastWait keeps the tests quick
+var fastWait = dynamodb.WaitConf{Timeout: time.Second, Delay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+// tableStatus returns a DescribeTable response with the given statuses
+func tableStatus(status string, indexStatus ...string) func() (int, interface{}) {
+	return func() (int, interface{}) {
+		return http.StatusOK, FakeTable(TestTableNameValid, status, indexStatus...)
+	}
+}
+
+// tableMissing returns a DescribeTable not found response
+func tableMissing() (int, interface{}) {
+	return http.StatusBadRequest, FakeError("ResourceNotFoundException", "Requested resource not found")
+}
+
+// accessDenied returns an access denied response
+func accessDenied() (int, interface{}) {
+	return http.StatusBadRequest, FakeError("AccessDeniedException", "denied")
+}
+
+// Test WaitUntilTableActive
+func TestWaitUntilTableActive(t *testing.T) {
+
+	// Setup test data
+	tests := []struct {
+		desc          string
+		tableName     string
+		responses     []func() (int, interface{})
+		conf          dynamodb.WaitConf
+		expectErr     bool
+		expectedErr   error
+		expectedCalls int
+	}{
+		{"No table name", "", nil, fastWait, true, nil, 0},
+		{"Already active", TestTableNameValid, []func() (int, interface{}){tableStatus("ACTIVE")}, fastWait, false, nil, 1},
+		{"Becomes active", TestTableNameValid, []func() (int, interface{}){tableStatus("CREATING"), tableStatus("CREATING"), tableStatus("ACTIVE")}, fastWait, false, nil, 3},
+		{"Waits for indexes", TestTableNameValid, []func() (int, interface{}){tableStatus("ACTIVE", "CREATING", "ACTIVE"), tableStatus("ACTIVE", "ACTIVE", "ACTIVE")}, fastWait, false, nil, 2},
+		{"Not described yet", TestTableNameValid, []func() (int, interface{}){tableMissing, tableStatus("CREATING"), tableStatus("ACTIVE")}, fastWait, false, nil, 3},
+		{"Never created", TestTableNameInvalid, []func() (int, interface{}){tableMissing}, dynamodb.WaitConf{Timeout: 50 * time.Millisecond, Delay: time.Millisecond}, true, context.DeadlineExceeded, -1},
+		{"Access denied", TestTableNameValid, []func() (int, interface{}){accessDenied}, fastWait, true, nil, 1},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Setup backend
+			fake := NewFakeDynamo()
+			defer fake.Server.Close()
+			if test.responses != nil {
+				fake.Handle("DescribeTable", internal.Sequence(test.responses...))
+			}
+
+			// Run the test
+			err := dynamodb.WaitUntilTableActive(context.Background(), fake.Session(), test.tableName, test.conf)
+			if test.expectErr {
+				internal.HasError(t, err)
+				if test.expectedErr != nil {
+					internal.Assert(t, errors.Is(err, test.expectedErr), "expected %v but got %v", test.expectedErr, err)
+				}
+			} else {
+				internal.NoError(t, err)
+			}
+			if test.expectedCalls >= 0 {
+				internal.Equals(t, test.expectedCalls, len(fake.Calls("DescribeTable")))
+			}
+		})
+	}
+}
+
+// Test WaitUntilTableDeleted
+func TestWaitUntilTableDeleted(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	fake.Handle("DescribeTable", internal.Sequence(tableStatus("DELETING"), tableStatus("DELETING"), tableMissing))
+
+	// Run the test
+	err := dynamodb.WaitUntilTableDeleted(context.Background(), fake.Session(), TestTableNameValid, fastWait)
+	internal.NoError(t, err)
+	internal.Equals(t, 3, len(fake.Calls("DescribeTable")))
+
+	// Errors other than not found are returned
+	fake.Handle("DescribeTable", internal.Sequence(accessDenied))
+	err = dynamodb.WaitUntilTableDeleted(context.Background(), fake.Session(), TestTableNameValid, fastWait)
+	internal.HasError(t, err)
+	err = dynamodb.WaitUntilTableDeleted(context.Background(), fake.Session(), "", fastWait)
+	internal.HasError(t, err)
+}
+
+// Test CreateTableWithContext & DeleteTableWithContext wait for the table
+func TestCreateDeleteTableWithContext(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeDynamo()
+	defer fake.Server.Close()
+	sess := fake.Session()
+	fake.Handle("CreateTable", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{"TableDescription": FakeTable(TestTableNameValid, "CREATING")["Table"]}
+	})
+	fake.Handle("DescribeTable", internal.Sequence(tableStatus("CREATING"), tableStatus("ACTIVE")))
+
+	// Create
+	err := dynamodb.CreateTableWithContext(context.Background(), sess, TestTableConf, TestTableAttribs, fastWait)
+	internal.NoError(t, err)
+	internal.Equals(t, 1, len(fake.Calls("CreateTable")))
+	internal.Equals(t, 2, len(fake.Calls("DescribeTable")))
+
+	// Delete
+	fake.Handle("DeleteTable", func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{"TableDescription": FakeTable(TestTableNameValid, "DELETING")["Table"]}
+	})
+	fake.Handle("DescribeTable", internal.Sequence(tableStatus("DELETING"), tableMissing))
+	err = dynamodb.DeleteTableWithContext(context.Background(), sess, TestTableNameValid, fastWait)
+	internal.NoError(t, err)
+	internal.Equals(t, 1, len(fake.Calls("DeleteTable")))
+	internal.Equals(t, 4, len(fake.Calls("DescribeTable")))
+
+	// A failed request doesn't wait
+	fake.Handle("DeleteTable", func(input map[string]interface{}) (int, interface{}) {
+		return tableMissing()
+	})
+	err = dynamodb.DeleteTableWithContext(context.Background(), sess, TestTableNameInvalid, fastWait)
+	internal.HasError(t, err)
+	internal.Equals(t, 4, len(fake.Calls("DescribeTable")))
+}
@@ -0,0 +1,361 @@
+// This file contains all the bits & pieces related to
+// uploading, downloading, inspecting & listing objects
+// in S3
+
+package s3
+
+import (
+	"io"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/clients"
+)
+
+// UploadConf - structure used to represent the optional settings for an upload
+type UploadConf struct {
+	ContentType string
+	Metadata    map[string]string
+}
+
+// ObjectPage - structure used to represent a single page of a listing.
+// NextToken is empty once the last page has been read.
+type ObjectPage struct {
+	Objects   []*s3.Object
+	Prefixes  []string
+	NextToken string
+}
+
+// ListConf - structure used to represent the settings for a listing.
+// A MaxKeys of zero uses the S3 default (1000).
+type ListConf struct {
+	Prefix    string
+	Delimiter string
+	MaxKeys   int64
+	Token     string
+}
+
+// UploadObject - This function uploads an object, large objects are
+// automatically uploaded in parts
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     bucket: the name of the bucket
+//     key: the key of the object
+//     body: the contents of the object
+//     conf: the optional content type & metadata
+//
+//   Example:
+//     result, err := UploadObject(mySession, "artifacts", "services/fred.zip", file, UploadConf{})
+func UploadObject(sess *session.Session, bucket string, key string, body io.Reader, conf UploadConf) (*s3manager.UploadOutput, error) {
+
+	// Sanity check
+	if bucket == "" {
+		return nil, newErrorBucketNameNotProvided()
+	}
+	if key == "" {
+		return nil, newErrorObjectKeyNotProvided()
+	}
+	if body == nil {
+		return nil, newErrorObjectBodyNotProvided()
+	}
+
+	// Build the input params
+	params := &s3manager.UploadInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   body,
+	}
+	if conf.ContentType != "" {
+		params.ContentType = aws.String(conf.ContentType)
+	}
+	if len(conf.Metadata) > 0 {
+		params.Metadata = aws.StringMap(conf.Metadata)
+	}
+
+	// Create the uploader
+	uploader := s3manager.NewUploader(sess)
+
+	// Make the call to S3
+	result, err := uploader.Upload(params)
+
+	// Return the result
+	return result, err
+}
+
+// UploadFile - This function uploads a local file
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     bucket: the name of the bucket
+//     key: the key of the object
+//     path: the path of the file to upload
+//     conf: the optional content type & metadata
+//
+//   Example:
+//     result, err := UploadFile(mySession, "artifacts", "services/fred.zip", "/tmp/fred.zip", UploadConf{})
+func UploadFile(sess *session.Session, bucket string, key string, path string, conf UploadConf) (*s3manager.UploadOutput, error) {
+
+	// Sanity check
+	if path == "" {
+		return nil, newErrorFilePathNotProvided()
+	}
+
+	// Open the file
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// Upload it
+	return UploadObject(sess, bucket, key, file, conf)
+}
+
+// DownloadObject - This function downloads an object
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     bucket: the name of the bucket
+//     key: the key of the object
+//     w: where to write the contents of the object
+//
+//   Example:
+//     size, err := DownloadObject(mySession, "artifacts", "services/fred.zip", &buf)
+func DownloadObject(sess *session.Session, bucket string, key string, w io.Writer) (int64, error) {
+
+	// Sanity check
+	if bucket == "" {
+		return 0, newErrorBucketNameNotProvided()
+	}
+	if key == "" {
+		return 0, newErrorObjectKeyNotProvided()
+	}
+	if w == nil {
+		return 0, newErrorWriterNotProvided()
+	}
+
+	// Build the input params
+	params := &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+
+	// Create the S3 client
+	svc := clients.S3(sess)
+
+	// Make the call to S3
+	result, err := svc.GetObject(params)
+	if err != nil {
+		return 0, err
+	}
+	defer result.Body.Close()
+
+	// Copy the contents
+	size, err := io.Copy(w, result.Body)
+
+	// Return the result
+	return size, err
+}
+
+// DownloadFile - This function downloads an object to a local file
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     bucket: the name of the bucket
+//     key: the key of the object
+//     path: the path of the file to create
+//
+//   Example:
+//     size, err := DownloadFile(mySession, "artifacts", "services/fred.zip", "/tmp/fred.zip")
+func DownloadFile(sess *session.Session, bucket string, key string, path string) (int64, error) {
+
+	// Sanity check
+	if path == "" {
+		return 0, newErrorFilePathNotProvided()
+	}
+
+	// Create the file
+	file, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+
+	// Download to it, removing the file if that fails
+	size, err := DownloadObject(sess, bucket, key, file)
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return size, err
+}
+
+// HeadObject - This function retrieves the metadata about an object
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     bucket: the name of the bucket
+//     key: the key of the object
+//
+//   Example:
+//     result, err := HeadObject(mySession, "artifacts", "services/fred.zip")
+func HeadObject(sess *session.Session, bucket string, key string) (*s3.HeadObjectOutput, error) {
+
+	// Sanity check
+	if bucket == "" {
+		return nil, newErrorBucketNameNotProvided()
+	}
+	if key == "" {
+		return nil, newErrorObjectKeyNotProvided()
+	}
+
+	// Build the input params
+	params := &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+
+	// Create the S3 client
+	svc := clients.S3(sess)
+
+	// Make the call to S3
+	result, err := svc.HeadObject(params)
+
+	// Return the result
+	return result, err
+}
+
+// ObjectExists - This function checks if the specified object exists
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     bucket: the name of the bucket
+//     key: the key of the object
+//
+//   Example:
+//     val, err := ObjectExists(mySession, "artifacts", "services/fred.zip")
+func ObjectExists(sess *session.Session, bucket string, key string) (bool, error) {
+
+	// Get the object details
+	_, err := HeadObject(sess, bucket, key)
+	if err != nil {
+
+		// Check error details to check if "real" error. HEAD responses
+		// have no body so the error code is derived from the status.
+		if aerr, ok := err.(awserr.RequestFailure); ok {
+			switch aerr.Code() {
+			case s3.ErrCodeNoSuchKey, "NotFound":
+				return false, nil
+			default:
+				return false, err
+			}
+		}
+		return false, err
+	}
+
+	// Return it
+	return true, nil
+}
+
+// ListObjects - This function retrieves a single page of objects.
+// Pass the NextToken of the returned page as the Token of the
+// next call to read the following page.
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     bucket: the name of the bucket
+//     conf: the prefix, delimiter, page size & continuation token
+//
+//   Example:
+//     page, err := ListObjects(mySession, "artifacts", ListConf{Prefix: "services/", MaxKeys: 100})
+func ListObjects(sess *session.Session, bucket string, conf ListConf) (ObjectPage, error) {
+
+	// Sanity check
+	var page ObjectPage
+	if bucket == "" {
+		return page, newErrorBucketNameNotProvided()
+	}
+	if conf.MaxKeys < 0 {
+		return page, newErrorMaxKeysInvalid()
+	}
+
+	// Create the S3 client
+	svc := clients.S3(sess)
+
+	// Make the call to S3
+	result, err := svc.ListObjectsV2(listObjectsInput(bucket, conf))
+	if err != nil {
+		return page, err
+	}
+
+	// Build the page
+	page.Objects = result.Contents
+	for _, p := range result.CommonPrefixes {
+		page.Prefixes = append(page.Prefixes, aws.StringValue(p.Prefix))
+	}
+	if aws.BoolValue(result.IsTruncated) {
+		page.NextToken = aws.StringValue(result.NextContinuationToken)
+	}
+
+	// Return it
+	return page, nil
+}
+
+// ListAllObjects - This function retrieves every object, reading as many
+// pages as needed
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     bucket: the name of the bucket
+//     prefix: only return objects whose key begins with this prefix
+//
+//   Example:
+//     objects, err := ListAllObjects(mySession, "artifacts", "services/")
+func ListAllObjects(sess *session.Session, bucket string, prefix string) ([]*s3.Object, error) {
+
+	// Sanity check
+	if bucket == "" {
+		return nil, newErrorBucketNameNotProvided()
+	}
+
+	// Create the S3 client
+	svc := clients.S3(sess)
+
+	// Make the calls to S3
+	var objects []*s3.Object
+	err := svc.ListObjectsV2Pages(listObjectsInput(bucket, ListConf{Prefix: prefix}), func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		objects = append(objects, page.Contents...)
+		return true
+	})
+
+	// Return the result
+	return objects, err
+}
+
+// listObjectsInput builds the input params for a listing
+func listObjectsInput(bucket string, conf ListConf) *s3.ListObjectsV2Input {
+
+	params := &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+	}
+	if conf.Prefix != "" {
+		params.Prefix = aws.String(conf.Prefix)
+	}
+	if conf.Delimiter != "" {
+		params.Delimiter = aws.String(conf.Delimiter)
+	}
+	if conf.MaxKeys > 0 {
+		params.MaxKeys = aws.Int64(conf.MaxKeys)
+	}
+	if conf.Token != "" {
+		params.ContinuationToken = aws.String(conf.Token)
+	}
+	return params
+}
@@ -0,0 +1,247 @@
+package s3_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/bradmccoydev/self-service-sdk/internal"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/s3"
+)
+
+// Test UploadObject
+func TestUploadObject(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeS3()
+	defer fake.Server.Close()
+	sess := fake.Session()
+	conf := s3.UploadConf{ContentType: "application/zip", Metadata: map[string]string{"Service": "fred"}}
+
+	// Setup test data
+	tests := []struct {
+		desc      string
+		bucket    string
+		key       string
+		body      string
+		conf      s3.UploadConf
+		expectErr bool
+	}{
+		{"No inputs", "", "", "", s3.UploadConf{}, true},
+		{"No key", TestBucketNameValid, "", "blah", s3.UploadConf{}, true},
+		{"Invalid bucket", TestBucketNameInvalid, "fred.zip", "blah", s3.UploadConf{}, true},
+		{"Valid upload", TestBucketNameValid, "services/fred.zip", "blah", s3.UploadConf{}, false},
+		{"Valid upload with metadata", TestBucketNameValid, "services/barney.zip", "blah", conf, false},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Run the test
+			var body io.Reader
+			if test.key != "" {
+				body = strings.NewReader(test.body)
+			}
+			_, err := s3.UploadObject(sess, test.bucket, test.key, body, test.conf)
+			if test.expectErr {
+				internal.HasError(t, err)
+				return
+			}
+			internal.NoError(t, err)
+			obj, ok := fake.Get(test.key)
+			internal.Assert(t, ok, "expected object %s to be uploaded", test.key)
+			internal.Equals(t, test.body, string(obj.body))
+			if test.conf.ContentType != "" {
+				internal.Equals(t, test.conf.ContentType, obj.contentType)
+				internal.Equals(t, "fred", obj.metadata["Service"])
+			}
+		})
+	}
+}
+
+// Test UploadFile & DownloadFile
+func TestUploadDownloadFile(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeS3()
+	defer fake.Server.Close()
+	sess := fake.Session()
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.zip")
+	dst := filepath.Join(dir, "dst.zip")
+	internal.NoError(t, ioutil.WriteFile(src, []byte("zipped"), 0600))
+
+	// Round trip the file
+	_, err := s3.UploadFile(sess, TestBucketNameValid, "services/fred.zip", src, s3.UploadConf{})
+	internal.NoError(t, err)
+	size, err := s3.DownloadFile(sess, TestBucketNameValid, "services/fred.zip", dst)
+	internal.NoError(t, err)
+	internal.Equals(t, int64(6), size)
+	data, err := ioutil.ReadFile(dst)
+	internal.NoError(t, err)
+	internal.Equals(t, "zipped", string(data))
+
+	// Errors
+	_, err = s3.UploadFile(sess, TestBucketNameValid, "services/fred.zip", "", s3.UploadConf{})
+	internal.HasError(t, err)
+	_, err = s3.UploadFile(sess, TestBucketNameValid, "services/fred.zip", filepath.Join(dir, "garbage"), s3.UploadConf{})
+	internal.HasError(t, err)
+	_, err = s3.DownloadFile(sess, TestBucketNameValid, "services/garbage.zip", filepath.Join(dir, "garbage"))
+	internal.HasError(t, err)
+	_, err = ioutil.ReadFile(filepath.Join(dir, "garbage"))
+	internal.HasError(t, err)
+}
+
+// Test DownloadObject
+func TestDownloadObject(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeS3()
+	defer fake.Server.Close()
+	sess := fake.Session()
+	fake.Put("services/fred.zip", "blah")
+
+	// Setup test data
+	tests := []struct {
+		desc         string
+		bucket       string
+		key          string
+		expectErr    bool
+		expectedBody string
+	}{
+		{"No inputs", "", "", true, ""},
+		{"No key", TestBucketNameValid, "", true, ""},
+		{"Invalid bucket", TestBucketNameInvalid, "services/fred.zip", true, ""},
+		{"Invalid key", TestBucketNameValid, "services/garbage.zip", true, ""},
+		{"Valid download", TestBucketNameValid, "services/fred.zip", false, "blah"},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Run the test
+			var buf bytes.Buffer
+			size, err := s3.DownloadObject(sess, test.bucket, test.key, &buf)
+			if test.expectErr {
+				internal.HasError(t, err)
+				return
+			}
+			internal.NoError(t, err)
+			internal.Equals(t, int64(len(test.expectedBody)), size)
+			internal.Equals(t, test.expectedBody, buf.String())
+		})
+	}
+
+	// A writer must be provided
+	_, err := s3.DownloadObject(sess, TestBucketNameValid, "services/fred.zip", nil)
+	internal.HasError(t, err)
+}
+
+// Test HeadObject & ObjectExists
+func TestObjectExists(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeS3()
+	defer fake.Server.Close()
+	sess := fake.Session()
+	fake.Put("services/fred.zip", "blah")
+
+	// Setup test data
+	tests := []struct {
+		desc           string
+		bucket         string
+		key            string
+		expectErr      bool
+		expectedResult bool
+	}{
+		{"No inputs", "", "", true, false},
+		{"No key", TestBucketNameValid, "", true, false},
+		{"Invalid bucket", TestBucketNameInvalid, "services/fred.zip", false, false},
+		{"Invalid key", TestBucketNameValid, "services/garbage.zip", false, false},
+		{"Valid key", TestBucketNameValid, "services/fred.zip", false, true},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Run the test
+			result, err := s3.ObjectExists(sess, test.bucket, test.key)
+			if test.expectErr {
+				internal.HasError(t, err)
+				return
+			}
+			internal.NoError(t, err)
+			internal.Equals(t, test.expectedResult, result)
+			if result {
+				head, err := s3.HeadObject(sess, test.bucket, test.key)
+				internal.NoError(t, err)
+				internal.Equals(t, int64(4), aws.Int64Value(head.ContentLength))
+			}
+		})
+	}
+}
+
+// Test ListObjects & ListAllObjects
+func TestListObjects(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeS3()
+	defer fake.Server.Close()
+	sess := fake.Session()
+	for _, k := range []string{"services/a.zip", "services/b.zip", "services/c.zip", "services/d.zip", "services/e.zip", "state/fred.tfstate"} {
+		fake.Put(k, "blah")
+	}
+
+	// Errors
+	_, err := s3.ListObjects(sess, "", s3.ListConf{})
+	internal.HasError(t, err)
+	_, err = s3.ListObjects(sess, TestBucketNameValid, s3.ListConf{MaxKeys: -1})
+	internal.HasError(t, err)
+	_, err = s3.ListObjects(sess, TestBucketNameInvalid, s3.ListConf{})
+	internal.HasError(t, err)
+	_, err = s3.ListAllObjects(sess, "", "")
+	internal.HasError(t, err)
+
+	// Page through the services
+	var keys []string
+	conf := s3.ListConf{Prefix: "services/", MaxKeys: 2}
+	pages := 0
+	for {
+		page, err := s3.ListObjects(sess, TestBucketNameValid, conf)
+		internal.NoError(t, err)
+		pages++
+		for _, o := range page.Objects {
+			keys = append(keys, aws.StringValue(o.Key))
+		}
+		if page.NextToken == "" {
+			break
+		}
+		conf.Token = page.NextToken
+	}
+	internal.Equals(t, 3, pages)
+	internal.Equals(t, []string{"services/a.zip", "services/b.zip", "services/c.zip", "services/d.zip", "services/e.zip"}, keys)
+
+	// Folders
+	page, err := s3.ListObjects(sess, TestBucketNameValid, s3.ListConf{Delimiter: "/"})
+	internal.NoError(t, err)
+	internal.Equals(t, []string{"services/", "state/"}, page.Prefixes)
+	internal.Equals(t, 0, len(page.Objects))
+
+	// Everything
+	objects, err := s3.ListAllObjects(sess, TestBucketNameValid, "")
+	internal.NoError(t, err)
+	internal.Equals(t, 6, len(objects))
+	objects, err = s3.ListAllObjects(sess, TestBucketNameValid, "state/")
+	internal.NoError(t, err)
+	internal.Equals(t, 1, len(objects))
+}
@@ -0,0 +1,12 @@
+// Package s3 provides a simplified api to perform common
+// S3 object operations (upload, download, head & list) and
+// to generate pre-signed URLs for temporary access to objects.
+//
+//   The following AWS GoLang SDK packages are used:
+//     * aws
+//     * aws/awserr
+//     * aws/request
+//     * aws/session
+//     * service/s3
+//     * service/s3/s3manager
+package s3
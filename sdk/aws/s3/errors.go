@@ -0,0 +1,38 @@
+// This file contains all the bits & pieces related to
+// error messages for the s3 package.
+
+package s3
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+func newErrorBucketNameNotProvided() error {
+	return errors.New("A bucket name must be provided")
+}
+
+func newErrorObjectKeyNotProvided() error {
+	return errors.New("An object key must be provided")
+}
+
+func newErrorObjectBodyNotProvided() error {
+	return errors.New("An object body must be provided")
+}
+
+func newErrorFilePathNotProvided() error {
+	return errors.New("A file path must be provided")
+}
+
+func newErrorWriterNotProvided() error {
+	return errors.New("A writer must be provided")
+}
+
+func newErrorMaxKeysInvalid() error {
+	return errors.New("The maximum number of keys cannot be negative")
+}
+
+func newErrorPresignExpiryInvalid(expiry time.Duration) error {
+	return fmt.Errorf("The pre-signed URL expiry of %s must be between %s & %s", expiry, MinPresignExpiry, MaxPresignExpiry)
+}
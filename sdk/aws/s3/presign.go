@@ -0,0 +1,109 @@
+// This file contains all the bits & pieces related to
+// generating pre-signed URLs that grant temporary access
+// to objects without AWS credentials
+
+package s3
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/clients"
+)
+
+const (
+	// DefaultPresignExpiry - how long a pre-signed URL is valid for by default
+	DefaultPresignExpiry time.Duration = 15 * time.Minute
+
+	// MinPresignExpiry - the shortest expiry allowed for a pre-signed URL
+	MinPresignExpiry time.Duration = time.Second
+
+	// MaxPresignExpiry - the longest expiry allowed for a pre-signed URL (SigV4 limit)
+	MaxPresignExpiry time.Duration = 7 * 24 * time.Hour
+)
+
+// PresignGetURL - This function generates a pre-signed URL that can be used
+// to download an object. Note that a URL signed with temporary credentials
+// stops working when those credentials expire.
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     bucket: the name of the bucket
+//     key: the key of the object
+//     expiry: how long the URL is valid for, zero uses DefaultPresignExpiry
+//
+//   Example:
+//     url, err := PresignGetURL(mySession, "artifacts", "services/fred.zip", time.Hour)
+func PresignGetURL(sess *session.Session, bucket string, key string, expiry time.Duration) (string, error) {
+
+	// Sanity check
+	if bucket == "" {
+		return "", newErrorBucketNameNotProvided()
+	}
+	if key == "" {
+		return "", newErrorObjectKeyNotProvided()
+	}
+
+	// Build the request
+	svc := clients.S3(sess)
+	req, _ := svc.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+
+	// Sign it
+	return presign(req, expiry)
+}
+
+// PresignPutURL - This function generates a pre-signed URL that can be used
+// to upload an object. If a content type is provided the uploader must send
+// the same Content-Type header.
+//
+//   Parameters:
+//     sess: a valid AWS session
+//     bucket: the name of the bucket
+//     key: the key of the object
+//     contentType: the optional content type of the object
+//     expiry: how long the URL is valid for, zero uses DefaultPresignExpiry
+//
+//   Example:
+//     url, err := PresignPutURL(mySession, "artifacts", "services/fred.zip", "application/zip", time.Hour)
+func PresignPutURL(sess *session.Session, bucket string, key string, contentType string, expiry time.Duration) (string, error) {
+
+	// Sanity check
+	if bucket == "" {
+		return "", newErrorBucketNameNotProvided()
+	}
+	if key == "" {
+		return "", newErrorObjectKeyNotProvided()
+	}
+
+	// Build the request
+	params := &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	if contentType != "" {
+		params.ContentType = aws.String(contentType)
+	}
+	svc := clients.S3(sess)
+	req, _ := svc.PutObjectRequest(params)
+
+	// Sign it
+	return presign(req, expiry)
+}
+
+// presign validates the expiry & signs the request
+func presign(req *request.Request, expiry time.Duration) (string, error) {
+
+	if expiry == 0 {
+		expiry = DefaultPresignExpiry
+	}
+	if expiry < MinPresignExpiry || expiry > MaxPresignExpiry {
+		return "", newErrorPresignExpiryInvalid(expiry)
+	}
+	return req.Presign(expiry)
+}
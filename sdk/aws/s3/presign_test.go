@@ -0,0 +1,125 @@
+package s3_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bradmccoydev/self-service-sdk/internal"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/s3"
+)
+
+// Test PresignGetURL
+func TestPresignGetURL(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeS3()
+	defer fake.Server.Close()
+	sess := fake.Session()
+	fake.Put("services/fred.zip", "blah")
+
+	// Setup test data
+	tests := []struct {
+		desc            string
+		bucket          string
+		key             string
+		expiry          time.Duration
+		expectErr       bool
+		expectedExpires string
+	}{
+		{"No inputs", "", "", 0, true, ""},
+		{"No key", TestBucketNameValid, "", 0, true, ""},
+		{"Negative expiry", TestBucketNameValid, "services/fred.zip", -time.Minute, true, ""},
+		{"Expiry too long", TestBucketNameValid, "services/fred.zip", 8 * 24 * time.Hour, true, ""},
+		{"Default expiry", TestBucketNameValid, "services/fred.zip", 0, false, "900"},
+		{"Custom expiry", TestBucketNameValid, "services/fred.zip", time.Hour, false, "3600"},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Run the test
+			link, err := s3.PresignGetURL(sess, test.bucket, test.key, test.expiry)
+			if test.expectErr {
+				internal.HasError(t, err)
+				return
+			}
+			internal.NoError(t, err)
+			u, err := url.Parse(link)
+			internal.NoError(t, err)
+			internal.Equals(t, "/"+test.bucket+"/"+test.key, u.Path)
+			internal.Equals(t, test.expectedExpires, u.Query().Get("X-Amz-Expires"))
+			internal.Assert(t, u.Query().Get("X-Amz-Signature") != "", "expected a signature in %s", link)
+
+			// The link works without credentials
+			resp, err := http.Get(link)
+			internal.NoError(t, err)
+			defer resp.Body.Close()
+			body, _ := ioutil.ReadAll(resp.Body)
+			internal.Equals(t, "blah", string(body))
+		})
+	}
+}
+
+// Test PresignPutURL
+func TestPresignPutURL(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeS3()
+	defer fake.Server.Close()
+	sess := fake.Session()
+
+	// Setup test data
+	tests := []struct {
+		desc        string
+		bucket      string
+		key         string
+		contentType string
+		expiry      time.Duration
+		expectErr   bool
+	}{
+		{"No inputs", "", "", "", 0, true},
+		{"No key", TestBucketNameValid, "", "", 0, true},
+		{"Expiry too long", TestBucketNameValid, "services/fred.zip", "", 8 * 24 * time.Hour, true},
+		{"Valid", TestBucketNameValid, "services/fred.zip", "", time.Hour, false},
+		{"Valid with content type", TestBucketNameValid, "services/barney.zip", "application/zip", 0, false},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Run the test
+			link, err := s3.PresignPutURL(sess, test.bucket, test.key, test.contentType, test.expiry)
+			if test.expectErr {
+				internal.HasError(t, err)
+				return
+			}
+			internal.NoError(t, err)
+			u, err := url.Parse(link)
+			internal.NoError(t, err)
+			internal.Assert(t, u.Query().Get("X-Amz-Signature") != "", "expected a signature in %s", link)
+			signed := u.Query().Get("X-Amz-SignedHeaders")
+			internal.Equals(t, test.contentType != "", strings.Contains(signed, "content-type"))
+
+			// The link can be used to upload the object
+			req, _ := http.NewRequest(http.MethodPut, link, strings.NewReader("uploaded"))
+			if test.contentType != "" {
+				req.Header.Set("Content-Type", test.contentType)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			internal.NoError(t, err)
+			resp.Body.Close()
+			internal.Equals(t, http.StatusOK, resp.StatusCode)
+			obj, ok := fake.Get(test.key)
+			internal.Assert(t, ok, "expected object %s to be uploaded", test.key)
+			internal.Equals(t, "uploaded", string(obj.body))
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package s3_test
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+const (
+	// The valid testing bucket name
+	TestBucketNameValid string = "testing"
+
+	// An invalid testing bucket name
+	TestBucketNameInvalid string = "garbage"
+)
+
+// fakeObject is an object held by the fake S3 server
+type fakeObject struct {
+	body        []byte
+	contentType string
+	metadata    map[string]string
+}
+
+// FakeS3 is a minimal stand-in for the S3 API (path-style addressing only)
+type FakeS3 struct {
+	Server  *httptest.Server
+	mu      sync.Mutex
+	objects map[string]fakeObject
+}
+
+// NewFakeS3 starts a fake S3 server holding the (empty) testing bucket
+func NewFakeS3() *FakeS3 {
+
+	fake := &FakeS3{objects: make(map[string]fakeObject)}
+	fake.Server = httptest.NewServer(http.HandlerFunc(fake.handle))
+	return fake
+}
+
+// Put stores an object in the testing bucket
+func (f *FakeS3) Put(key string, body string) {
+
+	f.mu.Lock()
+	f.objects[key] = fakeObject{body: []byte(body)}
+	f.mu.Unlock()
+}
+
+// Get returns an object from the testing bucket
+func (f *FakeS3) Get(key string) (fakeObject, bool) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	obj, ok := f.objects[key]
+	return obj, ok
+}
+
+// Session creates a session with static credentials that talks to the fake server
+func (f *FakeS3) Session() *session.Session {
+
+	return session.Must(session.NewSession(&aws.Config{
+		Region:           aws.String("us-east-1"),
+		Endpoint:         aws.String(f.Server.URL),
+		S3ForcePathStyle: aws.Bool(true),
+		Credentials:      credentials.NewStaticCredentials("AKIDS3", "SECRET", ""),
+	}))
+}
+
+// handle routes the request to the appropriate operation
+func (f *FakeS3) handle(w http.ResponseWriter, r *http.Request) {
+
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	if parts[0] != TestBucketNameValid {
+		writeError(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	if len(parts) == 1 || parts[1] == "" {
+		f.list(w, r)
+		return
+	}
+	key := parts[1]
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := ioutil.ReadAll(r.Body)
+		obj := fakeObject{body: body, contentType: r.Header.Get("Content-Type"), metadata: make(map[string]string)}
+		for k, v := range r.Header {
+			if strings.HasPrefix(k, "X-Amz-Meta-") {
+				obj.metadata[strings.TrimPrefix(k, "X-Amz-Meta-")] = v[0]
+			}
+		}
+		f.mu.Lock()
+		f.objects[key] = obj
+		f.mu.Unlock()
+		w.Header().Set("ETag", `"etag"`)
+	case http.MethodGet, http.MethodHead:
+		obj, ok := f.Get(key)
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("ETag", `"etag"`)
+		w.Header().Set("Content-Length", strconv.Itoa(len(obj.body)))
+		if obj.contentType != "" {
+			w.Header().Set("Content-Type", obj.contentType)
+		}
+		for k, v := range obj.metadata {
+			w.Header().Set("X-Amz-Meta-"+k, v)
+		}
+		if r.Method == http.MethodGet {
+			w.Write(obj.body)
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// list handles ListObjectsV2, the continuation token is the index of the next key
+func (f *FakeS3) list(w http.ResponseWriter, r *http.Request) {
+
+	type content struct {
+		Key  string
+		Size int
+	}
+	type prefix struct {
+		Prefix string
+	}
+	type result struct {
+		XMLName               xml.Name `xml:"ListBucketResult"`
+		Name                  string
+		IsTruncated           bool
+		NextContinuationToken string    `xml:",omitempty"`
+		Contents              []content `xml:"Contents"`
+		CommonPrefixes        []prefix  `xml:"CommonPrefixes"`
+	}
+
+	// Work out which keys match
+	q := r.URL.Query()
+	maxKeys := 1000
+	if v := q.Get("max-keys"); v != "" {
+		maxKeys, _ = strconv.Atoi(v)
+	}
+	start, _ := strconv.Atoi(q.Get("continuation-token"))
+	f.mu.Lock()
+	var keys []string
+	seen := make(map[string]bool)
+	for k := range f.objects {
+		if !strings.HasPrefix(k, q.Get("prefix")) {
+			continue
+		}
+		if d := q.Get("delimiter"); d != "" {
+			rest := strings.TrimPrefix(k, q.Get("prefix"))
+			if i := strings.Index(rest, d); i >= 0 {
+				k = q.Get("prefix") + rest[:i+len(d)]
+				if seen[k] {
+					continue
+				}
+				seen[k] = true
+			}
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	// Build the page
+	res := result{Name: TestBucketNameValid}
+	for i := start; i < len(keys) && i < start+maxKeys; i++ {
+		if seen[keys[i]] {
+			res.CommonPrefixes = append(res.CommonPrefixes, prefix{keys[i]})
+		} else {
+			res.Contents = append(res.Contents, content{keys[i], len(f.objects[keys[i]].body)})
+		}
+	}
+	f.mu.Unlock()
+	if start+maxKeys < len(keys) {
+		res.IsTruncated = true
+		res.NextContinuationToken = strconv.Itoa(start + maxKeys)
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(res)
+}
+
+// writeError writes an S3 style error response
+func writeError(w http.ResponseWriter, status int, code string) {
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+}
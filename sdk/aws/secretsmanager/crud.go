@@ -5,12 +5,13 @@
 package secretsmanager
 
 import (
+	"context"
 	"encoding/json"
-	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/clients"
 	guuid "github.com/google/uuid"
 )
 
@@ -22,8 +23,8 @@ type secretDetails struct {
 	secString string
 }
 
-// createSecret - This function creates a secret
-func createSecret(sess *session.Session, secret secretDetails) error {
+// createSecret - This function creates a secret & waits until it is available
+func createSecret(ctx context.Context, sess *session.Session, secret secretDetails, wait WaitConf) error {
 
 	// Sanity check
 	if len(secret.secBin) == 0 && secret.secString == "" {
@@ -43,18 +44,16 @@ func createSecret(sess *session.Session, secret secretDetails) error {
 	}
 
 	// Create the Secrets Manager client
-	svc := secretsmanager.New(sess)
+	svc := clients.SecretsManager(sess)
 
 	// Make the call to Secrets Manager
-	_, err := svc.CreateSecret(params)
-
-	// If ok, sleep for 5 seconds to allow time for processing
-	if err == nil {
-		time.Sleep(5 * time.Second)
+	_, err := svc.CreateSecretWithContext(ctx, params)
+	if err != nil {
+		return err
 	}
 
-	// Return the result
-	return err
+	// Wait for the secret to be available
+	return WaitUntilSecretExists(ctx, sess, secret.name, wait)
 }
 
 // CreateSecretKeyValue - This function creates a secret string
@@ -68,6 +67,23 @@ func createSecret(sess *session.Session, secret secretDetails) error {
 //   Example:
 //     err := CreateSecretKeyValue(mySession, secretName, secretDesc, secMap)
 func CreateSecretKeyValue(sess *session.Session, name string, description string, secret map[string]string) error {
+	return CreateSecretKeyValueWithContext(context.Background(), sess, name, description, secret, WaitConf{})
+}
+
+// CreateSecretKeyValueWithContext - This function creates a secret string
+// & waits until it is available
+//
+//   Parameters:
+//     ctx: the context used to cancel the request & wait
+//     sess: a valid AWS session
+//     name: the name of the secret to create
+//     description: the description for the secret
+//     secret: a hashmap of key/value secret pairs
+//     wait: the timeout & backoff to use while waiting
+//
+//   Example:
+//     err := CreateSecretKeyValueWithContext(ctx, mySession, secretName, secretDesc, secMap, WaitConf{})
+func CreateSecretKeyValueWithContext(ctx context.Context, sess *session.Session, name string, description string, secret map[string]string, wait WaitConf) error {
 
 	// Sanity check
 	if name == "" {
@@ -90,7 +106,7 @@ func CreateSecretKeyValue(sess *session.Session, name string, description string
 		desc:      description,
 		secString: secJSON,
 	}
-	err = createSecret(sess, secDetails)
+	err = createSecret(ctx, sess, secDetails, wait)
 
 	// Return the result
 	return err
@@ -107,6 +123,23 @@ func CreateSecretKeyValue(sess *session.Session, name string, description string
 //   Example:
 //     err := CreateSecretString(mySession, secretName, secretDesc, secretString)
 func CreateSecretString(sess *session.Session, name string, description string, secret string) error {
+	return CreateSecretStringWithContext(context.Background(), sess, name, description, secret, WaitConf{})
+}
+
+// CreateSecretStringWithContext - This function creates a secret string
+// & waits until it is available
+//
+//   Parameters:
+//     ctx: the context used to cancel the request & wait
+//     sess: a valid AWS session
+//     name: the name of the secret to create
+//     description: the description for the secret
+//     secret: the secret string
+//     wait: the timeout & backoff to use while waiting
+//
+//   Example:
+//     err := CreateSecretStringWithContext(ctx, mySession, secretName, secretDesc, secretString, WaitConf{})
+func CreateSecretStringWithContext(ctx context.Context, sess *session.Session, name string, description string, secret string, wait WaitConf) error {
 
 	// Sanity check
 	if name == "" {
@@ -122,7 +155,7 @@ func CreateSecretString(sess *session.Session, name string, description string,
 		desc:      description,
 		secString: secret,
 	}
-	err := createSecret(sess, secDetails)
+	err := createSecret(ctx, sess, secDetails, wait)
 
 	// Return the result
 	return err
@@ -138,6 +171,22 @@ func CreateSecretString(sess *session.Session, name string, description string,
 //   Example:
 //     err := DeleteSecret(mySession, secretName)
 func DeleteSecret(sess *session.Session, secretName string, force bool) error {
+	return DeleteSecretWithContext(context.Background(), sess, secretName, force, WaitConf{})
+}
+
+// DeleteSecretWithContext - This function deletes a secret & waits until it
+// has gone (if forced) or has been scheduled for deletion
+//
+//   Parameters:
+//     ctx: the context used to cancel the request & wait
+//     sess: a valid AWS session
+//     secretName: the name of the secret to delete
+//     force: If true, force delete the scret immediately
+//     wait: the timeout & backoff to use while waiting
+//
+//   Example:
+//     err := DeleteSecretWithContext(ctx, mySession, secretName, true, WaitConf{})
+func DeleteSecretWithContext(ctx context.Context, sess *session.Session, secretName string, force bool, wait WaitConf) error {
 
 	// Sanity check
 	if secretName == "" {
@@ -151,18 +200,19 @@ func DeleteSecret(sess *session.Session, secretName string, force bool) error {
 	}
 
 	// Create the Secrets Manager client
-	svc := secretsmanager.New(sess)
+	svc := clients.SecretsManager(sess)
 
 	// Make the call to Secrets Manager
-	_, err := svc.DeleteSecret(params)
-
-	// If ok, sleep for 5 seconds to allow time for processing
-	if err == nil {
-		time.Sleep(5 * time.Second)
+	_, err := svc.DeleteSecretWithContext(ctx, params)
+	if err != nil {
+		return err
 	}
 
-	// Return the result
-	return err
+	// Wait for the deletion to take effect
+	if force {
+		return WaitUntilSecretDeleted(ctx, sess, secretName, wait)
+	}
+	return waitUntilSecretScheduledForDeletion(ctx, sess, secretName, wait)
 }
 
 // getSecret - This function provides a generic routine to retrieve a secret
@@ -179,7 +229,7 @@ func getSecret(sess *session.Session, secretName string) (*secretsmanager.GetSec
 	}
 
 	// Create the Secrets Manager client
-	svc := secretsmanager.New(sess)
+	svc := clients.SecretsManager(sess)
 
 	// Make the call to Secrets Manager
 	result, err := svc.GetSecretValue(params)
@@ -12,6 +12,9 @@ import (
 // Test CreateSecretKeyValue
 func TestCreateSecretKeyValue(t *testing.T) {
 
+	// Only run against a real AWS account
+	internal.SkipAwsTests(t)
+
 	// Setup backend
 	deleteerr := DeleteTestSecretIfExists()
 	if deleteerr != nil {
@@ -65,6 +68,9 @@ func TestCreateSecretKeyValue(t *testing.T) {
 // Test CreateSecretString
 func TestCreateSecretString(t *testing.T) {
 
+	// Only run against a real AWS account
+	internal.SkipAwsTests(t)
+
 	// Setup backend
 	deleteerr := DeleteTestSecretIfExists()
 	if deleteerr != nil {
@@ -110,6 +116,9 @@ func TestCreateSecretString(t *testing.T) {
 // Test DeleteSecret
 func TestDeleteSecret(t *testing.T) {
 
+	// Only run against a real AWS account
+	internal.SkipAwsTests(t)
+
 	// Setup backend
 	createerr := CreateTestSecretIfNotExists()
 	if createerr != nil {
@@ -154,6 +163,9 @@ func TestDeleteSecret(t *testing.T) {
 // Test GetSecretKeyValue
 func TestGetSecretKeyValue(t *testing.T) {
 
+	// Only run against a real AWS account
+	internal.SkipAwsTests(t)
+
 	// Setup backend
 	createerr := CreateTestSecretIfNotExists()
 	if createerr != nil {
@@ -197,6 +209,9 @@ func TestGetSecretKeyValue(t *testing.T) {
 // Test GetSecretString
 func TestGetSecretString(t *testing.T) {
 
+	// Only run against a real AWS account
+	internal.SkipAwsTests(t)
+
 	// Setup backend
 	createerr := CreateTestSecretIfNotExists()
 	if createerr != nil {
@@ -8,6 +8,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/clients"
 )
 
 // DescribeSecret - This function retrieves the metadata about a secret
@@ -31,7 +32,7 @@ func DescribeSecret(sess *session.Session, secretName string) (*secretsmanager.D
 	}
 
 	// Create the Secrets Manager client
-	svc := secretsmanager.New(sess)
+	svc := clients.SecretsManager(sess)
 
 	// Make the call to Secrets Manager
 	result, err := svc.DescribeSecret(params)
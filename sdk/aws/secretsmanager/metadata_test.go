@@ -12,6 +12,9 @@ import (
 // Test DescribeSecret
 func TestDescribeSecret(t *testing.T) {
 
+	// Only run against a real AWS account
+	internal.SkipAwsTests(t)
+
 	// Setup backend
 	createerr := CreateTestSecretIfNotExists()
 	if createerr != nil {
@@ -1,10 +1,17 @@
 package secretsmanager_test
 
 import (
-	"log"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/bradmccoydev/self-service-sdk/internal"
 	"github.com/bradmccoydev/self-service-sdk/sdk/aws/secretsmanager"
 )
@@ -43,17 +50,83 @@ func DeleteTestSecretIfExists() error {
 	return err
 }
 
+// FakeHandler handles a single Secrets Manager operation, returning the
+// HTTP status & the body to send back
+type FakeHandler func(input map[string]interface{}) (int, interface{})
+
+// FakeSecrets is a minimal stand-in for the Secrets Manager API where each
+// operation is answered by a handler registered by the test
+type FakeSecrets struct {
+	Server   *httptest.Server
+	mu       sync.Mutex
+	handlers map[string]FakeHandler
+	calls    map[string]int
+}
+
+// NewFakeSecrets starts a fake Secrets Manager server
+func NewFakeSecrets() *FakeSecrets {
+
+	fake := &FakeSecrets{handlers: make(map[string]FakeHandler), calls: make(map[string]int)}
+	fake.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		// Work out the operation & record the call
+		op := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "secretsmanager.")
+		input := make(map[string]interface{})
+		json.NewDecoder(r.Body).Decode(&input)
+		fake.mu.Lock()
+		fake.calls[op]++
+		handler, ok := fake.handlers[op]
+		fake.mu.Unlock()
+
+		// Answer it
+		status, body := http.StatusBadRequest, interface{}(FakeError("UnknownOperationException"))
+		if ok {
+			status, body = handler(input)
+		}
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	}))
+	return fake
+}
+
+// Handle registers the handler for an operation
+func (f *FakeSecrets) Handle(op string, handler FakeHandler) {
+
+	f.mu.Lock()
+	f.handlers[op] = handler
+	f.mu.Unlock()
+}
+
+// Calls returns the number of calls made for an operation
+func (f *FakeSecrets) Calls(op string) int {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[op]
+}
+
+// Session creates a session with static credentials that talks to the fake server
+func (f *FakeSecrets) Session() *session.Session {
+
+	return session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(f.Server.URL),
+		Credentials: credentials.NewStaticCredentials("AKIDSECRETS", "SECRET", ""),
+		MaxRetries:  aws.Int(0),
+	}))
+}
+
+// FakeError builds a Secrets Manager style error body
+func FakeError(code string) map[string]interface{} {
+	return map[string]interface{}{"__type": code, "Message": code}
+}
+
 // TestMain routine for controlling setup/destruction for all tests in this package
 func TestMain(m *testing.M) {
 
-	// Do we need to do these tests?
-	var doTests bool = internal.PerformAwsTests()
-	if doTests == false {
-		log.Printf("AWS testing variable: %s not set or set to false", internal.TestAwsEnabled)
-		os.Exit(0)
-	}
-
-	// Run the various tests then exit
+	// Run the various tests then exit. Tests that need a real AWS
+	// account skip themselves unless AWS testing is enabled.
 	exitVal := m.Run()
 	os.Exit(exitVal)
 }
@@ -0,0 +1,117 @@
+// This file contains all the bits & pieces related to
+// waiting for secrets to reach a particular state
+
+package secretsmanager
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/clients"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/waiter"
+)
+
+const (
+	// DefaultWaitTimeout - how long to wait before giving up
+	DefaultWaitTimeout time.Duration = 2 * time.Minute
+
+	// DefaultWaitDelay - the delay before the first re-check
+	DefaultWaitDelay time.Duration = 250 * time.Millisecond
+
+	// DefaultWaitMaxDelay - the longest delay between checks
+	DefaultWaitMaxDelay time.Duration = 5 * time.Second
+)
+
+// WaitConf - structure used to represent how to poll while waiting.
+// The delay doubles after each check up to MaxDelay. Zero values use
+// the defaults above.
+type WaitConf = waiter.Conf
+
+// WaitUntilSecretExists - This function waits until the secret can be described
+//
+//   Parameters:
+//     ctx: the context used to cancel the wait
+//     sess: a valid AWS session
+//     secretName: the name of the secret
+//     conf: the timeout & backoff to use
+//
+//   Example:
+//     err := WaitUntilSecretExists(ctx, mySession, secretName, WaitConf{})
+func WaitUntilSecretExists(ctx context.Context, sess *session.Session, secretName string, conf WaitConf) error {
+
+	return waitForSecret(ctx, sess, secretName, conf, "available", func(result *secretsmanager.DescribeSecretOutput, err error) (bool, error) {
+		if secretNotFound(err) {
+			return false, nil
+		}
+		return err == nil, err
+	})
+}
+
+// WaitUntilSecretDeleted - This function waits until the secret no longer exists
+//
+//   Parameters:
+//     ctx: the context used to cancel the wait
+//     sess: a valid AWS session
+//     secretName: the name of the secret
+//     conf: the timeout & backoff to use
+//
+//   Example:
+//     err := WaitUntilSecretDeleted(ctx, mySession, secretName, WaitConf{})
+func WaitUntilSecretDeleted(ctx context.Context, sess *session.Session, secretName string, conf WaitConf) error {
+
+	return waitForSecret(ctx, sess, secretName, conf, "deleted", func(result *secretsmanager.DescribeSecretOutput, err error) (bool, error) {
+		if secretNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	})
+}
+
+// waitUntilSecretScheduledForDeletion waits until the secret has been
+// scheduled for deletion (or has already gone)
+func waitUntilSecretScheduledForDeletion(ctx context.Context, sess *session.Session, secretName string, conf WaitConf) error {
+
+	return waitForSecret(ctx, sess, secretName, conf, "scheduled for deletion", func(result *secretsmanager.DescribeSecretOutput, err error) (bool, error) {
+		if secretNotFound(err) {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return result.DeletedDate != nil, nil
+	})
+}
+
+// waitForSecret polls DescribeSecret until the check reports done
+func waitForSecret(ctx context.Context, sess *session.Session, secretName string, conf WaitConf, state string, check func(*secretsmanager.DescribeSecretOutput, error) (bool, error)) error {
+
+	// Sanity check
+	if secretName == "" {
+		return newErrorSecretNameNotProvided()
+	}
+
+	// Wait for it
+	svc := clients.SecretsManager(sess)
+	return wait(ctx, conf, "secret "+secretName, state, func(ctx context.Context) (bool, error) {
+		result, err := svc.DescribeSecretWithContext(ctx, &secretsmanager.DescribeSecretInput{SecretId: aws.String(secretName)})
+		return check(result, err)
+	})
+}
+
+// secretNotFound checks if the error is a resource not found error
+func secretNotFound(err error) bool {
+
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == secretsmanager.ErrCodeResourceNotFoundException
+}
+
+// wait polls check using the package defaults for anything not configured
+func wait(ctx context.Context, conf WaitConf, resource string, state string, check waiter.Check) error {
+
+	defaults := WaitConf{Timeout: DefaultWaitTimeout, Delay: DefaultWaitDelay, MaxDelay: DefaultWaitMaxDelay}
+	return waiter.Wait(ctx, conf.WithDefaults(defaults), resource, state, check)
+}
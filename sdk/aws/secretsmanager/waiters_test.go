@@ -0,0 +1,137 @@
+package secretsmanager_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bradmccoydev/self-service-sdk/internal"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/secretsmanager"
+)
+
+// fastWait keeps the tests quick
+var fastWait = secretsmanager.WaitConf{Timeout: time.Second, Delay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+// secretFound returns a DescribeSecret response, optionally scheduled for deletion
+func secretFound(scheduled bool) func() (int, interface{}) {
+	return func() (int, interface{}) {
+		body := map[string]interface{}{"Name": TestSecretNameValid, "ARN": "arn:aws:secretsmanager:us-east-1:123456789012:secret:testing"}
+		if scheduled {
+			body["DeletedDate"] = float64(time.Now().Unix())
+		}
+		return http.StatusOK, body
+	}
+}
+
+// secretMissing returns a DescribeSecret not found response
+func secretMissing() (int, interface{}) {
+	return http.StatusBadRequest, FakeError("ResourceNotFoundException")
+}
+
+// Test WaitUntilSecretExists
+func TestWaitUntilSecretExists(t *testing.T) {
+
+	// Setup test data
+	tests := []struct {
+		desc          string
+		secretName    string
+		responses     []func() (int, interface{})
+		conf          secretsmanager.WaitConf
+		expectErr     bool
+		expectedErr   error
+		expectedCalls int
+	}{
+		{"No secret name", "", nil, fastWait, true, nil, 0},
+		{"Already exists", TestSecretNameValid, []func() (int, interface{}){secretFound(false)}, fastWait, false, nil, 1},
+		{"Eventually exists", TestSecretNameValid, []func() (int, interface{}){secretMissing, secretMissing, secretFound(false)}, fastWait, false, nil, 3},
+		{"Never created", TestSecretNameValid, []func() (int, interface{}){secretMissing}, secretsmanager.WaitConf{Timeout: 50 * time.Millisecond, Delay: time.Millisecond}, true, context.DeadlineExceeded, -1},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Setup backend
+			fake := NewFakeSecrets()
+			defer fake.Server.Close()
+			if test.responses != nil {
+				fake.Handle("DescribeSecret", internal.Sequence(test.responses...))
+			}
+
+			// Run the test
+			err := secretsmanager.WaitUntilSecretExists(context.Background(), fake.Session(), test.secretName, test.conf)
+			if test.expectErr {
+				internal.HasError(t, err)
+				if test.expectedErr != nil {
+					internal.Assert(t, errors.Is(err, test.expectedErr), "expected %v but got %v", test.expectedErr, err)
+				}
+			} else {
+				internal.NoError(t, err)
+			}
+			if test.expectedCalls >= 0 {
+				internal.Equals(t, test.expectedCalls, fake.Calls("DescribeSecret"))
+			}
+		})
+	}
+}
+
+// Test WaitUntilSecretDeleted
+func TestWaitUntilSecretDeleted(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeSecrets()
+	defer fake.Server.Close()
+	fake.Handle("DescribeSecret", internal.Sequence(secretFound(true), secretMissing))
+
+	// Run the test
+	err := secretsmanager.WaitUntilSecretDeleted(context.Background(), fake.Session(), TestSecretNameValid, fastWait)
+	internal.NoError(t, err)
+	internal.Equals(t, 2, fake.Calls("DescribeSecret"))
+}
+
+// Test the create & delete functions wait for the secret
+func TestCreateDeleteSecretWithContext(t *testing.T) {
+
+	// Setup backend
+	fake := NewFakeSecrets()
+	defer fake.Server.Close()
+	sess := fake.Session()
+	ok := func(input map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, map[string]interface{}{"Name": TestSecretNameValid}
+	}
+	fake.Handle("CreateSecret", ok)
+	fake.Handle("DeleteSecret", ok)
+
+	// Create
+	fake.Handle("DescribeSecret", internal.Sequence(secretMissing, secretFound(false)))
+	err := secretsmanager.CreateSecretStringWithContext(context.Background(), sess, TestSecretNameValid, "desc", "value", fastWait)
+	internal.NoError(t, err)
+	internal.Equals(t, 2, fake.Calls("DescribeSecret"))
+	fake.Handle("DescribeSecret", internal.Sequence(secretFound(false)))
+	err = secretsmanager.CreateSecretKeyValueWithContext(context.Background(), sess, TestSecretNameValid, "desc", map[string]string{"fred": "nerk"}, fastWait)
+	internal.NoError(t, err)
+	internal.Equals(t, 3, fake.Calls("DescribeSecret"))
+
+	// Scheduled deletion waits for the deleted date
+	fake.Handle("DescribeSecret", internal.Sequence(secretFound(false), secretFound(true)))
+	err = secretsmanager.DeleteSecretWithContext(context.Background(), sess, TestSecretNameValid, false, fastWait)
+	internal.NoError(t, err)
+	internal.Equals(t, 5, fake.Calls("DescribeSecret"))
+
+	// Forced deletion waits for the secret to go
+	fake.Handle("DescribeSecret", internal.Sequence(secretFound(true), secretMissing))
+	err = secretsmanager.DeleteSecretWithContext(context.Background(), sess, TestSecretNameValid, true, fastWait)
+	internal.NoError(t, err)
+	internal.Equals(t, 7, fake.Calls("DescribeSecret"))
+
+	// A failed request doesn't wait
+	fake.Handle("DeleteSecret", func(input map[string]interface{}) (int, interface{}) {
+		return secretMissing()
+	})
+	err = secretsmanager.DeleteSecretWithContext(context.Background(), sess, TestSecretNameInvalid, true, fastWait)
+	internal.HasError(t, err)
+	internal.Equals(t, 7, fake.Calls("DescribeSecret"))
+}
@@ -0,0 +1,6 @@
+// Package waiter polls AWS resources until they reach a particular state,
+// backing off between checks, so the sdk packages can share one wait loop.
+//
+//   No AWS GoLang SDK packages are used, the sdk packages provide the
+//   checks that call the services.
+package waiter
@@ -0,0 +1,17 @@
+// This file contains all the bits & pieces related to
+// error messages for the waiter package.
+
+package waiter
+
+import (
+	"errors"
+	"fmt"
+)
+
+func newErrorConfInvalid() error {
+	return errors.New("A positive timeout, delay & maximum delay must be provided")
+}
+
+func newErrorWaitFailed(resource string, state string, err error) error {
+	return fmt.Errorf("Gave up waiting for %s to be %s: %w", resource, state, err)
+}
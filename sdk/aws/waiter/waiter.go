@@ -0,0 +1,94 @@
+// This file contains all the bits & pieces related to
+// polling until a check reports done
+
+package waiter
+
+import (
+	"context"
+	"time"
+)
+
+// Conf - structure used to represent how to poll while waiting.
+// The delay doubles after each check up to MaxDelay.
+type Conf struct {
+	Timeout  time.Duration
+	Delay    time.Duration
+	MaxDelay time.Duration
+}
+
+// Check - a function that reports whether the resource has reached the
+// desired state. Returning an error stops the wait.
+type Check func(ctx context.Context) (bool, error)
+
+// WithDefaults - This function returns a copy of the configuration with
+// any zero (or negative) values taken from the defaults
+//
+//   Parameters:
+//     defaults: the configuration to fill the gaps from
+//
+//   Example:
+//     conf = conf.WithDefaults(Conf{Timeout: time.Minute, Delay: time.Second, MaxDelay: 5 * time.Second})
+func (c Conf) WithDefaults(defaults Conf) Conf {
+
+	if c.Timeout <= 0 {
+		c.Timeout = defaults.Timeout
+	}
+	if c.Delay <= 0 {
+		c.Delay = defaults.Delay
+	}
+	if c.MaxDelay <= 0 {
+		c.MaxDelay = defaults.MaxDelay
+	}
+	return c
+}
+
+// Wait - This function calls check until it reports done, it fails, the
+// timeout passes or the context is cancelled. The error returned when the
+// timeout passes or the context is cancelled wraps the context error.
+//
+//   Parameters:
+//     ctx: the context used to cancel the wait
+//     conf: the timeout & backoff to use, filled in with WithDefaults
+//     resource: a description of what is being waited on, used in errors
+//     state: a description of the desired state, used in errors
+//     check: the function that checks the state
+//
+//   Example:
+//     err := Wait(ctx, conf, "table fred", "ACTIVE", myCheck)
+func Wait(ctx context.Context, conf Conf, resource string, state string, check Check) error {
+
+	// Sanity check
+	if conf.Timeout <= 0 || conf.Delay <= 0 || conf.MaxDelay <= 0 {
+		return newErrorConfInvalid()
+	}
+	ctx, cancel := context.WithTimeout(ctx, conf.Timeout)
+	defer cancel()
+
+	// Poll until done
+	delay := conf.Delay
+	for {
+		done, err := check(ctx)
+		if ctx.Err() != nil {
+			return newErrorWaitFailed(resource, state, ctx.Err())
+		}
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+
+		// Back off before trying again
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return newErrorWaitFailed(resource, state, ctx.Err())
+		case <-timer.C:
+		}
+		delay *= 2
+		if delay > conf.MaxDelay {
+			delay = conf.MaxDelay
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package waiter_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bradmccoydev/self-service-sdk/internal"
+	"github.com/bradmccoydev/self-service-sdk/sdk/aws/waiter"
+)
+
+// fastWait keeps the tests quick
+var fastWait = waiter.Conf{Timeout: time.Second, Delay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+// doneAfter returns a check that reports done on the given call, counting the calls
+func doneAfter(n int, calls *int) waiter.Check {
+	return func(ctx context.Context) (bool, error) {
+		*calls++
+		return *calls >= n, nil
+	}
+}
+
+// Test Wait
+func TestWait(t *testing.T) {
+
+	// Setup test data
+	failed := errors.New("failed")
+	tests := []struct {
+		desc          string
+		conf          waiter.Conf
+		done          int
+		err           error
+		expectErr     bool
+		expectedErr   error
+		expectedCalls int
+	}{
+		{"Already done", fastWait, 1, nil, false, nil, 1},
+		{"Eventually done", fastWait, 3, nil, false, nil, 3},
+		{"Check fails", fastWait, 3, failed, true, failed, 1},
+		{"Times out", waiter.Conf{Timeout: 50 * time.Millisecond, Delay: time.Millisecond, MaxDelay: 5 * time.Millisecond}, 1000, nil, true, context.DeadlineExceeded, -1},
+		{"Missing configuration", waiter.Conf{Timeout: time.Second}, 1, nil, true, nil, 0},
+	}
+
+	// Iterate through the test data
+	for _, test := range tests {
+
+		t.Run(test.desc, func(t *testing.T) {
+
+			// Run the test
+			calls := 0
+			check := doneAfter(test.done, &calls)
+			if test.err != nil {
+				check = func(ctx context.Context) (bool, error) {
+					calls++
+					return false, test.err
+				}
+			}
+			err := waiter.Wait(context.Background(), test.conf, "thing fred", "ready", check)
+			if test.expectErr {
+				internal.HasError(t, err)
+				if test.expectedErr != nil {
+					internal.Assert(t, errors.Is(err, test.expectedErr), "expected %v but got %v", test.expectedErr, err)
+				}
+			} else {
+				internal.NoError(t, err)
+			}
+			if test.expectedCalls >= 0 {
+				internal.Equals(t, test.expectedCalls, calls)
+			}
+		})
+	}
+
+	// A cancelled context stops the wait
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	calls := 0
+	err := waiter.Wait(ctx, fastWait, "thing fred", "ready", doneAfter(1000, &calls))
+	internal.HasError(t, err)
+	internal.Assert(t, errors.Is(err, context.Canceled), "expected a cancelled error but got %v", err)
+}
+
+// Test the delay backs off up to the maximum
+func TestWaitBackoff(t *testing.T) {
+
+	// Record when each check happens
+	var times []time.Time
+	conf := waiter.Conf{Timeout: time.Second, Delay: 10 * time.Millisecond, MaxDelay: 20 * time.Millisecond}
+	err := waiter.Wait(context.Background(), conf, "thing fred", "ready", func(ctx context.Context) (bool, error) {
+		times = append(times, time.Now())
+		return len(times) == 4, nil
+	})
+	internal.NoError(t, err)
+
+	// 10ms, 20ms then capped at 20ms
+	for i, min := range []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 20 * time.Millisecond} {
+		gap := times[i+1].Sub(times[i])
+		internal.Assert(t, gap >= min, "check %d came after %v, expected at least %v", i+1, gap, min)
+	}
+}
+
+// Test WithDefaults
+func TestWithDefaults(t *testing.T) {
+
+	defaults := waiter.Conf{Timeout: time.Minute, Delay: time.Second, MaxDelay: 5 * time.Second}
+	internal.Equals(t, defaults, waiter.Conf{}.WithDefaults(defaults))
+	internal.Equals(t, waiter.Conf{Timeout: time.Hour, Delay: time.Second, MaxDelay: 5 * time.Second}, waiter.Conf{Timeout: time.Hour}.WithDefaults(defaults))
+	internal.Equals(t, fastWait, fastWait.WithDefaults(defaults))
+}